@@ -7,6 +7,9 @@
 // unified template using marker lines (--- relative/path/to/file ---) and then renders each
 // file using Go's templating engine with custom delimiters.
 //
+// The generator itself lives in the importable helm/pkg/helmgen package; this
+// command only handles flags, config sources, output and publishing.
+//
 // A new option, -dependencies_enabled, is added to control whether a "dependencies"
 // section is generated in Chart.yaml.
 //
@@ -45,359 +48,400 @@
 package main
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
-	"path/filepath"
+	"os/exec"
+	"strconv"
 	"strings"
-	"text/template"
+	"time"
 
-	"gopkg.in/yaml.v2"
+	"github.com/ViaXLabs/tools/helm/pkg/helmgen"
 )
 
-// Subchart defines a dependency for the umbrella chart.
-type Subchart struct {
-	Name       string `yaml:"name"`
-	Version    string `yaml:"version"`
-	Repository string `yaml:"repository"`
-}
+// Global state shared by the command's helpers.
+var (
+	// logger is the leveled, structured process logger (see -log-format).
+	logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
 
-// ChartData holds all settings read from the YAML configuration.
-type ChartData struct {
-	// Core chart settings.
-	Name                string     `yaml:"name"`
-	ChartVersion        string     `yaml:"chart_version"`
-	AppVersion          string     `yaml:"app_version"`
-	Description         string     `yaml:"description"`
-	ReplicaCount        int        `yaml:"replica_count"`
-	ImageRepository     string     `yaml:"image_repository"`
-	ImageTag            string     `yaml:"image_tag"`
-	ImagePullPolicy     string     `yaml:"image_pull_policy"`
-	ServiceType         string     `yaml:"service_type"`
-	ServicePort         int        `yaml:"service_port"`
-	IngressEnabled      bool       `yaml:"ingress_enabled"`
-	IngressHost         string     `yaml:"ingress_host"`
-	IngressPath         string     `yaml:"ingress_path"`
-	ConfigMapKey        string     `yaml:"configmap_key"`
-	ConfigMapValue      string     `yaml:"configmap_value"`
-	DependenciesEnabled bool       `yaml:"dependencies_enabled"`
-	Subcharts           []Subchart `yaml:"subcharts"`
+	// configHeaders are "Name: value" headers sent when -config is a URL.
+	configHeaders stringList
+)
 
-	// Library chart settings.
-	LibraryEnabled    bool   `yaml:"library_enabled"`
-	LibraryName       string `yaml:"library_name"`
-	LibraryVersion    string `yaml:"library_version"`
-	LibraryRepository string `yaml:"library_repository"`
-}
+// stringList collects repeated command-line flags such as -set.
+type stringList []string
 
-// Global flags.
-var (
-	verbose     bool
-	overwrite   bool
-	limitMode   string // "full" (default) or "core"
-)
+func (l *stringList) String() string { return strings.Join(*l, ",") }
 
-// logVerbose prints log messages when verbose mode is enabled.
-func logVerbose(format string, args ...interface{}) {
-	if verbose {
-		log.Printf(format, args...)
-	}
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
-// loadConfig reads the YAML configuration file and unmarshals it into a ChartData struct.
-func loadConfig(configPath string) ChartData {
-	dataBytes, err := ioutil.ReadFile(configPath)
-	if err != nil {
-		log.Fatalf("Error reading configuration file '%s': %v", configPath, err)
+// readConfigSource returns the raw configuration from a file path, from stdin
+// when the path is "-", or from an HTTP(S) URL. Header values are expanded
+// from the environment so tokens such as $CATALOG_TOKEN stay off the
+// command line.
+func readConfigSource(configPath string) ([]byte, error) {
+	if configPath == "-" {
+		return ioutil.ReadAll(os.Stdin)
 	}
-	var config ChartData
-	if err = yaml.Unmarshal(dataBytes, &config); err != nil {
-		log.Fatalf("Error unmarshalling YAML: %v", err)
+	if !strings.HasPrefix(configPath, "http://") && !strings.HasPrefix(configPath, "https://") {
+		return ioutil.ReadFile(configPath)
 	}
-	if config.Name == "" {
-		log.Fatalf("Configuration error: 'name' must be specified.")
+	req, err := http.NewRequest(http.MethodGet, configPath, nil)
+	if err != nil {
+		return nil, err
 	}
-	logVerbose("Configuration loaded for chart: %s", config.Name)
-	return config
-}
-
-// prepareDirectory creates the output directory (named after the chart).
-// If the directory exists and the -overwrite flag is set, it is removed.
-func prepareDirectory(chartName string) string {
-	baseDir := chartName
-	if _, err := os.Stat(baseDir); err == nil {
-		if overwrite {
-			logVerbose("Directory '%s' exists; removing due to -overwrite flag.", baseDir)
-			if err := os.RemoveAll(baseDir); err != nil {
-				log.Fatalf("Failed to remove directory '%s': %v", baseDir, err)
-			}
-		} else {
-			log.Fatalf("Directory '%s' already exists. Use -overwrite to remove it.", baseDir)
+	for _, header := range configHeaders {
+		colon := strings.IndexByte(header, ':')
+		if colon <= 0 {
+			return nil, fmt.Errorf("invalid -config-header %q: expected 'Name: value'", header)
 		}
+		req.Header.Set(strings.TrimSpace(header[:colon]), os.ExpandEnv(strings.TrimSpace(header[colon+1:])))
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
 	}
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		log.Fatalf("Error creating directory '%s': %v", baseDir, err)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
 	}
-	logVerbose("Created base directory: %s", baseDir)
-	return baseDir
+	logger.Debug("fetched configuration", "url", configPath)
+	return ioutil.ReadAll(resp.Body)
 }
 
-// parseUnifiedTemplate splits the unified template content into a map,
-// where keys are relative file paths and values are the template content.
-func parseUnifiedTemplate(content string) map[string]string {
-	result := make(map[string]string)
-	lines := strings.Split(content, "\n")
-	var currentKey string
-	var currentLines []string
-	markerPrefix := "---"
-	for _, line := range lines {
-		trim := strings.TrimSpace(line)
-		if strings.HasPrefix(trim, markerPrefix) && strings.HasSuffix(trim, markerPrefix) {
-			if currentKey != "" && len(currentLines) > 0 {
-				result[currentKey] = strings.Join(currentLines, "\n")
-			}
-			trimmed := strings.TrimPrefix(trim, markerPrefix)
-			trimmed = strings.TrimSuffix(trimmed, markerPrefix)
-			currentKey = strings.TrimSpace(trimmed)
-			currentLines = []string{}
-		} else if currentKey != "" {
-			currentLines = append(currentLines, line)
-		}
+// updateDependencies runs `helm dependency update` on chartDir, producing
+// Chart.lock and charts/*.tgz. The helm binary is taken from $HELM_BIN or PATH.
+func updateDependencies(chartDir string) error {
+	helmBin := os.Getenv("HELM_BIN")
+	if helmBin == "" {
+		helmBin = "helm"
 	}
-	if currentKey != "" && len(currentLines) > 0 {
-		result[currentKey] = strings.Join(currentLines, "\n")
+	path, err := exec.LookPath(helmBin)
+	if err != nil {
+		return fmt.Errorf("helm binary not found (set HELM_BIN or add helm to PATH): %w", err)
 	}
-	return result
+	cmd := exec.Command(path, "dependency", "update", chartDir)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	logger.Debug("running helm", "args", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s dependency update: %w", helmBin, err)
+	}
+	logger.Info("updated chart dependencies", "chart", chartDir)
+	return nil
 }
 
-// processUnifiedTemplates processes and writes out each file from the unified template.
-// If limitMode is "core", only essential (core) templates are generated.
-func processUnifiedTemplates(data ChartData, baseDir string) {
-	templatesMap := parseUnifiedTemplate(allTemplates)
-	for relPath, tmplContent := range templatesMap {
-		// In "core" mode, skip non-core files.
-		if limitMode == "core" {
-			if strings.HasPrefix(relPath, "charts/") ||
-				relPath == "templates/ingress.yaml" ||
-				relPath == "templates/configmap.yaml" {
-				logVerbose("Skipping template due to limited core output: %s", relPath)
-				continue
-			}
+// packageChart writes the chart files in fsys as <name>-<version>.tgz in the
+// current directory, laid out like `helm package` output, and returns its path.
+func packageChart(fsys fs.FS, name, version string) (string, error) {
+	modTime, err := packageModTime()
+	if err != nil {
+		return "", err
+	}
+	pkgPath := fmt.Sprintf("%s-%s.tgz", name, version)
+	out, err := os.Create(pkgPath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	err = fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
 		}
-		// Always skip library chart files if LibraryEnabled is false.
-		if strings.HasPrefix(relPath, "charts/") && !data.LibraryEnabled {
-			logVerbose("Skipping library template file: %s", relPath)
-			continue
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
 		}
-		// Also skip ingress file if ingress is disabled.
-		if relPath == "templates/ingress.yaml" && !data.IngressEnabled {
-			logVerbose("Skipping ingress template (ingress_enabled is false).")
-			continue
+		header := &tar.Header{
+			Name:    name + "/" + filePath,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: modTime,
 		}
-		requiresReplacement := strings.Contains(tmplContent, "__CHART_NAME__")
-		outPath := filepath.Join(baseDir, relPath)
-		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
-			log.Fatalf("Error creating directory for file '%s': %v", outPath, err)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
 		}
-		logVerbose("Generating file: %s", outPath)
-		generateFile(outPath, tmplContent, data, requiresReplacement)
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	logger.Debug("packaged chart", "package", pkgPath)
+	return pkgPath, nil
+}
+
+// packageModTime returns the timestamp recorded for every file in a package:
+// $SOURCE_DATE_EPOCH when set, else the Unix epoch, so packaging the same
+// chart twice yields the same archive.
+func packageModTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH '%s': %w", epoch, err)
 	}
+	return time.Unix(seconds, 0).UTC(), nil
 }
 
-// generateFile renders a single template string using custom delimiters and writes it to a file.
-func generateFile(path, tmplStr string, data ChartData, replaceChartName bool) {
-	tmpl, err := template.New("file").
-		Funcs(template.FuncMap{
-			"or": func(a, b bool) bool { return a || b },
-			"gt": func(a, b int) bool { return a > b },
-		}).
-		Delims("<<", ">>").
-		Parse(tmplStr)
+// publishChart uploads a packaged chart to a ChartMuseum server. Credentials
+// follow the helm cm-push plugin conventions: HELM_REPO_ACCESS_TOKEN for a
+// bearer token, or HELM_REPO_USERNAME/HELM_REPO_PASSWORD for basic auth.
+func publishChart(repoURL, pkgPath string) error {
+	content, err := ioutil.ReadFile(pkgPath)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(repoURL, "/")
+	if !strings.HasSuffix(endpoint, "/api/charts") {
+		endpoint += "/api/charts"
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(content))
 	if err != nil {
-		log.Fatalf("Error parsing template for '%s': %v", path, err)
+		return err
 	}
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		log.Fatalf("Error executing template for '%s': %v", path, err)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if token := os.Getenv("HELM_REPO_ACCESS_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if user := os.Getenv("HELM_REPO_USERNAME"); user != "" {
+		req.SetBasicAuth(user, os.Getenv("HELM_REPO_PASSWORD"))
 	}
-	outContent := buf.String()
-	if replaceChartName {
-		outContent = strings.ReplaceAll(outContent, "__CHART_NAME__", data.Name)
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
 	}
-	if err := os.WriteFile(path, []byte(outContent), 0644); err != nil {
-		log.Fatalf("Error writing file '%s': %v", path, err)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("ChartMuseum returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
 	}
-	logVerbose("File successfully written: %s", path)
+	logger.Info("uploaded chart", "package", pkgPath, "endpoint", endpoint)
+	return nil
+}
+
+// cliOptions holds the parsed command-line flags.
+type cliOptions struct {
+	configFile    string
+	overrides     stringList
+	overwrite     bool
+	limit         string
+	generator     helmgen.GeneratorConfig
+	publishURL    string
+	fromChart     string
+	fromManifests string
+	depUpdate     bool
+	diffDir       string
+	vendor        bool
+	renderCheck   bool
+	workers       int
+	printSchema   bool
+}
+
+// newLogger builds the process logger for the requested format and level.
+func newLogger(format, level string, verbose bool) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level '%s': use debug, info, warn or error", level)
+	}
+	if verbose {
+		lvl = slog.LevelDebug
+	}
+	handlerOpts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, handlerOpts)), nil
+	}
+	return nil, fmt.Errorf("invalid -log-format '%s': use text or json", format)
 }
 
 func main() {
 	// Define command-line flags.
-	configFile := flag.String("config", "config.yaml", "Path to YAML configuration file")
-	flag.BoolVar(&overwrite, "overwrite", false, "Overwrite existing chart directory if it exists")
-	flag.BoolVar(&verbose, "verbose", false, "Enable verbose logging")
-	flag.StringVar(&limitMode, "limit", "full", "Output mode: 'full' for all files or 'core' for essential files only")
+	var opts cliOptions
+	flag.StringVar(&opts.configFile, "config", "config.yaml", "Path to YAML configuration file, '-' for stdin, or an http(s):// URL")
+	flag.Var(&configHeaders, "config-header", "HTTP header 'Name: value' sent when -config is a URL; $VARS are expanded from the environment (repeatable)")
+	flag.BoolVar(&opts.overwrite, "overwrite", false, "Overwrite existing chart directory if it exists")
+	verbose := flag.Bool("verbose", false, "Enable verbose logging (same as -log-level debug)")
+	logFormat := flag.String("log-format", "text", "Log output format: 'text' or 'json'")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error")
+	flag.StringVar(&opts.limit, "limit", "full", "Output mode: 'full' for all files or 'core' for essential files only")
+	flag.Var(&opts.overrides, "set", "Override a config value after loading, e.g. -set image_tag=1.2.3 or -set subcharts[0].version=0.3.0 (repeatable)")
+	flag.StringVar(&opts.generator.TemplateFile, "template", "", "Custom unified template file to render instead of the embedded one")
+	flag.StringVar(&opts.generator.LeftDelim, "left-delim", "", "Left generator delimiter of the custom template (default <<)")
+	flag.StringVar(&opts.generator.RightDelim, "right-delim", "", "Right generator delimiter of the custom template (default >>)")
+	flag.StringVar(&opts.generator.MarkerPrefix, "marker-prefix", "", "File marker prefix of the custom template (default ---)")
+	flag.StringVar(&opts.generator.MarkerSuffix, "marker-suffix", "", "File marker suffix of the custom template (default: same as prefix)")
+	flag.StringVar(&opts.generator.LineEndings, "line-endings", "", "Line endings of generated files: 'lf' (default) or 'crlf'")
+	flag.StringVar(&opts.diffDir, "diff", "", "Print unified diffs between this existing chart directory and the newly rendered chart instead of writing it")
+	flag.IntVar(&opts.workers, "workers", 0, "Maximum number of files rendered concurrently (default: number of CPUs)")
+	flag.BoolVar(&opts.renderCheck, "render-check", true, "Render the chart with the Helm template engine before writing it and fail on template or YAML errors")
+	flag.BoolVar(&opts.vendor, "vendor", false, "Download every subchart into charts/ and point its repository at file:// for air-gapped installs")
+	flag.BoolVar(&opts.depUpdate, "dep-update", false, "Run 'helm dependency update' on the generated chart to produce Chart.lock and charts/*.tgz (helm from $HELM_BIN or PATH)")
+	flag.StringVar(&opts.publishURL, "publish", "", "Package the chart and upload it to this ChartMuseum URL")
+	flag.StringVar(&opts.fromChart, "from-chart", "", "Derive a config.yaml from an existing chart directory and print it to stdout")
+	flag.StringVar(&opts.fromManifests, "from-manifests", "", "Derive a config.yaml from a directory of Kubernetes manifests and print it to stdout")
+	flag.BoolVar(&opts.printSchema, "print-config-schema", false, "Print a JSON Schema of the configuration file to stdout and exit")
 	flag.Parse()
 
-	// Load configuration.
-	configData := loadConfig(*configFile)
-	// Prepare the output directory.
-	baseDir := prepareDirectory(configData.Name)
-	// Process the unified template and generate files.
-	processUnifiedTemplates(configData, baseDir)
-
-	fmt.Printf("Helm umbrella chart '%s' generated successfully in directory '%s'.\n", configData.Name, baseDir)
+	var err error
+	if logger, err = newLogger(*logFormat, *logLevel, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := run(opts); err != nil {
+		logger.Error("helm chart generation failed", "error", err)
+		os.Exit(1)
+	}
 }
 
-//
-// --- Unified Template ---
-// All file templates are embedded below in one single block.
-// Marker lines of the format: --- relative/path/to/file --- separate each file's content.
-const allTemplates = `--- Chart.yaml ---
-apiVersion: v2
-name: <<.Name>>
-description: <<.Description>>
-type: application
-version: <<.ChartVersion>>
-appVersion: "<<.AppVersion>>"
-<<- if .DependenciesEnabled >>
-dependencies:
-<<- if .LibraryEnabled >>
-- name: <<.LibraryName>>
-  version: "<<.LibraryVersion>>"
-  repository: "<<.LibraryRepository>>"
-<<- end >>
-<<- range .Subcharts >>
-- name: <<.Name>>
-  version: "<<.Version>>"
-  repository: "<<.Repository>>"
-<<- end >>
-<<- end >>
---- values.yaml ---
-replicaCount: <<.ReplicaCount>>
+// run executes one invocation of the tool; every failure is returned to main
+// so it is logged once, with its full context.
+func run(opts cliOptions) error {
+	// Self-check the embedded template before doing any work.
+	if err := helmgen.CheckEmbeddedTemplate(); err != nil {
+		return err
+	}
 
-image:
-  repository: <<.ImageRepository>>
-  pullPolicy: <<.ImagePullPolicy>>
-  tag: "<<.ImageTag>>"
+	// Schema mode: describe the configuration file instead of reading one.
+	if opts.printSchema {
+		schema, err := helmgen.ConfigSchema()
+		if err != nil {
+			return fmt.Errorf("building configuration schema: %w", err)
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", schema)
+		return err
+	}
 
-service:
-  type: <<.ServiceType>>
-  port: <<.ServicePort>>
+	// Reverse mode: emit a config instead of generating a chart.
+	if opts.fromChart != "" && opts.fromManifests != "" {
+		return fmt.Errorf("use only one of -from-chart and -from-manifests")
+	}
+	if opts.fromChart != "" || opts.fromManifests != "" {
+		var derived helmgen.ChartData
+		var err error
+		if opts.fromChart != "" {
+			derived, err = helmgen.DeriveConfigFromChart(opts.fromChart, logger)
+		} else {
+			derived, err = helmgen.DeriveConfigFromManifests(opts.fromManifests, logger)
+		}
+		if err != nil {
+			return fmt.Errorf("deriving configuration: %w", err)
+		}
+		return helmgen.WriteDerivedConfig(derived, os.Stdout)
+	}
 
-# Optional overrides
-nameOverride: ""
-fullnameOverride: ""
---- templates/_helpers.tpl ---
-{{/*
-Return the base chart name.
-*/}}
-{{- define "__CHART_NAME__.name" -}}
-{{- default .Chart.Name .Values.nameOverride | trunc 63 | trimSuffix "-" -}}
-{{- end -}}
+	// Load configuration.
+	rawConfig, err := readConfigSource(opts.configFile)
+	if err != nil {
+		return fmt.Errorf("reading configuration '%s': %w", opts.configFile, err)
+	}
+	configData, err := helmgen.LoadConfig(rawConfig, opts.overrides)
+	if err != nil {
+		return fmt.Errorf("loading configuration '%s': %w", opts.configFile, err)
+	}
+	// Command-line generator settings override the configuration.
+	for _, override := range []struct{ flagValue, configValue *string }{
+		{&opts.generator.TemplateFile, &configData.Generator.TemplateFile},
+		{&opts.generator.LeftDelim, &configData.Generator.LeftDelim},
+		{&opts.generator.RightDelim, &configData.Generator.RightDelim},
+		{&opts.generator.MarkerPrefix, &configData.Generator.MarkerPrefix},
+		{&opts.generator.MarkerSuffix, &configData.Generator.MarkerSuffix},
+		{&opts.generator.LineEndings, &configData.Generator.LineEndings},
+	} {
+		if *override.flagValue != "" {
+			*override.configValue = *override.flagValue
+		}
+	}
+	// Render the chart in memory, then write it to a directory named after it.
+	chart, err := helmgen.Generate(configData, helmgen.Options{Limit: opts.limit, Logger: logger, Vendor: opts.vendor, Workers: opts.workers})
+	if err != nil {
+		return fmt.Errorf("generating chart: %w", err)
+	}
+	// Diff mode: compare against an existing chart without touching disk.
+	if opts.diffDir != "" {
+		changed, err := helmgen.Diff(chart.FS(), opts.diffDir, os.Stdout)
+		if err != nil {
+			return fmt.Errorf("diffing against '%s': %w", opts.diffDir, err)
+		}
+		if !changed {
+			logger.Info("no changes", "chart", opts.diffDir)
+		}
+		return nil
+	}
+	// Render the chart like `helm template` before writing it, so a broken
+	// chart never reaches disk.
+	if opts.renderCheck {
+		if _, err := helmgen.RenderCheck(chart.FS(), logger); err != nil {
+			return fmt.Errorf("render check of '%s' failed: %w", chart.Name, err)
+		}
+	}
+	baseDir := chart.Name
+	if _, err := os.Stat(baseDir); err == nil && !opts.overwrite {
+		return fmt.Errorf("directory '%s' already exists; use -overwrite to remove it", baseDir)
+	}
+	if err := helmgen.Write(chart.FS(), baseDir, opts.overwrite); err != nil {
+		return fmt.Errorf("writing chart: %w", err)
+	}
 
-{{/*
-Return a fully-qualified name.
-*/}}
-{{- define "__CHART_NAME__.fullname" -}}
-{{- if .Values.fullnameOverride -}}
-  {{- .Values.fullnameOverride | trunc 63 | trimSuffix "-" -}}
-{{- else -}}
-  {{- $name := default .Chart.Name .Values.nameOverride -}}
-  {{- printf "%s-%s" .Release.Name $name | trunc 63 | trimSuffix "-" -}}
-{{- end -}}
-{{- end -}}
---- templates/deployment.yaml ---
-apiVersion: apps/v1
-kind: Deployment
-metadata:
-  name: {{ include "__CHART_NAME__.fullname" . }}
-  labels:
-    app: {{ include "__CHART_NAME__.name" . }}
-spec:
-  replicas: <<ReplicaCount>>
-  selector:
-    matchLabels:
-      app: {{ include "__CHART_NAME__.name" . }}
-  template:
-    metadata:
-      labels:
-        app: {{ include "__CHART_NAME__.name" . }}
-    spec:
-      containers:
-      - name: {{ include "__CHART_NAME__.name" . }}
-        image: "<<ImageRepository>>:<<ImageTag>>"
-        imagePullPolicy: <<ImagePullPolicy>>
-        ports:
-        - containerPort: <<ServicePort>>
---- templates/service.yaml ---
-apiVersion: v1
-kind: Service
-metadata:
-  name: {{ include "__CHART_NAME__.fullname" . }}
-  labels:
-    app: {{ include "__CHART_NAME__.name" . }}
-spec:
-  type: <<.ServiceType>>
-  ports:
-  - port: <<.ServicePort>>
-    targetPort: <<.ServicePort>>
-    protocol: TCP
-    name: http
-  selector:
-    app: {{ include "__CHART_NAME__.name" . }}
---- templates/ingress.yaml ---
-apiVersion: networking.k8s.io/v1
-kind: Ingress
-metadata:
-  name: {{ include "__CHART_NAME__.fullname" . }}
-  labels:
-    app: {{ include "__CHART_NAME__.name" . }}
-spec:
-  rules:
-  - host: <<.IngressHost>>
-    http:
-      paths:
-      - path: <<.IngressPath>>
-        pathType: ImplementationSpecific
-        backend:
-          service:
-            name: {{ include "__CHART_NAME__.fullname" . }}
-            port:
-              number: <<.ServicePort>>
---- templates/configmap.yaml ---
-apiVersion: v1
-kind: ConfigMap
-metadata:
-  name: {{ include "__CHART_NAME__.fullname" . }}-config
-  labels:
-    app: {{ include "__CHART_NAME__.name" . }}
-data:
-  <<.ConfigMapKey>>: "<<.ConfigMapValue>>"
---- charts/library/Chart.yaml ---
-apiVersion: v2
-name: <<.LibraryName>>
-description: "Library chart containing common helper templates."
-type: library
-version: <<.LibraryVersion>>
---- charts/library/values.yaml ---
-# Library chart values (customize if needed).
---- charts/library/templates/_helpers.tpl ---
-{{/*
-Common helper functions for shared usage.
-Part of the library chart.
-*/}}
-{{- define "common.name" -}}
-{{- default .Chart.Name .Values.nameOverride | trunc 63 | trimSuffix "-" -}}
-{{- end -}}
-{{- define "common.fullname" -}}
-{{- if .Values.fullnameOverride -}}
-  {{- .Values.fullnameOverride | trunc 63 | trimSuffix "-" -}}
-{{- else -}}
-  {{- $name := default .Chart.Name .Values.nameOverride -}}
-  {{- printf "%s-%s" .Release.Name $name | trunc 63 | trimSuffix "-" -}}
-{{- end -}}
-{{- end -}}
-`
+	// Optionally resolve dependencies; the package then comes from disk so
+	// it includes Chart.lock and the downloaded subcharts.
+	chartFS := chart.FS()
+	if opts.depUpdate {
+		if configData.DependenciesEnabled {
+			if err := updateDependencies(baseDir); err != nil {
+				return fmt.Errorf("updating dependencies: %w", err)
+			}
+			chartFS = os.DirFS(baseDir)
+			// Render again now that the subcharts are there.
+			if opts.renderCheck {
+				if _, err := helmgen.RenderCheck(chartFS, logger); err != nil {
+					return fmt.Errorf("render check of '%s' with its dependencies failed: %w", baseDir, err)
+				}
+			}
+		} else {
+			logger.Warn("-dep-update ignored: dependencies_enabled is false")
+		}
+	}
+	if opts.renderCheck {
+		logger.Info("render check passed", "chart", baseDir)
+	}
+
+	fmt.Printf("Helm umbrella chart '%s' generated successfully in directory '%s'.\n", chart.Name, baseDir)
+
+	// Optionally package and publish the chart.
+	if opts.publishURL != "" {
+		pkgPath, err := packageChart(chartFS, chart.Name, chart.Version)
+		if err != nil {
+			return fmt.Errorf("packaging chart: %w", err)
+		}
+		if err := publishChart(opts.publishURL, pkgPath); err != nil {
+			return fmt.Errorf("publishing chart to '%s': %w", opts.publishURL, err)
+		}
+		fmt.Printf("Published %s to %s.\n", pkgPath, opts.publishURL)
+	}
+	return nil
+}
@@ -0,0 +1,1256 @@
+package helmgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Subchart defines a dependency for the umbrella chart.
+type Subchart struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Repository string `yaml:"repository"`
+	Alias      string `yaml:"alias"`
+	// Condition is a values path (or comma-separated paths) that enables the
+	// subchart, e.g. redis.enabled.
+	Condition string   `yaml:"condition"`
+	Tags      []string `yaml:"tags"`
+	// Enabled is the initial value of the condition flag; setting it without
+	// a condition implies <key>.enabled.
+	Enabled *bool `yaml:"enabled"`
+	// Common subchart settings, written as replicaCount and image.repository/tag.
+	ReplicaCount    int    `yaml:"replica_count"`
+	ImageRepository string `yaml:"image_repository"`
+	ImageTag        string `yaml:"image_tag"`
+	// Values are written under the subchart's key in the umbrella values.yaml.
+	Values map[string]interface{} `yaml:"values"`
+}
+
+// Key returns the name under which the subchart reads its values: the alias
+// if set, otherwise the chart name.
+func (s Subchart) Key() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// HasSettings reports whether replica_count or an image_* setting is given.
+func (s Subchart) HasSettings() bool {
+	return s.ReplicaCount != 0 || s.ImageRepository != "" || s.ImageTag != ""
+}
+
+// conditionFlag returns the first condition path below the subchart's own
+// values relative to its key, or "" if there is none.
+func (s Subchart) conditionFlag() string {
+	flag := strings.TrimSpace(strings.Split(s.Condition, ",")[0])
+	return strings.TrimPrefix(flag, s.Key()+".")
+}
+
+// Maintainer is a chart maintainer listed in Chart.yaml.
+type Maintainer struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email,omitempty"`
+	URL   string `yaml:"url,omitempty"`
+}
+
+// IstioSubset defines a named DestinationRule subset selected by pod labels.
+// The chart's default route goes to the whole Service, since its own pods
+// carry no subset labels; subsets are for routes added by hand or by
+// progressive delivery tooling.
+type IstioSubset struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// IstioGateway controls whether the chart creates its own Istio Gateway.
+type IstioGateway struct {
+	Create        bool              `yaml:"create"`
+	Selector      map[string]string `yaml:"selector"`
+	Port          int               `yaml:"port"`
+	TLSCredential string            `yaml:"tls_credential"` // Secret name; enables an HTTPS server when set.
+}
+
+// IstioConfig holds settings for the Istio networking resources
+// (Gateway, VirtualService and DestinationRule).
+type IstioConfig struct {
+	Enabled       bool                   `yaml:"enabled"`
+	Gateway       IstioGateway           `yaml:"gateway"`
+	Gateways      []string               `yaml:"gateways"` // Existing gateways to bind to, e.g. istio-system/shared-gateway.
+	Hosts         []string               `yaml:"hosts"`
+	TrafficPolicy map[string]interface{} `yaml:"traffic_policy"`
+	Subsets       []IstioSubset          `yaml:"subsets"`
+}
+
+// GatewayParentRef points an HTTPRoute at a Gateway (or one of its listeners).
+type GatewayParentRef struct {
+	Name        string `yaml:"name"`
+	Namespace   string `yaml:"namespace"`
+	SectionName string `yaml:"section_name"`
+}
+
+// HTTPRouteRule routes one path to the chart's Service. Filters accepts raw
+// Gateway API HTTPRouteFilter entries rendered verbatim.
+type HTTPRouteRule struct {
+	Path     string                   `yaml:"path"`
+	PathType string                   `yaml:"path_type"` // PathPrefix (default), Exact or RegularExpression.
+	Port     int                      `yaml:"port"`      // defaults to service_port.
+	Filters  []map[string]interface{} `yaml:"filters"`
+}
+
+// GatewayAPIConfig holds settings for a Gateway API HTTPRoute, an
+// alternative to the Ingress.
+type GatewayAPIConfig struct {
+	Enabled    bool               `yaml:"enabled"`
+	ParentRefs []GatewayParentRef `yaml:"parent_refs"`
+	Hostnames  []string           `yaml:"hostnames"`
+	Rules      []HTTPRouteRule    `yaml:"rules"`
+}
+
+// ExternalSecretData maps one key of the generated Secret to a remote key
+// (and optional property) in the external secret store.
+type ExternalSecretData struct {
+	SecretKey string `yaml:"secret_key"`
+	RemoteKey string `yaml:"remote_key"`
+	Property  string `yaml:"property"`
+}
+
+// ExternalSecretsConfig holds settings for the External Secrets Operator
+// ExternalSecret that replaces a plain Kubernetes Secret.
+type ExternalSecretsConfig struct {
+	Enabled         bool                 `yaml:"enabled"`
+	SecretStore     string               `yaml:"secret_store"`
+	SecretStoreKind string               `yaml:"secret_store_kind"` // SecretStore (default) or ClusterSecretStore.
+	RefreshInterval string               `yaml:"refresh_interval"`
+	Data            []ExternalSecretData `yaml:"data"`
+}
+
+// KubesealConfig tells the generator how to reach kubeseal when plain
+// secret values must be sealed at generation time.
+type KubesealConfig struct {
+	Binary              string `yaml:"binary"`               // Defaults to "kubeseal" on PATH.
+	Cert                string `yaml:"cert"`                 // Offline public certificate; preferred in CI.
+	ControllerName      string `yaml:"controller_name"`      // Used when no cert is given.
+	ControllerNamespace string `yaml:"controller_namespace"` // Used when no cert is given.
+}
+
+// SealedSecretsConfig holds settings for the Bitnami SealedSecret manifest.
+// EncryptedData carries values that were sealed ahead of time; PlainData is
+// sealed with kubeseal during generation and never written out in clear text.
+type SealedSecretsConfig struct {
+	Enabled       bool              `yaml:"enabled"`
+	SecretName    string            `yaml:"secret_name"` // Defaults to the chart name.
+	Namespace     string            `yaml:"namespace"`
+	Scope         string            `yaml:"scope"` // strict (default), namespace-wide or cluster-wide.
+	EncryptedData map[string]string `yaml:"encrypted_data"`
+	PlainData     map[string]string `yaml:"plain_data"`
+	Kubeseal      KubesealConfig    `yaml:"kubeseal"`
+}
+
+// IssuerRef points a Certificate at a cert-manager Issuer or ClusterIssuer.
+type IssuerRef struct {
+	Name string `yaml:"name"`
+	Kind string `yaml:"kind"` // Issuer (default) or ClusterIssuer.
+}
+
+// IssuerConfig optionally creates an ACME Issuer alongside the Certificate.
+type IssuerConfig struct {
+	Create       bool   `yaml:"create"`
+	ACMEEmail    string `yaml:"acme_email"`
+	ACMEServer   string `yaml:"acme_server"`
+	IngressClass string `yaml:"ingress_class"` // Class used for HTTP-01 solving.
+}
+
+// CertificateConfig holds settings for the cert-manager Certificate whose
+// secret is wired into the ingress TLS section.
+type CertificateConfig struct {
+	Enabled    bool         `yaml:"enabled"`
+	SecretName string       `yaml:"secret_name"` // Defaults to <name>-tls.
+	DNSNames   []string     `yaml:"dns_names"`   // Defaults to ingress_host.
+	IssuerRef  IssuerRef    `yaml:"issuer_ref"`
+	Issuer     IssuerConfig `yaml:"issuer"`
+}
+
+// CloudIdentityConfig maps the workload to a cloud IAM identity through
+// ServiceAccount annotations (AWS IRSA, GCP Workload Identity, Azure
+// Workload Identity).
+type CloudIdentityConfig struct {
+	AWSRoleARN        string `yaml:"aws_role_arn"`
+	GCPServiceAccount string `yaml:"gcp_service_account"`
+	AzureClientID     string `yaml:"azure_client_id"`
+	AzureTenantID     string `yaml:"azure_tenant_id"`
+}
+
+// Configured reports whether any cloud identity is set.
+func (c CloudIdentityConfig) Configured() bool {
+	return c.AWSRoleARN != "" || c.GCPServiceAccount != "" || c.AzureClientID != ""
+}
+
+// Annotations returns the ServiceAccount annotations for every configured cloud.
+func (c CloudIdentityConfig) Annotations() map[string]string {
+	annotations := make(map[string]string)
+	if c.AWSRoleARN != "" {
+		annotations["eks.amazonaws.com/role-arn"] = c.AWSRoleARN
+	}
+	if c.GCPServiceAccount != "" {
+		annotations["iam.gke.io/gcp-service-account"] = c.GCPServiceAccount
+	}
+	if c.AzureClientID != "" {
+		annotations["azure.workload.identity/client-id"] = c.AzureClientID
+		if c.AzureTenantID != "" {
+			annotations["azure.workload.identity/tenant-id"] = c.AzureTenantID
+		}
+	}
+	return annotations
+}
+
+// TopologySpreadConstraint spreads the workload's pods across a topology
+// domain. The label selector always targets the chart's own pods.
+type TopologySpreadConstraint struct {
+	MaxSkew           int    `yaml:"max_skew"`
+	TopologyKey       string `yaml:"topology_key"`
+	WhenUnsatisfiable string `yaml:"when_unsatisfiable"`
+}
+
+// Container describes an additional first-class container that runs next
+// to the main container in the same pod.
+type Container struct {
+	Name            string   `yaml:"name"`
+	ImageRepository string   `yaml:"image_repository"`
+	ImageTag        string   `yaml:"image_tag"`
+	ImagePullPolicy string   `yaml:"image_pull_policy"`
+	Port            int      `yaml:"port"`
+	Expose          bool     `yaml:"expose"` // Adds the port to the Service.
+	Command         []string `yaml:"command"`
+	Args            []string `yaml:"args"`
+	WorkingDir      string   `yaml:"working_dir"`
+}
+
+// ProbeConfig adds liveness and readiness probes to the main container.
+type ProbeConfig struct {
+	Type string `yaml:"type"` // http, grpc or tcp; empty disables probes.
+	Path string `yaml:"path"` // HTTP path; defaults to /.
+	Port int    `yaml:"port"` // defaults to service_port.
+}
+
+// CronJobConfig holds the schedule of the cronjob profile.
+type CronJobConfig struct {
+	Schedule          string `yaml:"schedule"`
+	ConcurrencyPolicy string `yaml:"concurrency_policy"` // Allow, Forbid (default) or Replace.
+	// BackoffLimit is the number of retries of a run, 0 for none; unset
+	// keeps the Kubernetes default of 6.
+	BackoffLimit *int `yaml:"backoff_limit"`
+}
+
+// AutoscalingConfig holds settings for the HorizontalPodAutoscaler. Metrics
+// accepts raw autoscaling/v2 MetricSpec entries (Pods, Object, External,
+// ContainerResource) that are rendered verbatim next to the CPU/memory targets.
+type AutoscalingConfig struct {
+	Enabled                 bool                     `yaml:"enabled"`
+	MinReplicas             int                      `yaml:"min_replicas"`
+	MaxReplicas             int                      `yaml:"max_replicas"`
+	TargetCPUUtilization    int                      `yaml:"target_cpu_utilization"`
+	TargetMemoryUtilization int                      `yaml:"target_memory_utilization"`
+	Metrics                 []map[string]interface{} `yaml:"metrics"`
+}
+
+// KedaTrigger is a single KEDA scaler definition, e.g. kafka or prometheus.
+type KedaTrigger struct {
+	Type              string            `yaml:"type"`
+	Metadata          map[string]string `yaml:"metadata"`
+	AuthenticationRef string            `yaml:"authentication_ref"` // Name of a TriggerAuthentication.
+}
+
+// KedaConfig holds settings for the KEDA ScaledObject, an event-driven
+// alternative to the HorizontalPodAutoscaler.
+type KedaConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	MinReplicas     int           `yaml:"min_replicas"`
+	MaxReplicas     int           `yaml:"max_replicas"`
+	PollingInterval int           `yaml:"polling_interval"`
+	CooldownPeriod  int           `yaml:"cooldown_period"`
+	Triggers        []KedaTrigger `yaml:"triggers"`
+}
+
+// VPAUpdatePolicy controls how the VerticalPodAutoscaler applies its
+// recommendations. The default "Off" mode only publishes recommendations.
+type VPAUpdatePolicy struct {
+	UpdateMode          string            `yaml:"update_mode"` // Off (default), Initial, Recreate or Auto.
+	MinAllowed          map[string]string `yaml:"min_allowed"`
+	MaxAllowed          map[string]string `yaml:"max_allowed"`
+	ControlledResources []string          `yaml:"controlled_resources"`
+}
+
+// StrategyConfig holds the Deployment update strategy. MaxSurge and
+// MaxUnavailable accept absolute numbers ("1") or percentages ("25%").
+type StrategyConfig struct {
+	Type           string `yaml:"type"` // RollingUpdate (default) or Recreate.
+	MaxSurge       string `yaml:"max_surge"`
+	MaxUnavailable string `yaml:"max_unavailable"`
+}
+
+// NamespaceConfig controls the optional Namespace manifest for umbrella
+// charts that own their namespace.
+type NamespaceConfig struct {
+	Create      bool              `yaml:"create"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// ResourceQuotaConfig holds the hard limits of the namespace ResourceQuota.
+type ResourceQuotaConfig struct {
+	Enabled bool              `yaml:"enabled"`
+	Hard    map[string]string `yaml:"hard"` // e.g. requests.cpu: "10", pods: "50".
+}
+
+// LimitRangeConfig holds per-container defaults and bounds for the
+// namespace LimitRange.
+type LimitRangeConfig struct {
+	Enabled        bool              `yaml:"enabled"`
+	Default        map[string]string `yaml:"default"`
+	DefaultRequest map[string]string `yaml:"default_request"`
+	Max            map[string]string `yaml:"max"`
+	Min            map[string]string `yaml:"min"`
+}
+
+// ConfigMapConfig is one ConfigMap of the chart, named <fullname>-<name>.
+// Values come from data and from the contents of local files.
+type ConfigMapConfig struct {
+	Name      string            `yaml:"name"`
+	Data      map[string]string `yaml:"data"`
+	Files     map[string]string `yaml:"files"`      // key -> path of a file whose content becomes the value.
+	MountPath string            `yaml:"mount_path"` // mounts the ConfigMap into the main container when set.
+}
+
+// HookJob is a Job run by Helm at a lifecycle event, e.g. a database
+// migration before install and upgrade. The image defaults to the chart's.
+type HookJob struct {
+	Name            string   `yaml:"name"`
+	Events          []string `yaml:"events"`        // helm.sh/hook values, e.g. pre-install, pre-upgrade.
+	Weight          int      `yaml:"weight"`        // helm.sh/hook-weight; lower runs first.
+	DeletePolicy    []string `yaml:"delete_policy"` // helm.sh/hook-delete-policy; default before-hook-creation.
+	ImageRepository string   `yaml:"image_repository"`
+	ImageTag        string   `yaml:"image_tag"`
+	ImagePullPolicy string   `yaml:"image_pull_policy"`
+	Command         []string `yaml:"command"`
+	Args            []string `yaml:"args"`
+	// BackoffLimit is the number of retries, 0 for none; unset keeps the
+	// Kubernetes default of 6.
+	BackoffLimit   *int `yaml:"backoff_limit"`
+	ActiveDeadline int  `yaml:"active_deadline_seconds"`
+}
+
+// ExtraManifest is a raw manifest copied verbatim into templates/, given
+// either inline as content or as a file path.
+type ExtraManifest struct {
+	Name    string `yaml:"name"` // file name under templates/; defaults to the base name of file.
+	File    string `yaml:"file"`
+	Content string `yaml:"content"`
+}
+
+// HelmfileEnvironment is a helmfile environment; its values are written to
+// environments/<name>.yaml and layered over the chart's values.yaml.
+type HelmfileEnvironment struct {
+	Name   string                 `yaml:"name"`
+	Values map[string]interface{} `yaml:"values"`
+}
+
+// HelmfileConfig controls the optional helmfile.yaml.gotmpl that installs the chart.
+type HelmfileConfig struct {
+	Enabled      bool                  `yaml:"enabled"`
+	ReleaseName  string                `yaml:"release_name"` // defaults to the chart name.
+	Namespace    string                `yaml:"namespace"`    // defaults to namespace_override.
+	Environments []HelmfileEnvironment `yaml:"environments"` // defaults to a single "default" environment.
+	// Secrets adds environments/<name>.secrets.yaml (helm-secrets) to the release.
+	Secrets bool `yaml:"secrets"`
+}
+
+// GeneratorConfig points the generator at a custom unified template and
+// describes its syntax. The embedded template always uses << >> and
+// --- path --- markers; other delimiters only apply to a template_file.
+type GeneratorConfig struct {
+	TemplateFile string `yaml:"template_file"`
+	LeftDelim    string `yaml:"left_delim"`
+	RightDelim   string `yaml:"right_delim"`
+	MarkerPrefix string `yaml:"marker_prefix"`
+	MarkerSuffix string `yaml:"marker_suffix"`
+	LineEndings  string `yaml:"line_endings"` // lf (default) or crlf for every generated text file.
+}
+
+// ChartData holds all settings read from the YAML configuration.
+type ChartData struct {
+	// Core chart settings.
+	Name                string     `yaml:"name"`              // Chart name and output directory.
+	ChartVersion        string     `yaml:"chart_version"`     // SemVer version of the chart.
+	AppVersion          string     `yaml:"app_version"`       // Version of the packaged application.
+	Description         string     `yaml:"description"`       // One-line chart description.
+	ReplicaCount        int        `yaml:"replica_count"`     // Pods of the main Deployment.
+	ImageRepository     string     `yaml:"image_repository"`  // Main container image.
+	ImageTag            string     `yaml:"image_tag"`         // Main container image tag.
+	ImagePullPolicy     string     `yaml:"image_pull_policy"` // Always, IfNotPresent or Never.
+	ServiceType         string     `yaml:"service_type"`      // ClusterIP, NodePort or LoadBalancer.
+	ServicePort         int        `yaml:"service_port"`      // Port of the Service and the main container.
+	IngressEnabled      bool       `yaml:"ingress_enabled"`   // Render an Ingress for ingress_host.
+	IngressHost         string     `yaml:"ingress_host"`
+	IngressPath         string     `yaml:"ingress_path"`
+	ConfigMapKey        string     `yaml:"configmap_key"`        // Deprecated: use ConfigMaps.
+	ConfigMapValue      string     `yaml:"configmap_value"`      // Deprecated: use ConfigMaps.
+	DependenciesEnabled bool       `yaml:"dependencies_enabled"` // Add subcharts to Chart.yaml dependencies.
+	Subcharts           []Subchart `yaml:"subcharts"`
+	// Global values are visible to the umbrella chart and every subchart
+	// as .Values.global.
+	Global map[string]interface{} `yaml:"global"`
+
+	// Chart.yaml metadata.
+	Maintainers      []Maintainer      `yaml:"maintainers"`
+	Keywords         []string          `yaml:"keywords"`
+	Home             string            `yaml:"home"`
+	Sources          []string          `yaml:"sources"`
+	Icon             string            `yaml:"icon"`
+	ChartAnnotations map[string]string `yaml:"chart_annotations"`
+	KubeVersion      string            `yaml:"kube_version"` // SemVer constraint, e.g. ">=1.25.0-0".
+
+	// Metadata added to every generated resource, e.g. team or cost-center.
+	CommonLabels      map[string]string `yaml:"common_labels"`      // Also added to pod templates.
+	CommonAnnotations map[string]string `yaml:"common_annotations"` // Resource metadata only.
+
+	// Library chart settings.
+	LibraryEnabled    bool   `yaml:"library_enabled"` // Add a library chart dependency.
+	LibraryName       string `yaml:"library_name"`
+	LibraryVersion    string `yaml:"library_version"`
+	LibraryRepository string `yaml:"library_repository"`
+
+	// Istio settings.
+	Istio IstioConfig `yaml:"istio"`
+
+	// Gateway API settings.
+	GatewayAPI GatewayAPIConfig `yaml:"gateway_api"`
+
+	// Secret settings.
+	ExternalSecrets ExternalSecretsConfig `yaml:"external_secrets"`
+	SealedSecrets   SealedSecretsConfig   `yaml:"sealed_secrets"`
+
+	// TLS settings.
+	Certificate CertificateConfig `yaml:"certificate"`
+
+	// ServiceAccount and workload identity settings.
+	ServiceAccountCreate bool                `yaml:"service_account_create"` // Create a ServiceAccount for the workload.
+	ServiceAccountName   string              `yaml:"service_account_name"`   // Existing account to use when create is false.
+	CloudIdentity        CloudIdentityConfig `yaml:"cloud_identity"`
+
+	// Workload profile settings.
+	Profile         string        `yaml:"profile"` // web-service, worker, cronjob or grpc.
+	Probes          ProbeConfig   `yaml:"probes"`
+	ServiceHeadless bool          `yaml:"service_headless"` // Render the Service with clusterIP: None.
+	CronJob         CronJobConfig `yaml:"cron_job"`
+
+	// Scheduling settings.
+	TopologySpread []TopologySpreadConstraint `yaml:"topology_spread"`
+
+	// Main container entrypoint settings.
+	Command    []string `yaml:"command"`
+	Args       []string `yaml:"args"`
+	WorkingDir string   `yaml:"working_dir"` // Working directory of the main container.
+
+	// Additional containers in the same pod.
+	Containers []Container `yaml:"containers"`
+
+	// Scaling settings.
+	Autoscaling AutoscalingConfig `yaml:"autoscaling"`
+	Keda        KedaConfig        `yaml:"keda"`
+	VPAEnabled  bool              `yaml:"vpa_enabled"` // Render a VerticalPodAutoscaler.
+	VPAPolicy   VPAUpdatePolicy   `yaml:"vpa_update_policy"`
+
+	// Rollout settings.
+	Strategy StrategyConfig `yaml:"strategy"`
+
+	// Namespace settings.
+	NamespaceOverride string          `yaml:"namespace_override"` // Namespace of all resources instead of the release namespace.
+	Namespace         NamespaceConfig `yaml:"namespace"`
+
+	// Namespace policy settings.
+	ResourceQuota ResourceQuotaConfig `yaml:"resource_quota"`
+	LimitRange    LimitRangeConfig    `yaml:"limit_range"`
+
+	// ConfigMap settings.
+	ConfigMaps []ConfigMapConfig `yaml:"configmaps"`
+
+	// Helm hook Jobs.
+	Hooks []HookJob `yaml:"hooks"`
+
+	// helmfile.yaml.gotmpl settings.
+	Helmfile HelmfileConfig `yaml:"helmfile"`
+
+	// Raw manifests copied into templates/ as-is.
+	ExtraManifests []ExtraManifest `yaml:"extra_manifests"`
+
+	// Template source settings.
+	Generator GeneratorConfig `yaml:"generator"`
+}
+
+// pathStep is one step of an override path: a map key or a list index.
+type pathStep struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parseSetPath splits an override path like subcharts[0].version into steps. A
+// backslash escapes a literal dot, e.g. resource_quota.hard.requests\.cpu.
+func parseSetPath(path string) ([]pathStep, error) {
+	var steps []pathStep
+	var key strings.Builder
+	flushKey := func() {
+		if key.Len() > 0 {
+			steps = append(steps, pathStep{key: key.String()})
+			key.Reset()
+		}
+	}
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '\\':
+			if i+1 < len(path) {
+				i++
+				key.WriteByte(path[i])
+			}
+		case '.':
+			flushKey()
+		case '[':
+			flushKey()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' in %q", path)
+			}
+			index, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid list index %q in %q", path[i+1:i+end], path)
+			}
+			steps = append(steps, pathStep{index: index, isIdx: true})
+			i += end
+		default:
+			key.WriteByte(c)
+		}
+	}
+	flushKey()
+	if len(steps) == 0 || steps[0].isIdx {
+		return nil, fmt.Errorf("path %q must start with a config key", path)
+	}
+	return steps, nil
+}
+
+// setPath stores value at steps below node, creating maps and growing
+// lists (by one element at a time) as needed, and returns the updated node.
+func setPath(node interface{}, steps []pathStep, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+	step := steps[0]
+	if step.isIdx {
+		list, ok := node.([]interface{})
+		if node != nil && !ok {
+			return nil, fmt.Errorf("[%d] used on a non-list value", step.index)
+		}
+		if step.index > len(list) {
+			return nil, fmt.Errorf("index [%d] is out of range (list has %d entries)", step.index, len(list))
+		}
+		if step.index == len(list) {
+			list = append(list, nil)
+		}
+		updated, err := setPath(list[step.index], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		list[step.index] = updated
+		return list, nil
+	}
+	m, ok := node.(map[interface{}]interface{})
+	if node != nil && !ok {
+		return nil, fmt.Errorf("key '%s' used on a non-map value", step.key)
+	}
+	if m == nil {
+		m = make(map[interface{}]interface{})
+	}
+	updated, err := setPath(m[step.key], steps[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	m[step.key] = updated
+	return m, nil
+}
+
+// applyOverrides applies key=value overrides to raw configuration YAML.
+// Values are parsed as YAML, so integers, booleans and [a, b] lists keep
+// their types; decimals stay strings so tags like 1.10 are not rounded.
+func applyOverrides(dataBytes []byte, overrides []string) ([]byte, error) {
+	var root interface{}
+	if err := yaml.Unmarshal(dataBytes, &root); err != nil {
+		return nil, err
+	}
+	for _, override := range overrides {
+		eq := strings.IndexByte(override, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("override %q: expected key=value", override)
+		}
+		steps, err := parseSetPath(override[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("override %q: %v", override, err)
+		}
+		var value interface{}
+		raw := override[eq+1:]
+		if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("override %q: invalid value: %v", override, err)
+		}
+		if _, isFloat := value.(float64); isFloat {
+			// Keep version-like values such as 1.10 as written.
+			value = raw
+		}
+		if root, err = setPath(root, steps, value); err != nil {
+			return nil, fmt.Errorf("override %q: %v", override, err)
+		}
+	}
+	return yaml.Marshal(root)
+}
+
+// LoadConfig parses YAML configuration, applying any key=value overrides
+// (dotted paths such as subcharts[0].version) before decoding. Defaults and
+// validation are applied later by Generate.
+func LoadConfig(data []byte, overrides []string) (ChartData, error) {
+	var config ChartData
+	if len(overrides) > 0 {
+		var err error
+		if data, err = applyOverrides(data, overrides); err != nil {
+			return config, err
+		}
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parsing configuration: %v", err)
+	}
+	return config, nil
+}
+
+// cloneConfig returns a deep copy of config by round-tripping it through
+// YAML, so normalizing the copy never writes through slices or maps shared
+// with the caller.
+func cloneConfig(config ChartData) (ChartData, error) {
+	var clone ChartData
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return clone, fmt.Errorf("copying configuration: %v", err)
+	}
+	if err := yaml.Unmarshal(out, &clone); err != nil {
+		return clone, fmt.Errorf("copying configuration: %v", err)
+	}
+	return clone, nil
+}
+
+// MountedConfigMaps returns the ConfigMaps that are mounted into the main
+// container.
+func (c ChartData) MountedConfigMaps() []ConfigMapConfig {
+	var mounted []ConfigMapConfig
+	for _, configMap := range c.ConfigMaps {
+		if configMap.MountPath != "" {
+			mounted = append(mounted, configMap)
+		}
+	}
+	return mounted
+}
+
+// SubchartTags returns the distinct tags of all subcharts, sorted.
+func (c ChartData) SubchartTags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, sub := range c.Subcharts {
+		for _, tag := range sub.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// labelName reports whether name is a valid label or annotation name: at most
+// 63 alphanumerics, '-', '_' or '.', beginning and ending with an
+// alphanumeric. An empty name is only valid when allowEmpty is set.
+func labelName(name string, allowEmpty bool) bool {
+	if name == "" {
+		return allowEmpty
+	}
+	if len(name) > 63 || !alphanumeric(name[0]) || !alphanumeric(name[len(name)-1]) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !alphanumeric(name[i]) && !strings.ContainsRune("-_.", rune(name[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedStringKeys returns the keys of m in sorted order, so validation
+// errors come out deterministically.
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// alphanumeric reports whether c is an ASCII letter or digit.
+func alphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// qualifiedName reports whether key is a valid label or annotation key: a
+// name with an optional DNS subdomain prefix, e.g. example.com/team.
+func qualifiedName(key string) bool {
+	name := key
+	if slash := strings.LastIndexByte(key, '/'); slash >= 0 {
+		prefix := key[:slash]
+		if prefix == "" || len(prefix) > 253 {
+			return false
+		}
+		for _, part := range strings.Split(prefix, ".") {
+			if !dnsLabel(part) {
+				return false
+			}
+		}
+		name = key[slash+1:]
+	}
+	return labelName(name, false)
+}
+
+// dnsLabel reports whether name is a valid Kubernetes DNS label.
+func dnsLabel(name string) bool {
+	if name == "" || len(name) > 63 || name[0] == '-' || name[len(name)-1] == '-' {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveConfigMapFiles returns the ConfigMaps with the contents of their
+// files merged into data. The caller's maps are left untouched.
+func resolveConfigMapFiles(configMaps []ConfigMapConfig) ([]ConfigMapConfig, error) {
+	resolved := make([]ConfigMapConfig, len(configMaps))
+	for i, configMap := range configMaps {
+		data := make(map[string]string, len(configMap.Data)+len(configMap.Files))
+		for key, value := range configMap.Data {
+			data[key] = value
+		}
+		for key, path := range configMap.Files {
+			content, err := os.ReadFile(localPath(path))
+			if err != nil {
+				return nil, fmt.Errorf("reading file for configmap '%s' key '%s': %v", configMap.Name, key, err)
+			}
+			data[key] = string(content)
+		}
+		configMap.Data, configMap.Files = data, nil
+		resolved[i] = configMap
+	}
+	return resolved, nil
+}
+
+// resolveSubchartValues returns the subcharts with their settings merged
+// into values: replica_count and image_* always win, while the condition flag
+// is set to Enabled when given and otherwise defaults to true. Nothing else is
+// added, so the subchart's own defaults stay in effect. The caller's maps are
+// left untouched.
+func resolveSubchartValues(subcharts []Subchart) ([]Subchart, error) {
+	resolved := make([]Subchart, len(subcharts))
+	for i, sub := range subcharts {
+		type setting struct {
+			path  string
+			value interface{}
+			force bool
+		}
+		var settings []setting
+		if strings.HasPrefix(sub.Condition, sub.Key()+".") {
+			settings = append(settings, setting{sub.conditionFlag(), sub.Enabled == nil || *sub.Enabled, sub.Enabled != nil})
+		}
+		if sub.ReplicaCount != 0 {
+			settings = append(settings, setting{"replicaCount", sub.ReplicaCount, true})
+		}
+		if sub.ImageRepository != "" {
+			settings = append(settings, setting{"image.repository", sub.ImageRepository, true})
+		}
+		if sub.ImageTag != "" {
+			settings = append(settings, setting{"image.tag", sub.ImageTag, true})
+		}
+		resolved[i] = sub
+		if len(settings) == 0 {
+			continue
+		}
+		// Round-trip through YAML for a deep copy that setPath can modify.
+		out, err := yaml.Marshal(sub.Values)
+		if err != nil {
+			return nil, err
+		}
+		var root interface{}
+		if err := yaml.Unmarshal(out, &root); err != nil {
+			return nil, err
+		}
+		for _, s := range settings {
+			steps, err := parseSetPath(s.path)
+			if err != nil {
+				return nil, fmt.Errorf("subchart '%s': %v", sub.Key(), err)
+			}
+			path := make([]interface{}, len(steps))
+			for j, step := range steps {
+				if step.isIdx {
+					path[j] = step.index
+				} else {
+					path[j] = step.key
+				}
+			}
+			if !s.force && yamlGet(root, path...) != nil {
+				continue
+			}
+			if root, err = setPath(root, steps, s.value); err != nil {
+				return nil, fmt.Errorf("subchart '%s' value '%s': %v", sub.Key(), s.path, err)
+			}
+		}
+		values := make(map[string]interface{})
+		for key, value := range root.(map[interface{}]interface{}) {
+			values[fmt.Sprint(key)] = value
+		}
+		resolved[i].Values = values
+	}
+	return resolved, nil
+}
+
+// normalize fills in defaults and validates the configuration. It is safe to
+// call more than once.
+func normalize(config *ChartData, logger Logger) error {
+	if config.Name == "" {
+		return fmt.Errorf("'name' must be specified")
+	}
+	if len(config.Maintainers) == 0 {
+		logger.Warn("no maintainers configured; chart registries may reject the chart")
+	}
+	for i, maintainer := range config.Maintainers {
+		if maintainer.Name == "" {
+			return fmt.Errorf("maintainers[%d] must specify a 'name'", i)
+		}
+	}
+	if err := applyProfile(config, logger); err != nil {
+		return err
+	}
+	seenSubcharts := make(map[string]bool)
+	for i := range config.Subcharts {
+		sub := &config.Subcharts[i]
+		switch {
+		case sub.Name == "":
+			return fmt.Errorf("subcharts[%d] must specify a 'name'", i)
+		case sub.Key() == "global" || sub.Key() == "tags":
+			return fmt.Errorf("subchart key '%s' is reserved by Helm; set an 'alias'", sub.Key())
+		case seenSubcharts[sub.Key()]:
+			return fmt.Errorf("subchart '%s' is declared more than once; set an 'alias'", sub.Key())
+		}
+		seenSubcharts[sub.Key()] = true
+		// A subchart without values gets an enabled flag in its skeleton
+		// block, unless its tags already switch it.
+		if sub.Condition == "" && (sub.Enabled != nil || len(sub.Values) == 0 && len(sub.Tags) == 0) {
+			sub.Condition = sub.Key() + ".enabled"
+		}
+		if sub.Condition != "" && !strings.HasPrefix(sub.Condition, sub.Key()+".") {
+			logger.Warn("subchart condition is outside its values; no enable flag is generated",
+				"subchart", sub.Key(), "condition", sub.Condition)
+		}
+	}
+	if config.Istio.Enabled && config.Istio.Gateway.Create && config.Istio.Gateway.Port == 0 {
+		config.Istio.Gateway.Port = 80
+	}
+	if config.GatewayAPI.Enabled {
+		route := &config.GatewayAPI
+		if len(route.ParentRefs) == 0 {
+			return fmt.Errorf("'gateway_api.parent_refs' must list at least one Gateway when gateway_api is enabled")
+		}
+		for i, ref := range route.ParentRefs {
+			if ref.Name == "" {
+				return fmt.Errorf("gateway_api.parent_refs[%d] must specify a 'name'", i)
+			}
+		}
+		if len(route.Hostnames) == 0 && config.IngressHost != "" {
+			route.Hostnames = []string{config.IngressHost}
+		}
+		if len(route.Rules) == 0 {
+			path := config.IngressPath
+			if path == "" {
+				path = "/"
+			}
+			route.Rules = []HTTPRouteRule{{Path: path}}
+		}
+		for i := range route.Rules {
+			rule := &route.Rules[i]
+			if rule.Path == "" {
+				rule.Path = "/"
+			}
+			switch rule.PathType {
+			case "":
+				rule.PathType = "PathPrefix"
+			case "PathPrefix", "Exact", "RegularExpression":
+			default:
+				return fmt.Errorf("unknown gateway_api.rules[%d].path_type '%s'", i, rule.PathType)
+			}
+			if rule.Port == 0 {
+				rule.Port = config.ServicePort
+			}
+		}
+		if config.IngressEnabled {
+			logger.Warn("both ingress_enabled and gateway_api are set; the chart exposes the Service twice")
+		}
+	}
+	if config.ExternalSecrets.Enabled {
+		if config.ExternalSecrets.SecretStore == "" {
+			return fmt.Errorf("'external_secrets.secret_store' must be specified when external_secrets is enabled")
+		}
+		if config.ExternalSecrets.SecretStoreKind == "" {
+			config.ExternalSecrets.SecretStoreKind = "SecretStore"
+		}
+		if config.ExternalSecrets.RefreshInterval == "" {
+			config.ExternalSecrets.RefreshInterval = "1h"
+		}
+	}
+	if config.SealedSecrets.Enabled {
+		if config.SealedSecrets.SecretName == "" {
+			config.SealedSecrets.SecretName = config.Name
+		}
+		if config.SealedSecrets.Scope == "" {
+			config.SealedSecrets.Scope = "strict"
+		}
+		if config.SealedSecrets.Kubeseal.Binary == "" {
+			config.SealedSecrets.Kubeseal.Binary = "kubeseal"
+		}
+		switch config.SealedSecrets.Scope {
+		case "strict", "namespace-wide", "cluster-wide":
+		default:
+			return fmt.Errorf("unknown sealed_secrets.scope '%s'", config.SealedSecrets.Scope)
+		}
+		if config.SealedSecrets.Scope != "cluster-wide" && config.SealedSecrets.Namespace == "" {
+			return fmt.Errorf("'sealed_secrets.namespace' is required for %s scope", config.SealedSecrets.Scope)
+		}
+	}
+	if config.Certificate.Enabled {
+		cert := &config.Certificate
+		if cert.SecretName == "" {
+			cert.SecretName = config.Name + "-tls"
+		}
+		if len(cert.DNSNames) == 0 && config.IngressHost != "" {
+			cert.DNSNames = []string{config.IngressHost}
+		}
+		if len(cert.DNSNames) == 0 {
+			return fmt.Errorf("'certificate.dns_names' or 'ingress_host' must be specified when certificate is enabled")
+		}
+		if cert.IssuerRef.Kind == "" {
+			cert.IssuerRef.Kind = "Issuer"
+		}
+		if cert.Issuer.Create {
+			if cert.IssuerRef.Kind != "Issuer" {
+				return fmt.Errorf("'certificate.issuer.create' creates a namespaced Issuer; it cannot be used with issuer_ref.kind '%s'", cert.IssuerRef.Kind)
+			}
+			if cert.IssuerRef.Name == "" {
+				cert.IssuerRef.Name = config.Name + "-issuer"
+			}
+			if cert.Issuer.ACMEServer == "" {
+				cert.Issuer.ACMEServer = "https://acme-v02.api.letsencrypt.org/directory"
+			}
+			if cert.Issuer.IngressClass == "" {
+				cert.Issuer.IngressClass = "nginx"
+			}
+		}
+		if cert.IssuerRef.Name == "" {
+			return fmt.Errorf("'certificate.issuer_ref.name' must be specified unless certificate.issuer.create is true")
+		}
+	}
+	if config.CloudIdentity.Configured() && !config.ServiceAccountCreate {
+		if config.ServiceAccountName == "" {
+			logger.Debug("cloud_identity is set; enabling service_account_create")
+			config.ServiceAccountCreate = true
+		} else {
+			logger.Warn("cloud_identity annotations are ignored for an existing service account", "service_account", config.ServiceAccountName)
+		}
+	}
+	for i := range config.TopologySpread {
+		constraint := &config.TopologySpread[i]
+		if constraint.MaxSkew == 0 {
+			constraint.MaxSkew = 1
+		}
+		if constraint.TopologyKey == "" {
+			constraint.TopologyKey = "topology.kubernetes.io/zone"
+		}
+		if constraint.WhenUnsatisfiable == "" {
+			constraint.WhenUnsatisfiable = "ScheduleAnyway"
+		}
+	}
+	containerNames := map[string]bool{config.Name: true}
+	for i := range config.Containers {
+		container := &config.Containers[i]
+		if container.Name == "" || container.ImageRepository == "" {
+			return fmt.Errorf("containers[%d] must specify 'name' and 'image_repository'", i)
+		}
+		if containerNames[container.Name] {
+			return fmt.Errorf("duplicate container name '%s'", container.Name)
+		}
+		containerNames[container.Name] = true
+		if container.ImageTag == "" {
+			container.ImageTag = "latest"
+		}
+		if container.ImagePullPolicy == "" {
+			container.ImagePullPolicy = config.ImagePullPolicy
+		}
+		if container.Expose && container.Port == 0 {
+			return fmt.Errorf("container '%s' sets 'expose' without a 'port'", container.Name)
+		}
+	}
+	if config.Autoscaling.Enabled {
+		scaling := &config.Autoscaling
+		if scaling.MinReplicas == 0 {
+			scaling.MinReplicas = 1
+		}
+		if scaling.MaxReplicas < scaling.MinReplicas {
+			return fmt.Errorf("'autoscaling.max_replicas' (%d) must be at least min_replicas (%d)", scaling.MaxReplicas, scaling.MinReplicas)
+		}
+		if scaling.TargetCPUUtilization == 0 && scaling.TargetMemoryUtilization == 0 && len(scaling.Metrics) == 0 {
+			scaling.TargetCPUUtilization = 80
+		}
+		for i, metric := range scaling.Metrics {
+			if _, ok := metric["type"]; !ok {
+				return fmt.Errorf("autoscaling.metrics[%d] must specify a 'type'", i)
+			}
+		}
+	}
+	if config.Keda.Enabled {
+		keda := &config.Keda
+		if config.Autoscaling.Enabled {
+			return fmt.Errorf("'keda' and 'autoscaling' cannot both be enabled; KEDA manages its own HPA")
+		}
+		if len(keda.Triggers) == 0 {
+			return fmt.Errorf("'keda.triggers' must contain at least one trigger")
+		}
+		for i, trigger := range keda.Triggers {
+			if trigger.Type == "" {
+				return fmt.Errorf("keda.triggers[%d] must specify a 'type'", i)
+			}
+		}
+		// Fall back to KEDA's own defaults.
+		if keda.MaxReplicas == 0 {
+			keda.MaxReplicas = 100
+		}
+		if keda.PollingInterval == 0 {
+			keda.PollingInterval = 30
+		}
+		if keda.CooldownPeriod == 0 {
+			keda.CooldownPeriod = 300
+		}
+		if keda.MaxReplicas < keda.MinReplicas {
+			return fmt.Errorf("'keda.max_replicas' (%d) must be at least min_replicas (%d)", keda.MaxReplicas, keda.MinReplicas)
+		}
+	}
+	if config.VPAEnabled {
+		policy := &config.VPAPolicy
+		if policy.UpdateMode == "" {
+			policy.UpdateMode = "Off"
+		}
+		switch policy.UpdateMode {
+		case "Off", "Initial", "Recreate", "Auto":
+		default:
+			return fmt.Errorf("unknown vpa_update_policy.update_mode '%s'", policy.UpdateMode)
+		}
+		if len(policy.ControlledResources) == 0 {
+			policy.ControlledResources = []string{"cpu", "memory"}
+		}
+		if policy.UpdateMode != "Off" && config.Autoscaling.Enabled &&
+			(config.Autoscaling.TargetCPUUtilization > 0 || config.Autoscaling.TargetMemoryUtilization > 0) {
+			logger.Warn("a VPA that applies updates together with a CPU/memory HPA makes the two autoscalers fight; consider update_mode \"Off\"", "update_mode", policy.UpdateMode)
+		}
+	}
+	switch config.Strategy.Type {
+	case "", "RollingUpdate":
+		config.Strategy.Type = "RollingUpdate"
+		if config.Strategy.MaxSurge == "" {
+			config.Strategy.MaxSurge = "25%"
+		}
+		if config.Strategy.MaxUnavailable == "" {
+			config.Strategy.MaxUnavailable = "25%"
+		}
+	case "Recreate":
+		if config.Strategy.MaxSurge != "" || config.Strategy.MaxUnavailable != "" {
+			return fmt.Errorf("'strategy.max_surge' and 'strategy.max_unavailable' only apply to the RollingUpdate strategy")
+		}
+	default:
+		return fmt.Errorf("unknown strategy.type '%s'; use RollingUpdate or Recreate", config.Strategy.Type)
+	}
+	if config.Namespace.Create && config.NamespaceOverride == "" {
+		return fmt.Errorf("'namespace_override' must be specified when namespace.create is true")
+	}
+	if config.ResourceQuota.Enabled && len(config.ResourceQuota.Hard) == 0 {
+		return fmt.Errorf("'resource_quota.hard' must list at least one limit when resource_quota is enabled")
+	}
+	if config.LimitRange.Enabled && len(config.LimitRange.Default) == 0 && len(config.LimitRange.DefaultRequest) == 0 &&
+		len(config.LimitRange.Max) == 0 && len(config.LimitRange.Min) == 0 {
+		return fmt.Errorf("'limit_range' is enabled but sets no default, default_request, max or min")
+	}
+	if (config.ResourceQuota.Enabled || config.LimitRange.Enabled) && !config.Namespace.Create {
+		logger.Warn("resource_quota/limit_range apply to the whole namespace; they are usually paired with namespace.create")
+	}
+	if config.ConfigMapKey != "" {
+		if len(config.ConfigMaps) > 0 {
+			return fmt.Errorf("'configmap_key' is replaced by 'configmaps'; set only one of them")
+		}
+		logger.Warn("configmap_key/configmap_value are deprecated; use configmaps")
+		config.ConfigMaps = []ConfigMapConfig{{
+			Name: "config",
+			Data: map[string]string{config.ConfigMapKey: config.ConfigMapValue},
+		}}
+	}
+	for _, key := range sortedStringKeys(config.CommonLabels) {
+		switch {
+		case !qualifiedName(key):
+			return fmt.Errorf("common_labels key '%s' is not a valid label name", key)
+		case key == "app":
+			return fmt.Errorf("common_labels must not set 'app'; the chart uses it to select its pods")
+		case !labelName(config.CommonLabels[key], true):
+			return fmt.Errorf("common_labels value '%s' for '%s' is not a valid label value", config.CommonLabels[key], key)
+		}
+	}
+	for _, key := range sortedStringKeys(config.CommonAnnotations) {
+		switch {
+		case !qualifiedName(key):
+			return fmt.Errorf("common_annotations key '%s' is not a valid annotation name", key)
+		case strings.HasPrefix(key, "helm.sh/"):
+			return fmt.Errorf("common_annotations must not set '%s'; helm.sh/ annotations are managed by Helm", key)
+		}
+	}
+	configMapNames := make(map[string]bool)
+	mountPaths := make(map[string]string)
+	for i, configMap := range config.ConfigMaps {
+		if !dnsLabel(configMap.Name) {
+			return fmt.Errorf("configmaps[%d] needs a 'name' of lowercase letters, digits and '-'", i)
+		}
+		if configMapNames[configMap.Name] {
+			return fmt.Errorf("duplicate configmaps name '%s'", configMap.Name)
+		}
+		configMapNames[configMap.Name] = true
+		if len(configMap.Data) == 0 && len(configMap.Files) == 0 {
+			return fmt.Errorf("configmap '%s' must set 'data' or 'files'", configMap.Name)
+		}
+		for key := range configMap.Files {
+			if _, dup := configMap.Data[key]; dup {
+				return fmt.Errorf("configmap '%s' sets key '%s' in both 'data' and 'files'", configMap.Name, key)
+			}
+		}
+		if configMap.MountPath != "" {
+			if other, dup := mountPaths[configMap.MountPath]; dup {
+				return fmt.Errorf("configmaps '%s' and '%s' are both mounted at '%s'", other, configMap.Name, configMap.MountPath)
+			}
+			mountPaths[configMap.MountPath] = configMap.Name
+		}
+	}
+	hookNames := make(map[string]bool)
+	for i := range config.Hooks {
+		hook := &config.Hooks[i]
+		if !dnsLabel(hook.Name) {
+			return fmt.Errorf("hooks[%d] needs a 'name' of lowercase letters, digits and '-'", i)
+		}
+		if hookNames[hook.Name] {
+			return fmt.Errorf("duplicate hooks name '%s'", hook.Name)
+		}
+		hookNames[hook.Name] = true
+		if len(hook.Events) == 0 {
+			return fmt.Errorf("hook '%s' must list at least one event", hook.Name)
+		}
+		for _, event := range hook.Events {
+			switch event {
+			case "pre-install", "post-install", "pre-delete", "post-delete",
+				"pre-upgrade", "post-upgrade", "pre-rollback", "post-rollback", "test":
+			default:
+				return fmt.Errorf("hook '%s' has unknown event '%s'", hook.Name, event)
+			}
+		}
+		if len(hook.DeletePolicy) == 0 {
+			hook.DeletePolicy = []string{"before-hook-creation"}
+		}
+		for _, policy := range hook.DeletePolicy {
+			switch policy {
+			case "before-hook-creation", "hook-succeeded", "hook-failed":
+			default:
+				return fmt.Errorf("hook '%s' has unknown delete_policy '%s'", hook.Name, policy)
+			}
+		}
+		if hook.ImageRepository == "" {
+			hook.ImageRepository = config.ImageRepository
+			if hook.ImageTag == "" {
+				hook.ImageTag = config.ImageTag
+			}
+		}
+		if hook.ImageTag == "" {
+			hook.ImageTag = "latest"
+		}
+		if hook.ImagePullPolicy == "" {
+			hook.ImagePullPolicy = config.ImagePullPolicy
+		}
+	}
+	extraNames := make(map[string]bool)
+	for i := range config.ExtraManifests {
+		extra := &config.ExtraManifests[i]
+		if (extra.File == "") == (extra.Content == "") {
+			return fmt.Errorf("extra_manifests[%d] must set exactly one of 'file' or 'content'", i)
+		}
+		if extra.Name == "" {
+			extra.Name = filepath.Base(localPath(extra.File))
+		}
+		if extra.Name == "" || strings.ContainsAny(extra.Name, `/\`) {
+			return fmt.Errorf("extra_manifests[%d] needs a plain file 'name' (no directories)", i)
+		}
+		if extraNames[extra.Name] {
+			return fmt.Errorf("duplicate extra_manifests name '%s'", extra.Name)
+		}
+		extraNames[extra.Name] = true
+	}
+	if config.Helmfile.Enabled {
+		helmfile := &config.Helmfile
+		if helmfile.ReleaseName == "" {
+			helmfile.ReleaseName = config.Name
+		}
+		if helmfile.Namespace == "" {
+			helmfile.Namespace = config.NamespaceOverride
+		}
+		if len(helmfile.Environments) == 0 {
+			helmfile.Environments = []HelmfileEnvironment{{Name: "default"}}
+		}
+		envNames := make(map[string]bool)
+		for i, env := range helmfile.Environments {
+			if !dnsLabel(env.Name) {
+				return fmt.Errorf("helmfile.environments[%d] name '%s' must be a lowercase DNS label", i, env.Name)
+			}
+			if envNames[env.Name] {
+				return fmt.Errorf("duplicate helmfile environment '%s'", env.Name)
+			}
+			envNames[env.Name] = true
+		}
+	}
+	switch config.Generator.LineEndings {
+	case "":
+		config.Generator.LineEndings = "lf"
+	case "lf", "crlf":
+	default:
+		return fmt.Errorf("unknown generator.line_endings '%s'; use lf or crlf", config.Generator.LineEndings)
+	}
+	logger.Debug("configuration loaded", "chart", config.Name)
+	return nil
+}
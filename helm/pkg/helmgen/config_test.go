@@ -0,0 +1,269 @@
+package helmgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadTestConfig parses YAML configuration, failing the test on errors.
+func loadTestConfig(t *testing.T, data string) ChartData {
+	t.Helper()
+	config, err := LoadConfig([]byte(data), nil)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return config
+}
+
+func TestNormalizeCertificateIssuer(t *testing.T) {
+	tests := []struct {
+		name     string
+		issuer   string
+		wantKind string
+		wantName string
+		wantErr  string
+	}{
+		{
+			name:     "kind defaults to Issuer",
+			issuer:   "issuer_ref: {name: letsencrypt}",
+			wantKind: "Issuer",
+			wantName: "letsencrypt",
+		},
+		{
+			name:     "configured ClusterIssuer is kept",
+			issuer:   "issuer_ref: {name: letsencrypt, kind: ClusterIssuer}",
+			wantKind: "ClusterIssuer",
+			wantName: "letsencrypt",
+		},
+		{
+			name:     "create defaults the Issuer name",
+			issuer:   "issuer: {create: true, acme_email: ops@example.com}",
+			wantKind: "Issuer",
+			wantName: "web-issuer",
+		},
+		{
+			name:    "create conflicts with ClusterIssuer",
+			issuer:  "issuer_ref: {kind: ClusterIssuer}\n  issuer: {create: true, acme_email: ops@example.com}",
+			wantErr: "issuer_ref.kind 'ClusterIssuer'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := loadTestConfig(t, "name: web\ncertificate:\n  enabled: true\n  dns_names: [web.example.com]\n  "+tt.issuer+"\n")
+			err := normalize(&config, nopLogger{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("normalize error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalize: %v", err)
+			}
+			if ref := config.Certificate.IssuerRef; ref.Kind != tt.wantKind || ref.Name != tt.wantName {
+				t.Errorf("issuer_ref = %+v, want {Name:%s Kind:%s}", ref, tt.wantName, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestParseSetPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathStep
+		wantErr string
+	}{
+		{path: "image_tag", want: []pathStep{{key: "image_tag"}}},
+		{path: "ingress.host", want: []pathStep{{key: "ingress"}, {key: "host"}}},
+		{path: `pod_annotations.prometheus\.io/scrape`, want: []pathStep{{key: "pod_annotations"}, {key: "prometheus.io/scrape"}}},
+		{path: "subcharts[1].version", want: []pathStep{{key: "subcharts"}, {index: 1, isIdx: true}, {key: "version"}}},
+		{path: "ports[0][2]", want: []pathStep{{key: "ports"}, {index: 0, isIdx: true}, {index: 2, isIdx: true}}},
+		{path: "subcharts[0", wantErr: "unclosed '['"},
+		{path: "subcharts[x]", wantErr: `invalid list index "x"`},
+		{path: "subcharts[-1]", wantErr: `invalid list index "-1"`},
+		{path: "[0].name", wantErr: "must start with a config key"},
+		{path: "", wantErr: "must start with a config key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			steps, err := parseSetPath(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseSetPath error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSetPath: %v", err)
+			}
+			if !reflect.DeepEqual(steps, tt.want) {
+				t.Errorf("parseSetPath = %+v, want %+v", steps, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		overrides []string
+		want      string
+		wantErr   string
+	}{
+		{
+			name:      "nested key is created",
+			config:    "name: web\n",
+			overrides: []string{"ingress.host=web.example.com"},
+			want:      "name: web\ningress: {host: web.example.com}\n",
+		},
+		{
+			name:      "escaped dot stays in the key",
+			config:    "name: web\n",
+			overrides: []string{`pod_annotations.prometheus\.io/scrape="true"`},
+			want:      "name: web\npod_annotations: {prometheus.io/scrape: \"true\"}\n",
+		},
+		{
+			name:      "list entries are replaced and appended",
+			config:    "subcharts: [{name: redis, version: 17.0.0}]\n",
+			overrides: []string{"subcharts[0].version=18.1.0", "subcharts[1].name=postgresql"},
+			want:      "subcharts: [{name: redis, version: 18.1.0}, {name: postgresql}]\n",
+		},
+		{
+			name:      "values keep their YAML types",
+			config:    "name: web\n",
+			overrides: []string{"replica_count=3", "hpa.enabled=true", "image_tag=latest", "service_port=\"8080\""},
+			want:      "name: web\nreplica_count: 3\nhpa: {enabled: true}\nimage_tag: latest\nservice_port: \"8080\"\n",
+		},
+		{
+			name:      "decimals stay strings",
+			config:    "name: web\n",
+			overrides: []string{"image_tag=1.10"},
+			want:      "name: web\nimage_tag: \"1.10\"\n",
+		},
+		{
+			name:      "scalar overwrites a map",
+			config:    "ingress: {enabled: true, host: web.example.com}\n",
+			overrides: []string{"ingress=false"},
+			want:      "ingress: false\n",
+		},
+		{
+			name:      "key on a scalar",
+			config:    "image_tag: latest\n",
+			overrides: []string{"image_tag.major=1"},
+			wantErr:   "key 'major' used on a non-map value",
+		},
+		{
+			name:      "index on a map",
+			config:    "ingress: {host: web.example.com}\n",
+			overrides: []string{"ingress[0]=x"},
+			wantErr:   "[0] used on a non-list value",
+		},
+		{
+			name:      "index past the end",
+			config:    "subcharts: [{name: redis}]\n",
+			overrides: []string{"subcharts[2].name=postgresql"},
+			wantErr:   "index [2] is out of range (list has 1 entries)",
+		},
+		{
+			name:      "missing value",
+			config:    "name: web\n",
+			overrides: []string{"image_tag"},
+			wantErr:   "expected key=value",
+		},
+		{
+			name:      "malformed path",
+			config:    "name: web\n",
+			overrides: []string{"subcharts[0.name=redis"},
+			wantErr:   "unclosed '['",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := applyOverrides([]byte(tt.config), tt.overrides)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("applyOverrides error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyOverrides: %v", err)
+			}
+			var got, want interface{}
+			if err := yaml.Unmarshal(data, &got); err != nil {
+				t.Fatalf("result is not YAML: %v", err)
+			}
+			if err := yaml.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("applyOverrides =\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSubchartValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		subchart string
+		want     string
+	}{
+		{
+			name:     "enabled flag without values",
+			subchart: "{name: redis}",
+			want:     "{enabled: true}",
+		},
+		{
+			name:     "settings without values",
+			subchart: "{name: redis, alias: cache, enabled: false, replica_count: 3, image_tag: '7.2'}",
+			want:     "{enabled: false, replicaCount: 3, image: {tag: '7.2'}}",
+		},
+		{
+			name:     "tags switch the subchart",
+			subchart: "{name: redis, tags: [backend]}",
+			want:     "{}",
+		},
+		{
+			name:     "configured values are kept",
+			subchart: "{name: redis, values: {architecture: standalone}}",
+			want:     "{architecture: standalone}",
+		},
+		{
+			name:     "settings win over values",
+			subchart: "{name: redis, image_repository: bitnami/redis, values: {image: {repository: redis, tag: '7.2'}}}",
+			want:     "{image: {repository: bitnami/redis, tag: '7.2'}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := loadTestConfig(t, "name: web\nsubcharts:\n  - "+tt.subchart+"\n")
+			if err := normalize(&config, nopLogger{}); err != nil {
+				t.Fatalf("normalize: %v", err)
+			}
+			subcharts, err := resolveSubchartValues(config.Subcharts)
+			if err != nil {
+				t.Fatalf("resolveSubchartValues: %v", err)
+			}
+			got, err := yaml.Marshal(subcharts[0].Values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want map[string]interface{}
+			if err := yaml.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			wantYAML, err := yaml.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(wantYAML) {
+				t.Errorf("values =\n%s\nwant\n%s", got, wantYAML)
+			}
+		})
+	}
+}
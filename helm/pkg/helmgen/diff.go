@@ -0,0 +1,247 @@
+package helmgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// Diff writes a unified diff for every file that differs between the chart
+// directory dir (typically a previous generation) and fsys, and reports
+// whether anything differs. Artifacts of `helm dependency update`
+// (Chart.lock and packaged subcharts) are ignored.
+func Diff(fsys fs.FS, dir string, w io.Writer) (bool, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return false, err
+	} else if !info.IsDir() {
+		return false, fmt.Errorf("'%s' is not a directory", dir)
+	}
+	oldFiles, err := readTree(os.DirFS(dir))
+	if err != nil {
+		return false, fmt.Errorf("reading '%s': %v", dir, err)
+	}
+	newFiles, err := readTree(fsys)
+	if err != nil {
+		return false, err
+	}
+	names := make(map[string]bool)
+	for name := range oldFiles {
+		names[name] = true
+	}
+	for name := range newFiles {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	changed := false
+	for _, name := range sorted {
+		oldContent, inOld := oldFiles[name]
+		newContent, inNew := newFiles[name]
+		if inOld && inNew && bytes.Equal(oldContent, newContent) {
+			continue
+		}
+		changed = true
+		// Use git's header so the output applies with `git apply`.
+		oldName, newName := "a/"+name, "b/"+name
+		fmt.Fprintf(w, "diff --git %s %s\n", oldName, newName)
+		if !inOld {
+			fmt.Fprintf(w, "new file mode 100644\n")
+			oldName = "/dev/null"
+		}
+		if !inNew {
+			fmt.Fprintf(w, "deleted file mode 100644\n")
+			newName = "/dev/null"
+		}
+		fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+		writeHunks(w, splitLines(oldContent), splitLines(newContent))
+	}
+	return changed, nil
+}
+
+// readTree reads every file of fsys except dependency build artifacts.
+func readTree(fsys fs.FS) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if name == "Chart.lock" || path.Ext(name) == ".tgz" {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		files[name] = content
+		return nil
+	})
+	return files, err
+}
+
+// splitLines splits content into lines, keeping their newlines so that a
+// missing newline at the end of the file counts as a change.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// maxDiffCells bounds the longest common subsequence table built for one
+// file; larger changes are written as a removal of every changed line
+// followed by their replacement.
+const maxDiffCells = 1 << 22
+
+// diffLines returns an edit script turning a into b. The common prefix and
+// suffix are kept as is and the lines between them are diffed with
+// lcsDiffLines.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, lcsDiffLines(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// lcsDiffLines returns a minimal edit script turning a into b, based on the
+// longest common subsequence of their lines, unless its table would exceed
+// maxDiffCells; then every line of a is removed and every line of b added.
+func lcsDiffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// writeHunks writes the changes between a and b as unified diff hunks.
+func writeHunks(w io.Writer, a, b []string) {
+	ops := diffLines(a, b)
+	// aLine[k] and bLine[k] count the lines of a and b before ops[k].
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			return
+		}
+		// Extend the hunk while the next change is at most two contexts
+		// away, as diff -u does.
+		last := first + 1
+		for k := first; k < len(ops) && k-last <= 2*diffContext; k++ {
+			if ops[k].kind != ' ' {
+				last = k + 1
+			}
+		}
+		from, to := first-diffContext, last+diffContext
+		if from < start {
+			from = start
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n",
+			hunkRange(aLine[from], aLine[to]-aLine[from]), hunkRange(bLine[from], bLine[to]-bLine[from]))
+		for _, op := range ops[from:to] {
+			fmt.Fprintf(w, "%c%s", op.kind, op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				fmt.Fprintf(w, "\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+}
+
+// hunkRange formats a unified diff line range starting after line before.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
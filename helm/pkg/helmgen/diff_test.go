@@ -0,0 +1,189 @@
+package helmgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWriteHunks(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "a\nb\nc\n",
+			b:    "a\nb\nc\n",
+			want: "",
+		},
+		{
+			name: "pure add",
+			a:    "",
+			b:    "x\ny\n",
+			want: "@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			name: "pure delete",
+			a:    "x\ny\n",
+			b:    "",
+			want: "@@ -1,2 +0,0 @@\n-x\n-y\n",
+		},
+		{
+			name: "change with context",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "missing trailing newline",
+			a:    "a\nb\n",
+			b:    "a\nb",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name: "trailing newline added",
+			a:    "a",
+			b:    "a\n",
+			want: "@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			writeHunks(&out, splitLines([]byte(tt.a)), splitLines([]byte(tt.b)))
+			if out.String() != tt.want {
+				t.Errorf("writeHunks =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+var hunkHeader = regexp.MustCompile(`(?m)^@@ .* @@$`)
+
+func TestWriteHunksMergesNearbyChanges(t *testing.T) {
+	// numbered returns the lines 1 to 20, with the given ones changed.
+	numbered := func(changed ...int) []string {
+		var lines []string
+		for i := 1; i <= 20; i++ {
+			line := strconv.Itoa(i)
+			for _, c := range changed {
+				if i == c {
+					line = "changed"
+				}
+			}
+			lines = append(lines, line+"\n")
+		}
+		return lines
+	}
+	tests := []struct {
+		gap  int
+		want []string
+	}{
+		{gap: 5, want: []string{"@@ -1,11 +1,11 @@"}},
+		{gap: 6, want: []string{"@@ -1,12 +1,12 @@"}},
+		{gap: 7, want: []string{"@@ -1,5 +1,5 @@", "@@ -7,7 +7,7 @@"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		writeHunks(&out, numbered(), numbered(2, 3+tt.gap))
+		if got := hunkHeader.FindAllString(out.String(), -1); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%d unchanged lines between the changes: hunks %q, want %q", tt.gap, got, tt.want)
+		}
+	}
+}
+
+func TestDiffIdenticalInputs(t *testing.T) {
+	lines := splitLines([]byte("a\nb\n"))
+	for _, op := range diffLines(lines, lines) {
+		if op.kind != ' ' {
+			t.Fatalf("diffLines of identical inputs = %q", diffLines(lines, lines))
+		}
+	}
+}
+
+func TestDiffNewAndRemovedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"Chart.yaml":  "name: web\n",
+		"removed.txt": "gone\n",
+		"Chart.lock":  "dependencies: []\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsys := fstest.MapFS{
+		"Chart.yaml":          {Data: []byte("name: web\n")},
+		"templates/added.txt": {Data: []byte("new\n")},
+	}
+
+	var out bytes.Buffer
+	changed, err := Diff(fsys, dir, &out)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	want := "diff --git a/removed.txt b/removed.txt\n" +
+		"deleted file mode 100644\n" +
+		"--- a/removed.txt\n" +
+		"+++ /dev/null\n" +
+		"@@ -1,1 +0,0 @@\n" +
+		"-gone\n" +
+		"diff --git a/templates/added.txt b/templates/added.txt\n" +
+		"new file mode 100644\n" +
+		"--- /dev/null\n" +
+		"+++ b/templates/added.txt\n" +
+		"@@ -0,0 +1,1 @@\n" +
+		"+new\n"
+	if !changed || out.String() != want {
+		t.Errorf("Diff = %v,\n%s\nwant true,\n%s", changed, out.String(), want)
+	}
+
+	out.Reset()
+	if changed, err := Diff(fstest.MapFS{"Chart.yaml": {Data: []byte("name: web\n")}, "removed.txt": {Data: []byte("gone\n")}}, dir, &out); err != nil || changed || out.Len() > 0 {
+		t.Errorf("Diff of the same files = %v, %v,\n%s\nwant no changes", changed, err, out.String())
+	}
+}
+
+func TestDiffLinesLargeChange(t *testing.T) {
+	const context, changed = 10, 3000
+	var a, b []string
+	for i := 0; i < context; i++ {
+		a = append(a, "head "+strconv.Itoa(i)+"\n")
+	}
+	b = append(b, a...)
+	for i := 0; i < changed; i++ {
+		a = append(a, "old "+strconv.Itoa(i)+"\n")
+		b = append(b, "new "+strconv.Itoa(i)+"\n")
+	}
+	for i := 0; i < context; i++ {
+		a = append(a, "tail "+strconv.Itoa(i)+"\n")
+		b = append(b, "tail "+strconv.Itoa(i)+"\n")
+	}
+
+	ops := diffLines(a, b)
+	counts := make(map[byte]int)
+	var gotA, gotB []string
+	for _, op := range ops {
+		counts[op.kind]++
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+	}
+	if counts[' '] != 2*context || counts['-'] != changed || counts['+'] != changed {
+		t.Errorf("kept %d, removed %d, added %d lines; want %d, %d, %d",
+			counts[' '], counts['-'], counts['+'], 2*context, changed, changed)
+	}
+	if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+		t.Error("edit script does not turn a into b")
+	}
+}
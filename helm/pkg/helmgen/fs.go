@@ -0,0 +1,158 @@
+package helmgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FS returns a read-only file system view of the chart, rooted at the chart
+// directory, for fs.WalkDir, archiving or Write.
+func (c Chart) FS() fs.FS {
+	return memFS(c.Files)
+}
+
+// Write copies every file of fsys into dir, writing files concurrently. An
+// existing dir is removed first when overwrite is set and is an error
+// otherwise. All write errors are reported together.
+func Write(fsys fs.FS, dir string, overwrite bool) error {
+	if _, err := os.Stat(dir); err == nil {
+		if !overwrite {
+			return fmt.Errorf("directory '%s' already exists", dir)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("removing directory '%s': %v", dir, err)
+		}
+	}
+	// Create the directories up front so the files can be written in any order.
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, filepath.FromSlash(name)), 0755)
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	errs := make([]error, len(names))
+	forEach(len(names), 0, func(i int) {
+		target := filepath.Join(dir, filepath.FromSlash(names[i]))
+		content, err := fs.ReadFile(fsys, names[i])
+		if err == nil {
+			err = os.WriteFile(target, content, 0644)
+		}
+		if err != nil {
+			errs[i] = fmt.Errorf("writing file '%s': %v", target, err)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// memFS is an fs.FS over a map of slash-separated paths to file contents.
+// Directories are implied by the paths of the files they contain.
+type memFS map[string][]byte
+
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if content, ok := m[name]; ok {
+		info := memInfo{name: path.Base(name), size: int64(len(content))}
+		return &memFile{info: info, Reader: bytes.NewReader(content)}, nil
+	}
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	children := make(map[string]memInfo)
+	for filePath, content := range m {
+		if !strings.HasPrefix(filePath, prefix) {
+			continue
+		}
+		rest := filePath[len(prefix):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			children[rest[:i]] = memInfo{name: rest[:i], dir: true}
+		} else {
+			children[rest] = memInfo{name: rest, size: int64(len(content))}
+		}
+	}
+	if len(children) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, info := range children {
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return &memDir{info: memInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// memInfo describes a memFS file or directory.
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() interface{}   { return nil }
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// memFile is an open memFS file.
+type memFile struct {
+	info memInfo
+	*bytes.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an open memFS directory.
+type memDir struct {
+	info    memInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
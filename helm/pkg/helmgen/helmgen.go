@@ -0,0 +1,232 @@
+// Package helmgen generates an umbrella Helm chart from a ChartData
+// configuration using one unified template. All file definitions (Chart.yaml,
+// values.yaml, Kubernetes manifests in the `templates/` directory, and an
+// optional library chart) are contained in a single source block that is
+// split on marker lines (--- relative/path/to/file ---) and rendered with
+// Go's templating engine using custom delimiters.
+//
+// The generator works in memory: Generate returns a Chart whose FS can be
+// walked, archived or written to disk with Write.
+//
+//	config, err := helmgen.LoadConfig(data, nil)
+//	chart, err := helmgen.Generate(config, helmgen.Options{})
+//	err = helmgen.Write(chart.FS(), chart.Name, true)
+package helmgen
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Logger receives leveled, structured messages from the generator: a short
+// message followed by alternating keys and values. *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+// nopLogger discards everything; it is used when no Logger is given.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+
+// Options controls a Generate run.
+type Options struct {
+	// Limit is "full" (default) for all files or "core" for essential files only.
+	Limit string
+	// Logger receives progress messages and warnings; nil discards them.
+	Logger Logger
+	// Vendor downloads every subchart into charts/ and rewrites its
+	// repository to file://, for installs without network access.
+	Vendor bool
+	// HTTPClient is used for vendoring; nil uses a client with a timeout.
+	HTTPClient *http.Client
+	// Workers bounds how many files are rendered concurrently; zero or less
+	// uses GOMAXPROCS.
+	Workers int
+}
+
+// Chart is a generated chart held in memory.
+type Chart struct {
+	Name    string
+	Version string
+	// Files maps slash-separated paths relative to the chart root to contents.
+	Files map[string][]byte
+}
+
+// Generate applies defaults to a copy of config, validates it, seals secrets
+// if requested and renders every chart file, followed by ManifestFile.
+// Neither config nor the disk is modified. The output is byte-for-byte
+// reproducible for the same config, except for values sealed by kubeseal,
+// which encrypts with a random session key.
+func Generate(config ChartData, opts Options) (Chart, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	switch opts.Limit {
+	case "", "full", "core":
+	default:
+		return Chart{}, fmt.Errorf("unknown limit '%s'; use 'full' or 'core'", opts.Limit)
+	}
+	// Work on a deep copy so the caller's config is never modified and
+	// repeated or concurrent calls with the same value agree.
+	input := config
+	config, err := cloneConfig(input)
+	if err != nil {
+		return Chart{}, err
+	}
+	// Hash the configuration before defaults and sealed values are applied.
+	configHash, err := configSHA256(input)
+	if err != nil {
+		return Chart{}, err
+	}
+	if err := normalize(&config, logger); err != nil {
+		return Chart{}, fmt.Errorf("configuration error: %v", err)
+	}
+	source, syntax, err := resolveTemplateSource(config.Generator, logger)
+	if err != nil {
+		return Chart{}, err
+	}
+	// Seal any clear-text secret values before rendering.
+	if err := sealSecrets(&config, logger); err != nil {
+		return Chart{}, err
+	}
+	if config.ConfigMaps, err = resolveConfigMapFiles(config.ConfigMaps); err != nil {
+		return Chart{}, err
+	}
+	if config.Subcharts, err = resolveSubchartValues(config.Subcharts); err != nil {
+		return Chart{}, err
+	}
+	var vendored map[string][]byte
+	if opts.Vendor && config.DependenciesEnabled {
+		if vendored, err = vendorSubcharts(&config, opts.HTTPClient, logger); err != nil {
+			return Chart{}, fmt.Errorf("vendoring subcharts: %v", err)
+		}
+	}
+
+	chart := Chart{Name: config.Name, Version: config.ChartVersion, Files: make(map[string][]byte)}
+	templates := parseUnifiedTemplate(source, syntax)
+	relPaths := make([]string, 0, len(templates))
+	for relPath := range templates {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+	// Render every file before giving up so all broken templates are reported at once.
+	contents := make([][]byte, len(relPaths))
+	errs := make([]error, len(relPaths))
+	forEach(len(relPaths), opts.Workers, func(i int) {
+		relPath := relPaths[i]
+		if reason := templateSkipReason(relPath, config, opts.Limit); reason != "" {
+			logger.Debug("skipping template", "path", relPath, "reason", reason)
+			return
+		}
+		contents[i], errs[i] = renderTemplate(relPath, templates[relPath], config, opts.Limit, syntax)
+	})
+	if err := errors.Join(errs...); err != nil {
+		return Chart{}, err
+	}
+	for i, relPath := range relPaths {
+		if contents[i] != nil {
+			logger.Debug("generated file", "path", relPath)
+			chart.Files[relPath] = contents[i]
+		}
+	}
+	for relPath, content := range vendored {
+		if _, exists := chart.Files[relPath]; exists {
+			return Chart{}, fmt.Errorf("vendored subchart file '%s' would overwrite a generated file", relPath)
+		}
+		chart.Files[relPath] = content
+	}
+	if err := addExtraManifests(&chart, config, logger); err != nil {
+		return Chart{}, err
+	}
+	if err := addHelmfileEnvironments(&chart, config, opts.Limit); err != nil {
+		return Chart{}, err
+	}
+	applyLineEndings(&chart, config.Generator.LineEndings, vendored)
+	if err := checkPortablePaths(chart); err != nil {
+		return Chart{}, err
+	}
+	if err := addManifest(&chart, configHash, config, source); err != nil {
+		return Chart{}, err
+	}
+	logger.Info("chart rendered", "chart", chart.Name, "version", chart.Version, "files", len(chart.Files))
+	return chart, nil
+}
+
+// forEach calls fn for 0..n-1 on at most workers goroutines (GOMAXPROCS if
+// workers <= 0) and returns when all calls are done.
+func forEach(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// addHelmfileEnvironments writes the values file of every helmfile
+// environment next to helmfile.yaml.gotmpl.
+func addHelmfileEnvironments(chart *Chart, config ChartData, limit string) error {
+	if templateSkipReason("helmfile.yaml.gotmpl", config, limit) != "" {
+		return nil
+	}
+	for _, env := range config.Helmfile.Environments {
+		values := "{}"
+		if len(env.Values) > 0 {
+			var err error
+			if values, err = toYaml(env.Values); err != nil {
+				return fmt.Errorf("helmfile environment '%s': %v", env.Name, err)
+			}
+		}
+		chart.Files["environments/"+env.Name+".yaml"] = []byte(fmt.Sprintf(
+			"# Values for the %s environment, layered over values.yaml.\n%s\n", env.Name, values))
+	}
+	return nil
+}
+
+// addExtraManifests copies the configured raw manifests into templates/
+// without rendering them, refusing to replace a generated file.
+func addExtraManifests(chart *Chart, config ChartData, logger Logger) error {
+	for _, extra := range config.ExtraManifests {
+		relPath := "templates/" + extra.Name
+		if _, exists := chart.Files[relPath]; exists {
+			return fmt.Errorf("extra manifest '%s' would overwrite a generated template", extra.Name)
+		}
+		content := []byte(extra.Content)
+		if extra.File != "" {
+			var err error
+			if content, err = os.ReadFile(localPath(extra.File)); err != nil {
+				return fmt.Errorf("reading extra manifest '%s': %v", extra.File, err)
+			}
+		}
+		logger.Debug("copied extra manifest", "path", relPath)
+		chart.Files[relPath] = content
+	}
+	return nil
+}
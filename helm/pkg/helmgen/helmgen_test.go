@@ -0,0 +1,110 @@
+package helmgen
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestGenerateLeavesConfigUntouched(t *testing.T) {
+	const data = `name: web
+dependencies_enabled: true
+subcharts:
+  - name: redis
+    version: 1.0.0
+    repository: https://charts.example.com
+containers:
+  - name: sidecar
+    image_repository: busybox
+    image_tag: "1.36"
+`
+	config := loadTestConfig(t, data)
+	before := loadTestConfig(t, data)
+	if _, err := Generate(config, Options{}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !reflect.DeepEqual(config, before) {
+		t.Errorf("Generate modified its config:\n got %+v\nwant %+v", config, before)
+	}
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	const data = `name: web
+chart_version: "0.1.0"
+image_repository: nginx
+image_tag: "1.25"
+service_port: 8080
+dependencies_enabled: true
+subcharts:
+  - name: redis
+    version: 1.0.0
+    repository: https://charts.example.com
+sealed_secrets:
+  enabled: true
+  namespace: default
+  encrypted_data:
+    API_KEY: AgBy3i4OJSWK
+`
+	config := loadTestConfig(t, data)
+	first, err := Generate(config, Options{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	second, err := Generate(config, Options{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !reflect.DeepEqual(first.Files, second.Files) {
+		t.Errorf("two runs differ:\n%s\nvs\n%s", first.Files[ManifestFile], second.Files[ManifestFile])
+	}
+
+	var manifest generationManifest
+	if err := yaml.Unmarshal(first.Files[ManifestFile], &manifest); err != nil {
+		t.Fatalf("parsing %s: %v", ManifestFile, err)
+	}
+	want, err := configSHA256(loadTestConfig(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.ConfigSHA256 != want {
+		t.Errorf("config_sha256 = %s, want the hash of the config before defaults %s", manifest.ConfigSHA256, want)
+	}
+}
+
+func TestDeriveConfigFromChartRoundTrip(t *testing.T) {
+	config := loadTestConfig(t, `name: web
+chart_version: "0.1.0"
+image_repository: nginx
+image_tag: "1.25"
+service_port: 8080
+ingress_enabled: true
+ingress_host: web.example.com
+ingress_path: /app
+`)
+	first, err := Generate(config, Options{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "web")
+	if err := Write(first.FS(), dir, false); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	derived, err := DeriveConfigFromChart(dir, nil)
+	if err != nil {
+		t.Fatalf("DeriveConfigFromChart: %v", err)
+	}
+	if !derived.IngressEnabled || derived.IngressHost != "web.example.com" || derived.IngressPath != "/app" {
+		t.Errorf("derived ingress enabled=%v host=%q path=%q, want web.example.com/app", derived.IngressEnabled, derived.IngressHost, derived.IngressPath)
+	}
+	second, err := Generate(derived, Options{})
+	if err != nil {
+		t.Fatalf("Generate of the derived config: %v", err)
+	}
+	for name, content := range first.Files {
+		if name != ManifestFile && string(second.Files[name]) != string(content) {
+			t.Errorf("%s differs after the round trip:\n%s\nvs\n%s", name, content, second.Files[name])
+		}
+	}
+}
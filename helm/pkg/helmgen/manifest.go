@@ -0,0 +1,121 @@
+package helmgen
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ManifestFile is the generation manifest written at the chart root. It lists
+// every generated file with its sha256 so downstream systems can detect drift
+// and verify where a chart came from.
+const ManifestFile = ".helmgen-manifest.yaml"
+
+// Version identifies the generator in the generation manifest. Release builds
+// set it with -ldflags "-X github.com/ViaXLabs/tools/helm/pkg/helmgen.Version=v1.2.3";
+// when empty the module version from the build info is used.
+var Version string
+
+// modulePath is the module the generator is built from.
+const modulePath = "github.com/ViaXLabs/tools"
+
+// generationManifest is the content of ManifestFile. It holds no timestamps
+// so identical inputs produce an identical manifest.
+type generationManifest struct {
+	Generator struct {
+		Version  string `yaml:"version"`
+		Template string `yaml:"template"` // "embedded" or the custom template file.
+		// TemplateSHA256 is the hash of the unified template source.
+		TemplateSHA256 string `yaml:"template_sha256"`
+	} `yaml:"generator"`
+	Chart struct {
+		Name       string `yaml:"name"`
+		Version    string `yaml:"version"`
+		AppVersion string `yaml:"app_version"`
+	} `yaml:"chart"`
+	// ConfigSHA256 is the hash of the configuration as passed to Generate,
+	// after -set overrides and before defaults are applied or secrets sealed.
+	ConfigSHA256 string         `yaml:"config_sha256"`
+	Files        []manifestFile `yaml:"files"`
+}
+
+// manifestFile is one generated file in the generation manifest.
+type manifestFile struct {
+	Path   string `yaml:"path"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// generatorVersion returns Version, or the module version the generator was
+// built from.
+func generatorVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath {
+			return info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
+
+// addManifest records every file of chart, sorted by path, in ManifestFile,
+// which gets the same line endings as the other files.
+func addManifest(chart *Chart, configHash string, config ChartData, source string) error {
+	var manifest generationManifest
+	manifest.Generator.Version = generatorVersion()
+	manifest.Generator.Template = "embedded"
+	if config.Generator.TemplateFile != "" {
+		manifest.Generator.Template = config.Generator.TemplateFile
+	}
+	manifest.Generator.TemplateSHA256 = sha256Hex([]byte(source))
+	manifest.Chart.Name = config.Name
+	manifest.Chart.Version = config.ChartVersion
+	manifest.Chart.AppVersion = config.AppVersion
+	manifest.ConfigSHA256 = configHash
+
+	paths := make([]string, 0, len(chart.Files))
+	for relPath := range chart.Files {
+		paths = append(paths, relPath)
+	}
+	sort.Strings(paths)
+	for _, relPath := range paths {
+		manifest.Files = append(manifest.Files, manifestFile{Path: relPath, SHA256: sha256Hex(chart.Files[relPath])})
+	}
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	content = append([]byte("# Generated by helm_chart_generator; do not edit.\n"), content...)
+	if config.Generator.LineEndings == "crlf" {
+		content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+	}
+	chart.Files[ManifestFile] = content
+	return nil
+}
+
+// configSHA256 returns the hash of config as passed to Generate, before
+// defaults are applied and secrets are sealed.
+func configSHA256(config ChartData) (string, error) {
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("hashing configuration: %v", err)
+	}
+	return sha256Hex(out), nil
+}
+
+// sha256Hex returns the hex-encoded sha256 of content.
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
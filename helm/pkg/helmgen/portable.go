@@ -0,0 +1,78 @@
+package helmgen
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// windowsReservedNames are device names Windows refuses as file names, with
+// or without an extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// localPath turns a path from the configuration, written with either / or \
+// separators, into a path for the local file system, so configs written on
+// Windows work elsewhere and vice versa.
+func localPath(p string) string {
+	return filepath.FromSlash(strings.ReplaceAll(p, `\`, "/"))
+}
+
+// portablePathProblem returns why the slash-separated relPath cannot be
+// created on every platform, or an empty string if it can.
+func portablePathProblem(relPath string) string {
+	for _, part := range strings.Split(relPath, "/") {
+		base := strings.ToUpper(strings.SplitN(part, ".", 2)[0])
+		switch {
+		case part == "":
+			return "empty path element"
+		case windowsReservedNames[base]:
+			return fmt.Sprintf("'%s' is a reserved file name on Windows", part)
+		case strings.ContainsAny(part, `<>:"\|?*`):
+			return fmt.Sprintf(`'%s' contains a character Windows does not allow (<>:"\|?*)`, part)
+		case strings.HasSuffix(part, ".") || strings.HasSuffix(part, " "):
+			return fmt.Sprintf("'%s' ends with a dot or space, which Windows drops", part)
+		}
+	}
+	return ""
+}
+
+// checkPortablePaths fails if the chart directory or any chart file could
+// not be written on Windows.
+func checkPortablePaths(chart Chart) error {
+	var problems []string
+	if problem := portablePathProblem(chart.Name); problem != "" {
+		problems = append(problems, fmt.Sprintf("chart directory: %s", problem))
+	}
+	for relPath := range chart.Files {
+		if problem := portablePathProblem(relPath); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", relPath, problem))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("chart paths are not portable:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// applyLineEndings rewrites every text file of chart to the given line
+// endings ("lf" or "crlf"), leaving binary files and the paths in keep
+// untouched.
+func applyLineEndings(chart *Chart, endings string, keep map[string][]byte) {
+	for relPath, content := range chart.Files {
+		if _, ok := keep[relPath]; ok || bytes.IndexByte(content, 0) >= 0 {
+			continue
+		}
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+		if endings == "crlf" {
+			content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+		}
+		chart.Files[relPath] = content
+	}
+}
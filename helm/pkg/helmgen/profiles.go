@@ -0,0 +1,112 @@
+package helmgen
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Profiles preselect the generated resources and fill in defaults for common
+// workload shapes. Explicit settings always win over profile defaults.
+//
+//	web-service  Deployment and Service with HTTP probes
+//	worker       Deployment only: no Service and no ports
+//	cronjob      CronJob instead of a Deployment
+//	grpc         Deployment and headless Service with gRPC probes
+const (
+	profileWebService = "web-service"
+	profileWorker     = "worker"
+	profileCronJob    = "cronjob"
+	profileGRPC       = "grpc"
+)
+
+// applyProfile applies the defaults of config.Profile and disables settings
+// the profile cannot serve, with a warning.
+func applyProfile(config *ChartData, logger Logger) error {
+	switch config.Profile {
+	case "":
+	case profileWebService:
+		if config.Probes.Type == "" {
+			config.Probes.Type = "http"
+		}
+	case profileGRPC:
+		if config.ServiceType == "" || config.ServiceType == "ClusterIP" {
+			config.ServiceType = "ClusterIP"
+			config.ServiceHeadless = true
+		}
+		if config.Probes.Type == "" {
+			config.Probes.Type = "grpc"
+		}
+	case profileWorker, profileCronJob:
+		disable := map[string]*bool{
+			"ingress_enabled":     &config.IngressEnabled,
+			"gateway_api.enabled": &config.GatewayAPI.Enabled,
+			"istio.enabled":       &config.Istio.Enabled,
+		}
+		if config.Profile == profileCronJob {
+			disable["autoscaling.enabled"] = &config.Autoscaling.Enabled
+			disable["keda.enabled"] = &config.Keda.Enabled
+			disable["vpa_enabled"] = &config.VPAEnabled
+			if config.CronJob.Schedule == "" {
+				return fmt.Errorf("'cron_job.schedule' must be specified for the cronjob profile")
+			}
+		}
+		for _, key := range sortedSettingKeys(disable) {
+			if *disable[key] {
+				logger.Warn("setting ignored by profile", "profile", config.Profile, "setting", key)
+				*disable[key] = false
+			}
+		}
+	default:
+		return fmt.Errorf("unknown profile '%s'; use web-service, worker, cronjob or grpc", config.Profile)
+	}
+
+	if config.Profile == profileCronJob {
+		switch config.CronJob.ConcurrencyPolicy {
+		case "":
+			config.CronJob.ConcurrencyPolicy = "Forbid"
+		case "Allow", "Forbid", "Replace":
+		default:
+			return fmt.Errorf("unknown cron_job.concurrency_policy '%s'", config.CronJob.ConcurrencyPolicy)
+		}
+	}
+	switch config.Probes.Type {
+	case "":
+	case "http":
+		if config.Probes.Path == "" {
+			config.Probes.Path = "/"
+		}
+		fallthrough
+	case "grpc", "tcp":
+		if config.Probes.Port == 0 {
+			config.Probes.Port = config.ServicePort
+		}
+	default:
+		return fmt.Errorf("unknown probes.type '%s'; use http, grpc or tcp", config.Probes.Type)
+	}
+	return nil
+}
+
+// sortedSettingKeys returns the keys of settings in sorted order so warnings
+// come out deterministically.
+func sortedSettingKeys(settings map[string]*bool) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ServesTraffic reports whether the workload receives traffic through a
+// Service, which is not the case for the worker and cronjob profiles.
+func (c ChartData) ServesTraffic() bool {
+	return c.Profile != profileWorker && c.Profile != profileCronJob
+}
+
+// ServicePortName returns the name of the main Service port.
+func (c ChartData) ServicePortName() string {
+	if c.Profile == profileGRPC {
+		return "grpc"
+	}
+	return "http"
+}
@@ -0,0 +1,96 @@
+package helmgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+)
+
+// RenderCheck renders the chart in fsys with the Helm template engine and its
+// own values.yaml, like `helm template`, and fails on template errors (e.g.
+// undefined helpers) or rendered manifests that are not valid YAML. Declared
+// subcharts that are not present under charts/ are skipped with a warning.
+// It returns the rendered manifests keyed by template path.
+func RenderCheck(fsys fs.FS, logger Logger) (map[string]string, error) {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	var files []*loader.BufferedFile
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		files = append(files, &loader.BufferedFile{Name: name, Data: content})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	chrt, err := loader.LoadFiles(files)
+	if err != nil {
+		return nil, fmt.Errorf("loading chart: %v", err)
+	}
+	present := make(map[string]bool)
+	for _, dep := range chrt.Dependencies() {
+		present[dep.Name()] = true
+	}
+	if chrt.Metadata != nil {
+		for _, dep := range chrt.Metadata.Dependencies {
+			if !present[dep.Name] {
+				logger.Warn("subchart not rendered; it is missing under charts/ (run with -dep-update or -vendor)", "subchart", dep.Name)
+			}
+		}
+	}
+	if err := chartutil.ProcessDependencies(chrt, chrt.Values); err != nil {
+		return nil, fmt.Errorf("processing dependencies: %v", err)
+	}
+	options := chartutil.ReleaseOptions{Name: "release-name", Namespace: "default", Revision: 1, IsInstall: true}
+	values, err := chartutil.ToRenderValues(chrt, chrt.Values, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, fmt.Errorf("preparing values: %v", err)
+	}
+	rendered, err := engine.Render(chrt, values)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var problems []string
+	for _, name := range names {
+		if ext := path.Ext(name); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader([]byte(rendered[name])))
+		for {
+			var doc interface{}
+			if err := decoder.Decode(&doc); err == io.EOF {
+				break
+			} else if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("rendered manifests are not valid YAML:\n  %s", strings.Join(problems, "\n  "))
+	}
+	logger.Debug("chart rendered with the helm engine", "templates", len(rendered))
+	return rendered, nil
+}
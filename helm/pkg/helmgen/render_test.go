@@ -0,0 +1,210 @@
+package helmgen
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// renderTestChart generates a chart from YAML configuration and renders it
+// with the Helm engine, failing the test on errors.
+func renderTestChart(t *testing.T, data string) map[string]string {
+	t.Helper()
+	chart, err := Generate(loadTestConfig(t, data), Options{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	rendered, err := RenderCheck(chart.FS(), nil)
+	if err != nil {
+		t.Fatalf("RenderCheck: %v", err)
+	}
+	return rendered
+}
+
+var secretChecksum = regexp.MustCompile(`checksum/secret: ([0-9a-f]{64})`)
+
+func TestSecretChecksumCoversEverySecretSource(t *testing.T) {
+	config := func(externalKey, sealedValue string) string {
+		return `name: web
+chart_version: "0.1.0"
+image_repository: nginx
+image_tag: "1.25"
+service_port: 8080
+external_secrets:
+  enabled: true
+  secret_store: vault-backend
+  data:
+    - secret_key: DB_PASSWORD
+      remote_key: ` + externalKey + `
+sealed_secrets:
+  enabled: true
+  namespace: default
+  encrypted_data:
+    API_KEY: ` + sealedValue + `
+`
+	}
+	checksum := func(data string) string {
+		t.Helper()
+		deployment := renderTestChart(t, data)["web/templates/deployment.yaml"]
+		match := secretChecksum.FindStringSubmatch(deployment)
+		if match == nil {
+			t.Fatalf("no checksum/secret annotation in deployment.yaml:\n%s", deployment)
+		}
+		return match[1]
+	}
+
+	base := checksum(config("secret/data/web", "AgBy3i4OJSWK"))
+	if changed := checksum(config("secret/data/web-v2", "AgBy3i4OJSWK")); changed == base {
+		t.Error("checksum/secret did not change with the ExternalSecret")
+	}
+	if changed := checksum(config("secret/data/web", "AgCz9x7PQRST")); changed == base {
+		t.Error("checksum/secret did not change with the SealedSecret")
+	}
+}
+
+func TestIstioRoutesToTheService(t *testing.T) {
+	rendered := renderTestChart(t, `name: web
+chart_version: "0.1.0"
+image_repository: nginx
+image_tag: "1.25"
+service_port: 8080
+istio:
+  enabled: true
+  hosts:
+    - example.com
+  subsets:
+    - name: v1
+      labels:
+        version: v1
+`)
+	route := rendered["web/templates/istio-virtualservice.yaml"]
+	if !strings.Contains(route, "host: release-name-web") || strings.Contains(route, "subset:") {
+		t.Errorf("VirtualService does not route to the whole Service:\n%s", route)
+	}
+	if rule := rendered["web/templates/istio-destinationrule.yaml"]; !strings.Contains(rule, "name: v1") {
+		t.Errorf("DestinationRule lacks the configured subset:\n%s", rule)
+	}
+}
+
+func TestHookBackoffLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		want    string // "" for no backoffLimit
+	}{
+		{"unset", "", ""},
+		{"no retries", "\n    backoff_limit: 0", "backoffLimit: 0"},
+		{"retries", "\n    backoff_limit: 2", "backoffLimit: 2"},
+	}
+	for _, test := range tests {
+		hooks := renderTestChart(t, `name: web
+chart_version: "0.1.0"
+image_repository: nginx
+image_tag: "1.25"
+service_port: 8080
+hooks:
+  - name: migrate
+    events: [pre-upgrade]`+test.setting+`
+`)["web/templates/hooks.yaml"]
+		if got := strings.Contains(hooks, "backoffLimit:"); got != (test.want != "") || !strings.Contains(hooks, test.want) {
+			t.Errorf("%s: hook Job does not set %q:\n%s", test.name, test.want, hooks)
+		}
+	}
+}
+
+func TestCronJobBackoffLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+		want    string // "" for no backoffLimit
+	}{
+		{"unset", "", ""},
+		{"no retries", ", backoff_limit: 0", "backoffLimit: 0"},
+		{"retries", ", backoff_limit: 3", "backoffLimit: 3"},
+	}
+	for _, test := range tests {
+		cronJob := renderTestChart(t, `name: web
+chart_version: "0.1.0"
+image_repository: nginx
+image_tag: "1.25"
+profile: cronjob
+cron_job: {schedule: "0 * * * *"`+test.setting+`}
+`)["web/templates/cronjob.yaml"]
+		if got := strings.Contains(cronJob, "backoffLimit:"); got != (test.want != "") || !strings.Contains(cronJob, test.want) {
+			t.Errorf("%s: CronJob does not set %q:\n%s", test.name, test.want, cronJob)
+		}
+	}
+}
+
+func TestRenderOptionalResources(t *testing.T) {
+	const base = `name: web
+chart_version: "0.1.0"
+image_repository: nginx
+image_tag: "1.25"
+service_port: 8080
+`
+	tests := []struct {
+		name   string
+		config string
+		file   string
+		want   []string
+	}{
+		{"istio", `istio:
+  enabled: true
+  gateways: [istio-system/shared-gateway]
+  hosts: [web.example.com]
+`, "istio-virtualservice.yaml", []string{"kind: VirtualService", "- istio-system/shared-gateway", "- web.example.com", "host: release-name-web", "number: 8080"}},
+		{"external secrets", `external_secrets:
+  enabled: true
+  secret_store: vault-backend
+  secret_store_kind: ClusterSecretStore
+  data:
+    - secret_key: DB_PASSWORD
+      remote_key: app/db
+      property: password
+`, "externalsecret.yaml", []string{"kind: ExternalSecret", "name: vault-backend", "kind: ClusterSecretStore", "secretKey: DB_PASSWORD", "key: app/db", "property: password"}},
+		{"certificate", `ingress_enabled: true
+ingress_host: web.example.com
+ingress_path: /
+certificate:
+  enabled: true
+  issuer_ref: {name: letsencrypt, kind: ClusterIssuer}
+`, "certificate.yaml", []string{"kind: Certificate", "secretName: web-tls", "- web.example.com", "name: letsencrypt", "kind: ClusterIssuer"}},
+		{"keda", `keda:
+  enabled: true
+  min_replicas: 1
+  max_replicas: 5
+  triggers:
+    - type: cpu
+      metadata: {value: "70"}
+`, "scaledobject.yaml", []string{"kind: ScaledObject", "name: release-name-web", "minReplicaCount: 1", "maxReplicaCount: 5", "type: cpu", `value: "70"`}},
+		{"gateway api", `gateway_api:
+  enabled: true
+  parent_refs: [{name: shared, namespace: infra}]
+  hostnames: [web.example.com]
+  rules: [{path: /api}]
+`, "httproute.yaml", []string{"kind: HTTPRoute", "name: shared", "namespace: infra", "- web.example.com", `value: "/api"`, "type: PathPrefix", "port: 8080"}},
+		{"resource quota", `resource_quota:
+  enabled: true
+  hard: {pods: "50", requests.cpu: "10"}
+`, "resourcequota.yaml", []string{"kind: ResourceQuota", `pods: "50"`, `requests.cpu: "10"`}},
+		{"limit range", `limit_range:
+  enabled: true
+  default: {cpu: 500m}
+  default_request: {cpu: 100m}
+`, "limitrange.yaml", []string{"kind: LimitRange", "type: Container", "default:\n      cpu: 500m", "defaultRequest:\n      cpu: 100m"}},
+	}
+	for _, test := range tests {
+		rendered := renderTestChart(t, base+test.config)
+		got, ok := rendered["web/templates/"+test.file]
+		if !ok {
+			t.Errorf("%s: %s not rendered", test.name, test.file)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: %s lacks %q:\n%s", test.name, test.file, want, got)
+			}
+		}
+	}
+}
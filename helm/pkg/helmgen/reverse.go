@@ -0,0 +1,663 @@
+package helmgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// --- Reverse mode ---
+// DeriveConfigFromChart and DeriveConfigFromManifests inspect existing charts or
+// plain Kubernetes manifests and build a best-effort ChartData, easing the
+// migration of hand-written charts into this generator.
+
+// yamlGet walks nested YAML maps (and list indexes) and returns the value at
+// the given path, or nil if any step is missing.
+func yamlGet(node interface{}, path ...interface{}) interface{} {
+	for _, step := range path {
+		switch key := step.(type) {
+		case string:
+			m, ok := node.(map[interface{}]interface{})
+			if !ok {
+				return nil
+			}
+			node = m[key]
+		case int:
+			list, ok := node.([]interface{})
+			if !ok || key >= len(list) {
+				return nil
+			}
+			node = list[key]
+		}
+	}
+	return node
+}
+
+// yamlString returns the value at path as a string ("" if missing).
+func yamlString(node interface{}, path ...interface{}) string {
+	v := yamlGet(node, path...)
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// yamlInt returns the value at path as an int (0 if missing or not numeric).
+func yamlInt(node interface{}, path ...interface{}) int {
+	switch v := yamlGet(node, path...).(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// yamlIntPtr returns the value at path as an int, or nil if missing.
+func yamlIntPtr(node interface{}, path ...interface{}) *int {
+	if yamlGet(node, path...) == nil {
+		return nil
+	}
+	n := yamlInt(node, path...)
+	return &n
+}
+
+// yamlBool returns the value at path as a bool (false if missing).
+func yamlBool(node interface{}, path ...interface{}) bool {
+	v, _ := yamlGet(node, path...).(bool)
+	return v
+}
+
+// yamlStringSlice returns the list at path as strings.
+func yamlStringSlice(node interface{}, path ...interface{}) []string {
+	list, _ := yamlGet(node, path...).([]interface{})
+	var out []string
+	for _, item := range list {
+		out = append(out, fmt.Sprint(item))
+	}
+	return out
+}
+
+// yamlStringMap returns the map at path with keys and values as strings.
+func yamlStringMap(node interface{}, path ...interface{}) map[string]string {
+	m, _ := yamlGet(node, path...).(map[interface{}]interface{})
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[fmt.Sprint(k)] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// yamlMap returns the map at path with string keys; nested values are kept
+// as decoded.
+func yamlMap(node interface{}, path ...interface{}) map[string]interface{} {
+	m, _ := yamlGet(node, path...).(map[interface{}]interface{})
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[fmt.Sprint(k)] = v
+	}
+	return out
+}
+
+// splitImage splits "repo:tag" (ignoring registry ports) into its parts.
+func splitImage(image string) (string, string) {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+	return image, "latest"
+}
+
+// cloudIdentityFromAnnotations picks the workload identity settings back out
+// of ServiceAccount annotations.
+func cloudIdentityFromAnnotations(annotations map[string]string) CloudIdentityConfig {
+	return CloudIdentityConfig{
+		AWSRoleARN:        annotations["eks.amazonaws.com/role-arn"],
+		GCPServiceAccount: annotations["iam.gke.io/gcp-service-account"],
+		AzureClientID:     annotations["azure.workload.identity/client-id"],
+		AzureTenantID:     annotations["azure.workload.identity/tenant-id"],
+	}
+}
+
+// DeriveConfigFromChart reads Chart.yaml and values.yaml of an existing chart.
+// Templates are not parsed, except for the ingress host and path when
+// values.yaml has no ingress section; the conventional `helm create` values
+// layout is assumed instead.
+func DeriveConfigFromChart(chartDir string, logger Logger) (ChartData, error) {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	var chart, values interface{}
+	chartBytes, err := ioutil.ReadFile(filepath.Join(chartDir, "Chart.yaml"))
+	if err != nil {
+		return ChartData{}, fmt.Errorf("reading Chart.yaml in '%s': %v", chartDir, err)
+	}
+	if err := yaml.Unmarshal(chartBytes, &chart); err != nil {
+		return ChartData{}, fmt.Errorf("parsing Chart.yaml in '%s': %v", chartDir, err)
+	}
+	if valuesBytes, err := ioutil.ReadFile(filepath.Join(chartDir, "values.yaml")); err == nil {
+		if err := yaml.Unmarshal(valuesBytes, &values); err != nil {
+			return ChartData{}, fmt.Errorf("parsing values.yaml in '%s': %v", chartDir, err)
+		}
+	} else {
+		logger.Warn("no values.yaml; only Chart.yaml metadata is derived", "chart", chartDir)
+	}
+
+	config := ChartData{
+		Name:            yamlString(chart, "name"),
+		ChartVersion:    yamlString(chart, "version"),
+		AppVersion:      yamlString(chart, "appVersion"),
+		Description:     yamlString(chart, "description"),
+		Keywords:        yamlStringSlice(chart, "keywords"),
+		Home:            yamlString(chart, "home"),
+		Sources:         yamlStringSlice(chart, "sources"),
+		Icon:            yamlString(chart, "icon"),
+		KubeVersion:     yamlString(chart, "kubeVersion"),
+		ReplicaCount:    yamlInt(values, "replicaCount"),
+		ImageRepository: yamlString(values, "image", "repository"),
+		ImageTag:        yamlString(values, "image", "tag"),
+		ImagePullPolicy: yamlString(values, "image", "pullPolicy"),
+		ServiceType:     yamlString(values, "service", "type"),
+		ServicePort:     yamlInt(values, "service", "port"),
+		IngressEnabled:  yamlBool(values, "ingress", "enabled"),
+		Command:         yamlStringSlice(values, "command"),
+		Args:            yamlStringSlice(values, "args"),
+		WorkingDir:      yamlString(values, "workingDir"),
+	}
+	if config.ImageTag == "" {
+		config.ImageTag = config.AppVersion
+	}
+	// Ingress host/path: the `helm create` hosts list, else the first rule of
+	// templates/ingress.yaml, where our own charts write them.
+	config.IngressHost = yamlString(values, "ingress", "hosts", 0, "host")
+	config.IngressPath = yamlString(values, "ingress", "hosts", 0, "paths", 0, "path")
+	if yamlGet(values, "ingress") == nil {
+		if rule := ingressRuleFromTemplate(chartDir); rule != nil {
+			config.IngressEnabled = true
+			config.IngressHost = yamlString(rule, "host")
+			config.IngressPath = yamlString(rule, "http", "paths", 0, "path")
+		}
+	}
+
+	for i := 0; yamlGet(chart, "dependencies", i) != nil; i++ {
+		config.DependenciesEnabled = true
+		name := yamlString(chart, "dependencies", i, "name")
+		alias := yamlString(chart, "dependencies", i, "alias")
+		key := name
+		if alias != "" {
+			key = alias
+		}
+		sub := Subchart{
+			Name:       name,
+			Version:    yamlString(chart, "dependencies", i, "version"),
+			Repository: yamlString(chart, "dependencies", i, "repository"),
+			Alias:      alias,
+			Condition:  yamlString(chart, "dependencies", i, "condition"),
+			Tags:       yamlStringSlice(chart, "dependencies", i, "tags"),
+			Values:     yamlMap(values, key),
+		}
+		// Lift a disabled condition flag out of the values so it survives
+		// pruning as an explicit enabled: false.
+		if flag := sub.conditionFlag(); strings.HasPrefix(sub.Condition, key+".") && !strings.Contains(flag, ".") {
+			if enabled, ok := sub.Values[flag].(bool); ok && !enabled {
+				sub.Enabled = &enabled
+				delete(sub.Values, flag)
+			}
+		}
+		config.Subcharts = append(config.Subcharts, sub)
+	}
+	config.Global = yamlMap(values, "global")
+	config.CommonLabels = yamlStringMap(values, "commonLabels")
+	config.CommonAnnotations = yamlStringMap(values, "commonAnnotations")
+	if yamlGet(values, "cronJob") != nil {
+		config.Profile = profileCronJob
+		config.CronJob = CronJobConfig{
+			Schedule:          yamlString(values, "cronJob", "schedule"),
+			ConcurrencyPolicy: yamlString(values, "cronJob", "concurrencyPolicy"),
+			BackoffLimit:      yamlIntPtr(values, "cronJob", "backoffLimit"),
+		}
+	}
+	for i := 0; yamlGet(chart, "maintainers", i) != nil; i++ {
+		config.Maintainers = append(config.Maintainers, Maintainer{
+			Name:  yamlString(chart, "maintainers", i, "name"),
+			Email: yamlString(chart, "maintainers", i, "email"),
+			URL:   yamlString(chart, "maintainers", i, "url"),
+		})
+	}
+	config.ChartAnnotations = yamlStringMap(chart, "annotations")
+
+	if yamlBool(values, "autoscaling", "enabled") {
+		config.Autoscaling = AutoscalingConfig{
+			Enabled:                 true,
+			MinReplicas:             yamlInt(values, "autoscaling", "minReplicas"),
+			MaxReplicas:             yamlInt(values, "autoscaling", "maxReplicas"),
+			TargetCPUUtilization:    yamlInt(values, "autoscaling", "targetCPUUtilizationPercentage"),
+			TargetMemoryUtilization: yamlInt(values, "autoscaling", "targetMemoryUtilizationPercentage"),
+		}
+	}
+	config.ServiceAccountCreate = yamlBool(values, "serviceAccount", "create")
+	config.ServiceAccountName = yamlString(values, "serviceAccount", "name")
+	config.CloudIdentity = cloudIdentityFromAnnotations(yamlStringMap(values, "serviceAccount", "annotations"))
+	config.NamespaceOverride = yamlString(values, "namespaceOverride")
+	config.Strategy = StrategyConfig{
+		Type:           yamlString(values, "strategy", "type"),
+		MaxSurge:       yamlString(values, "strategy", "rollingUpdate", "maxSurge"),
+		MaxUnavailable: yamlString(values, "strategy", "rollingUpdate", "maxUnavailable"),
+	}
+	for i := 0; yamlGet(values, "topologySpreadConstraints", i) != nil; i++ {
+		config.TopologySpread = append(config.TopologySpread, TopologySpreadConstraint{
+			MaxSkew:           yamlInt(values, "topologySpreadConstraints", i, "maxSkew"),
+			TopologyKey:       yamlString(values, "topologySpreadConstraints", i, "topologyKey"),
+			WhenUnsatisfiable: yamlString(values, "topologySpreadConstraints", i, "whenUnsatisfiable"),
+		})
+	}
+	configMaps, _ := yamlGet(values, "configMaps").(map[interface{}]interface{})
+	for _, name := range sortedKeys(configMaps) {
+		config.ConfigMaps = append(config.ConfigMaps, ConfigMapConfig{
+			Name:      name,
+			Data:      yamlStringMap(values, "configMaps", name, "data"),
+			MountPath: yamlString(values, "configMaps", name, "mountPath"),
+		})
+	}
+	hooks, _ := yamlGet(values, "hooks").(map[interface{}]interface{})
+	for _, name := range sortedKeys(hooks) {
+		weight, _ := strconv.Atoi(yamlString(values, "hooks", name, "weight"))
+		config.Hooks = append(config.Hooks, HookJob{
+			Name:            name,
+			Events:          splitList(yamlString(values, "hooks", name, "events")),
+			Weight:          weight,
+			DeletePolicy:    splitList(yamlString(values, "hooks", name, "deletePolicy")),
+			ImageRepository: yamlString(values, "hooks", name, "image", "repository"),
+			ImageTag:        yamlString(values, "hooks", name, "image", "tag"),
+			ImagePullPolicy: yamlString(values, "hooks", name, "image", "pullPolicy"),
+			Command:         yamlStringSlice(values, "hooks", name, "command"),
+			Args:            yamlStringSlice(values, "hooks", name, "args"),
+			BackoffLimit:    yamlIntPtr(values, "hooks", name, "backoffLimit"),
+			ActiveDeadline:  yamlInt(values, "hooks", name, "activeDeadlineSeconds"),
+		})
+	}
+	return config, nil
+}
+
+// templateAction matches a Go template action such as {{- if .Values.x }}.
+var templateAction = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+
+// ingressRuleFromTemplate returns the first rule of the chart's
+// templates/ingress.yaml, parsed with its template actions dropped, or nil
+// if there is no such template or it is not YAML without them.
+func ingressRuleFromTemplate(chartDir string) interface{} {
+	content, err := ioutil.ReadFile(filepath.Join(chartDir, "templates", "ingress.yaml"))
+	if err != nil {
+		return nil
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(templateAction.ReplaceAll(content, nil), &doc); err != nil {
+		return nil
+	}
+	if yamlString(doc, "kind") != "Ingress" {
+		return nil
+	}
+	return yamlGet(doc, "spec", "rules", 0)
+}
+
+// splitList splits a comma-separated annotation value such as
+// "pre-install,pre-upgrade".
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// probeFromManifest maps a container probe back onto probe settings.
+func probeFromManifest(probe interface{}) ProbeConfig {
+	switch {
+	case yamlGet(probe, "httpGet") != nil:
+		return ProbeConfig{Type: "http", Path: yamlString(probe, "httpGet", "path"), Port: yamlInt(probe, "httpGet", "port")}
+	case yamlGet(probe, "grpc") != nil:
+		return ProbeConfig{Type: "grpc", Port: yamlInt(probe, "grpc", "port")}
+	case yamlGet(probe, "tcpSocket") != nil:
+		return ProbeConfig{Type: "tcp", Port: yamlInt(probe, "tcpSocket", "port")}
+	}
+	return ProbeConfig{}
+}
+
+// sortedKeys returns the keys of a YAML map as sorted strings.
+func sortedKeys(m map[interface{}]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, fmt.Sprint(key))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DeriveConfigFromManifests reads every YAML document under dir (recursively)
+// and maps the recognised Kubernetes kinds back onto config settings. The
+// first Deployment found is treated as the chart's workload.
+func DeriveConfigFromManifests(dir string, logger Logger) (ChartData, error) {
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	var config ChartData
+	var docs []interface{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !(strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		for {
+			var doc interface{}
+			if err := decoder.Decode(&doc); err != nil {
+				if err == io.EOF {
+					break
+				}
+				logger.Warn("skipping unparseable YAML", "file", path, "error", err)
+				break
+			}
+			if doc != nil {
+				docs = append(docs, doc)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return config, fmt.Errorf("reading manifests from '%s': %v", dir, err)
+	}
+
+	workloadFound := false
+	configMapMounts := make(map[string]string)
+	for _, doc := range docs {
+		kind := yamlString(doc, "kind")
+		switch kind {
+		case "Deployment":
+			if workloadFound {
+				logger.Warn("ignoring additional Deployment", "name", yamlString(doc, "metadata", "name"))
+				continue
+			}
+			workloadFound = true
+			config.Name = yamlString(doc, "metadata", "name")
+			config.ReplicaCount = yamlInt(doc, "spec", "replicas")
+			config.Strategy = StrategyConfig{
+				Type:           yamlString(doc, "spec", "strategy", "type"),
+				MaxSurge:       yamlString(doc, "spec", "strategy", "rollingUpdate", "maxSurge"),
+				MaxUnavailable: yamlString(doc, "spec", "strategy", "rollingUpdate", "maxUnavailable"),
+			}
+			podSpec := yamlGet(doc, "spec", "template", "spec")
+			config.ServiceAccountName = yamlString(podSpec, "serviceAccountName")
+			// Remember where ConfigMap volumes are mounted in the main container.
+			for i := 0; yamlGet(podSpec, "volumes", i) != nil; i++ {
+				if configMapName := yamlString(podSpec, "volumes", i, "configMap", "name"); configMapName != "" {
+					volumeName := yamlString(podSpec, "volumes", i, "name")
+					for j := 0; yamlGet(podSpec, "containers", 0, "volumeMounts", j) != nil; j++ {
+						if yamlString(podSpec, "containers", 0, "volumeMounts", j, "name") == volumeName {
+							configMapMounts[configMapName] = yamlString(podSpec, "containers", 0, "volumeMounts", j, "mountPath")
+						}
+					}
+				}
+			}
+			for i := 0; yamlGet(podSpec, "topologySpreadConstraints", i) != nil; i++ {
+				config.TopologySpread = append(config.TopologySpread, TopologySpreadConstraint{
+					MaxSkew:           yamlInt(podSpec, "topologySpreadConstraints", i, "maxSkew"),
+					TopologyKey:       yamlString(podSpec, "topologySpreadConstraints", i, "topologyKey"),
+					WhenUnsatisfiable: yamlString(podSpec, "topologySpreadConstraints", i, "whenUnsatisfiable"),
+				})
+			}
+			for i := 0; yamlGet(podSpec, "containers", i) != nil; i++ {
+				container := yamlGet(podSpec, "containers", i)
+				repository, tag := splitImage(yamlString(container, "image"))
+				if i == 0 {
+					config.ImageRepository, config.ImageTag = repository, tag
+					config.ImagePullPolicy = yamlString(container, "imagePullPolicy")
+					config.ServicePort = yamlInt(container, "ports", 0, "containerPort")
+					config.Command = yamlStringSlice(container, "command")
+					config.Args = yamlStringSlice(container, "args")
+					config.WorkingDir = yamlString(container, "workingDir")
+					config.Probes = probeFromManifest(yamlGet(container, "readinessProbe"))
+					continue
+				}
+				config.Containers = append(config.Containers, Container{
+					Name:            yamlString(container, "name"),
+					ImageRepository: repository,
+					ImageTag:        tag,
+					ImagePullPolicy: yamlString(container, "imagePullPolicy"),
+					Port:            yamlInt(container, "ports", 0, "containerPort"),
+					Command:         yamlStringSlice(container, "command"),
+					Args:            yamlStringSlice(container, "args"),
+					WorkingDir:      yamlString(container, "workingDir"),
+				})
+			}
+		case "CronJob":
+			if workloadFound {
+				logger.Warn("ignoring additional CronJob", "name", yamlString(doc, "metadata", "name"))
+				continue
+			}
+			workloadFound = true
+			config.Name = yamlString(doc, "metadata", "name")
+			config.Profile = profileCronJob
+			config.CronJob = CronJobConfig{
+				Schedule:          yamlString(doc, "spec", "schedule"),
+				ConcurrencyPolicy: yamlString(doc, "spec", "concurrencyPolicy"),
+				BackoffLimit:      yamlIntPtr(doc, "spec", "jobTemplate", "spec", "backoffLimit"),
+			}
+			container := yamlGet(doc, "spec", "jobTemplate", "spec", "template", "spec", "containers", 0)
+			config.ImageRepository, config.ImageTag = splitImage(yamlString(container, "image"))
+			config.ImagePullPolicy = yamlString(container, "imagePullPolicy")
+			config.Command = yamlStringSlice(container, "command")
+			config.Args = yamlStringSlice(container, "args")
+			config.WorkingDir = yamlString(container, "workingDir")
+		case "Service":
+			config.ServiceType = yamlString(doc, "spec", "type")
+			config.ServiceHeadless = yamlString(doc, "spec", "clusterIP") == "None"
+			if port := yamlInt(doc, "spec", "ports", 0, "port"); port != 0 {
+				config.ServicePort = port
+			}
+		case "Ingress":
+			config.IngressEnabled = true
+			config.IngressHost = yamlString(doc, "spec", "rules", 0, "host")
+			config.IngressPath = yamlString(doc, "spec", "rules", 0, "http", "paths", 0, "path")
+		case "HTTPRoute":
+			route := &config.GatewayAPI
+			route.Enabled = true
+			for i := 0; yamlGet(doc, "spec", "parentRefs", i) != nil; i++ {
+				route.ParentRefs = append(route.ParentRefs, GatewayParentRef{
+					Name:        yamlString(doc, "spec", "parentRefs", i, "name"),
+					Namespace:   yamlString(doc, "spec", "parentRefs", i, "namespace"),
+					SectionName: yamlString(doc, "spec", "parentRefs", i, "sectionName"),
+				})
+			}
+			route.Hostnames = yamlStringSlice(doc, "spec", "hostnames")
+			for i := 0; yamlGet(doc, "spec", "rules", i) != nil; i++ {
+				route.Rules = append(route.Rules, HTTPRouteRule{
+					Path:     yamlString(doc, "spec", "rules", i, "matches", 0, "path", "value"),
+					PathType: yamlString(doc, "spec", "rules", i, "matches", 0, "path", "type"),
+					Port:     yamlInt(doc, "spec", "rules", i, "backendRefs", 0, "port"),
+				})
+			}
+		case "ConfigMap":
+			config.ConfigMaps = append(config.ConfigMaps, ConfigMapConfig{
+				Name: yamlString(doc, "metadata", "name"),
+				Data: yamlStringMap(doc, "data"),
+			})
+		case "Job":
+			annotations := yamlStringMap(doc, "metadata", "annotations")
+			if annotations["helm.sh/hook"] == "" {
+				logger.Warn("resource has no config equivalent; add it to the generated chart manually", "kind", kind, "name", yamlString(doc, "metadata", "name"))
+				continue
+			}
+			container := yamlGet(doc, "spec", "template", "spec", "containers", 0)
+			repository, tag := splitImage(yamlString(container, "image"))
+			weight, _ := strconv.Atoi(annotations["helm.sh/hook-weight"])
+			config.Hooks = append(config.Hooks, HookJob{
+				Name:            yamlString(doc, "metadata", "name"),
+				Events:          splitList(annotations["helm.sh/hook"]),
+				Weight:          weight,
+				DeletePolicy:    splitList(annotations["helm.sh/hook-delete-policy"]),
+				ImageRepository: repository,
+				ImageTag:        tag,
+				ImagePullPolicy: yamlString(container, "imagePullPolicy"),
+				Command:         yamlStringSlice(container, "command"),
+				Args:            yamlStringSlice(container, "args"),
+				BackoffLimit:    yamlIntPtr(doc, "spec", "backoffLimit"),
+				ActiveDeadline:  yamlInt(doc, "spec", "activeDeadlineSeconds"),
+			})
+		case "ServiceAccount":
+			config.ServiceAccountCreate = true
+			config.ServiceAccountName = yamlString(doc, "metadata", "name")
+			config.CloudIdentity = cloudIdentityFromAnnotations(yamlStringMap(doc, "metadata", "annotations"))
+		case "HorizontalPodAutoscaler":
+			config.Autoscaling.Enabled = true
+			config.Autoscaling.MinReplicas = yamlInt(doc, "spec", "minReplicas")
+			config.Autoscaling.MaxReplicas = yamlInt(doc, "spec", "maxReplicas")
+			for i := 0; yamlGet(doc, "spec", "metrics", i) != nil; i++ {
+				switch yamlString(doc, "spec", "metrics", i, "resource", "name") {
+				case "cpu":
+					config.Autoscaling.TargetCPUUtilization = yamlInt(doc, "spec", "metrics", i, "resource", "target", "averageUtilization")
+				case "memory":
+					config.Autoscaling.TargetMemoryUtilization = yamlInt(doc, "spec", "metrics", i, "resource", "target", "averageUtilization")
+				}
+			}
+		case "Namespace":
+			config.NamespaceOverride = yamlString(doc, "metadata", "name")
+			config.Namespace = NamespaceConfig{Create: true, Labels: yamlStringMap(doc, "metadata", "labels")}
+		case "ResourceQuota":
+			config.ResourceQuota = ResourceQuotaConfig{Enabled: true, Hard: yamlStringMap(doc, "spec", "hard")}
+		case "LimitRange":
+			config.LimitRange = LimitRangeConfig{
+				Enabled:        true,
+				Default:        yamlStringMap(doc, "spec", "limits", 0, "default"),
+				DefaultRequest: yamlStringMap(doc, "spec", "limits", 0, "defaultRequest"),
+				Max:            yamlStringMap(doc, "spec", "limits", 0, "max"),
+				Min:            yamlStringMap(doc, "spec", "limits", 0, "min"),
+			}
+		case "":
+			// Not a Kubernetes object.
+		default:
+			logger.Warn("resource has no config equivalent; add it to the generated chart manually", "kind", kind, "name", yamlString(doc, "metadata", "name"))
+		}
+	}
+	if !workloadFound {
+		logger.Warn("no Deployment found; image and replica settings are empty", "dir", dir)
+	}
+	if config.Name == "" {
+		config.Name = filepath.Base(filepath.Clean(dir))
+	}
+	// Generated ConfigMaps and hooks are named <fullname>-<name>, so drop the workload prefix.
+	for i := range config.ConfigMaps {
+		configMap := &config.ConfigMaps[i]
+		configMap.MountPath = configMapMounts[configMap.Name]
+		configMap.Name = strings.TrimPrefix(configMap.Name, config.Name+"-")
+	}
+	for i := range config.Hooks {
+		config.Hooks[i].Name = strings.TrimPrefix(config.Hooks[i].Name, config.Name+"-")
+	}
+	return config, nil
+}
+
+// pruneEmpty drops keys whose values are empty (false, 0, "", empty lists
+// and maps) so derived configs only list what was actually found.
+func pruneEmpty(node interface{}) interface{} {
+	switch v := node.(type) {
+	case yaml.MapSlice:
+		var out yaml.MapSlice
+		for _, item := range v {
+			if pruned := pruneEmpty(item.Value); pruned != nil {
+				out = append(out, yaml.MapItem{Key: item.Key, Value: pruned})
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []interface{}:
+		var out []interface{}
+		for _, item := range v {
+			if pruned := pruneEmpty(item); pruned != nil {
+				out = append(out, pruned)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case bool:
+		if !v {
+			return nil
+		}
+	case int:
+		if v == 0 {
+			return nil
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+	case nil:
+		return nil
+	}
+	return node
+}
+
+// WriteDerivedConfig prints config as YAML, keeping struct field order and
+// leaving out empty settings.
+func WriteDerivedConfig(config ChartData, out io.Writer) error {
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("marshalling derived configuration: %v", err)
+	}
+	var ordered yaml.MapSlice
+	if err := yaml.Unmarshal(raw, &ordered); err != nil {
+		return fmt.Errorf("re-reading derived configuration: %v", err)
+	}
+	pruned, _ := pruneEmpty(ordered).(yaml.MapSlice)
+	// A disabled subchart is the one false value that carries meaning.
+	for i, item := range pruned {
+		subcharts, ok := item.Value.([]interface{})
+		if item.Key != "subcharts" || !ok {
+			continue
+		}
+		for j, sub := range config.Subcharts {
+			if entry, ok := subcharts[j].(yaml.MapSlice); ok && sub.Enabled != nil && !*sub.Enabled {
+				subcharts[j] = append(entry, yaml.MapItem{Key: "enabled", Value: false})
+			}
+		}
+		pruned[i].Value = subcharts
+	}
+	// The name is mandatory, so always keep it even if it came out empty.
+	if len(pruned) == 0 || pruned[0].Key != "name" {
+		pruned = append(yaml.MapSlice{{Key: "name", Value: config.Name}}, pruned...)
+	}
+	result, err := yaml.Marshal(pruned)
+	if err != nil {
+		return fmt.Errorf("marshalling derived configuration: %v", err)
+	}
+	_, err = fmt.Fprint(out, "# Derived by helm_chart_generator; review before use.\n", string(result))
+	return err
+}
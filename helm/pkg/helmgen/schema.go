@@ -0,0 +1,199 @@
+package helmgen
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// configSource is the source of the configuration types; ConfigSchema reads
+// keys from their yaml tags and descriptions from their comments.
+//
+//go:embed config.go
+var configSource string
+
+// configDefaults lists static defaults applied by normalize, keyed by schema
+// path ("[]" stands for a list item).
+var configDefaults = map[string]interface{}{
+	"istio.gateway.port":                   80,
+	"gateway_api.rules[].path_type":        "PathPrefix",
+	"external_secrets.secret_store_kind":   "SecretStore",
+	"external_secrets.refresh_interval":    "1h",
+	"sealed_secrets.scope":                 "strict",
+	"sealed_secrets.kubeseal.binary":       "kubeseal",
+	"certificate.issuer_ref.kind":          "Issuer",
+	"certificate.issuer.acme_server":       "https://acme-v02.api.letsencrypt.org/directory",
+	"certificate.issuer.ingress_class":     "nginx",
+	"topology_spread[].max_skew":           1,
+	"topology_spread[].topology_key":       "topology.kubernetes.io/zone",
+	"topology_spread[].when_unsatisfiable": "ScheduleAnyway",
+	"containers[].image_tag":               "latest",
+	"autoscaling.min_replicas":             1,
+	"vpa_update_policy.update_mode":        "Off",
+	"strategy.type":                        "RollingUpdate",
+	"hooks[].delete_policy":                []string{"before-hook-creation"},
+	"probes.path":                          "/",
+	"cron_job.concurrency_policy":          "Forbid",
+	"helmfile.environments":                []map[string]string{{"name": "default"}},
+	"generator.left_delim":                 "<<",
+	"generator.right_delim":                ">>",
+	"generator.marker_prefix":              "---",
+	"generator.line_endings":               "lf",
+}
+
+// intOrStringKeys are string settings that take Kubernetes IntOrString values
+// such as 1 or "25%".
+var intOrStringKeys = map[string]bool{
+	"strategy.max_surge":       true,
+	"strategy.max_unavailable": true,
+}
+
+// ConfigSchema returns a JSON Schema (draft-07) of the configuration file,
+// derived from the ChartData struct: yaml tags give the keys, Go types the
+// JSON types and doc comments the descriptions.
+func ConfigSchema() ([]byte, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	b := schemaBuilder{structs: make(map[string]*ast.StructType), docs: make(map[string]string)}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if st, ok := typeSpec.Type.(*ast.StructType); ok {
+				b.structs[typeSpec.Name.Name] = st
+				b.docs[typeSpec.Name.Name] = commentText(gen.Doc)
+			}
+		}
+	}
+	root, err := b.structSchema("ChartData", "")
+	if err != nil {
+		return nil, err
+	}
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["title"] = "helm_chart_generator configuration"
+	root["required"] = []string{"name"}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// schemaBuilder turns the parsed configuration types into schema objects.
+type schemaBuilder struct {
+	structs map[string]*ast.StructType
+	docs    map[string]string
+}
+
+func (b schemaBuilder) structSchema(name, path string) (map[string]interface{}, error) {
+	st, ok := b.structs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown configuration type %s", name)
+	}
+	properties := make(map[string]interface{})
+	for _, field := range st.Fields.List {
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		key := strings.Split(reflect.StructTag(tag).Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		schema, err := b.typeSchema(field.Type, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		if intOrStringKeys[fieldPath] {
+			schema["type"] = []string{"integer", "string"}
+		}
+		// A doc comment above a field only describes it if it starts with the
+		// field's name; otherwise it heads a group of fields.
+		description := commentText(field.Comment)
+		if doc := commentText(field.Doc); strings.HasPrefix(doc, field.Names[0].Name+" ") {
+			description = strings.TrimSpace(doc + " " + description)
+		}
+		if description == "" {
+			description = b.docs[elemTypeName(field.Type)]
+		}
+		if description != "" {
+			schema["description"] = description
+			if strings.HasPrefix(description, "Deprecated:") {
+				schema["deprecated"] = true
+			}
+		}
+		if value, ok := configDefaults[fieldPath]; ok {
+			schema["default"] = value
+		}
+		properties[key] = schema
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}, nil
+}
+
+func (b schemaBuilder) typeSchema(expr ast.Expr, path string) (map[string]interface{}, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return map[string]interface{}{"type": "string"}, nil
+		case "int":
+			return map[string]interface{}{"type": "integer"}, nil
+		case "bool":
+			return map[string]interface{}{"type": "boolean"}, nil
+		case "float64":
+			return map[string]interface{}{"type": "number"}, nil
+		}
+		return b.structSchema(t.Name, path)
+	case *ast.StarExpr:
+		return b.typeSchema(t.X, path)
+	case *ast.ArrayType:
+		items, err := b.typeSchema(t.Elt, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case *ast.MapType:
+		values, err := b.typeSchema(t.Value, path+".*")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case *ast.InterfaceType:
+		return map[string]interface{}{}, nil
+	}
+	return nil, fmt.Errorf("unsupported configuration type %T at %s", expr, path)
+}
+
+// elemTypeName returns the name of the named type behind pointers and
+// slices, or "" for other types.
+func elemTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return elemTypeName(t.X)
+	case *ast.ArrayType:
+		return elemTypeName(t.Elt)
+	}
+	return ""
+}
+
+// commentText returns a comment group as a single line.
+func commentText(group *ast.CommentGroup) string {
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
@@ -0,0 +1,113 @@
+package helmgen
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// schemaDefaultValue returns the value at a configDefaults path in a
+// configuration decoded from YAML, taking the first item for "[]".
+func schemaDefaultValue(root interface{}, path string) interface{} {
+	node := root
+	for _, key := range strings.Split(path, ".") {
+		list := strings.HasSuffix(key, "[]")
+		m, ok := node.(yaml.MapSlice)
+		if !ok {
+			return nil
+		}
+		node = nil
+		for _, item := range m {
+			if item.Key == strings.TrimSuffix(key, "[]") {
+				node = item.Value
+			}
+		}
+		if list {
+			items, ok := node.([]interface{})
+			if !ok || len(items) == 0 {
+				return nil
+			}
+			node = items[0]
+		}
+	}
+	return node
+}
+
+// TestConfigDefaultsMatchNormalize keeps the defaults published in the schema
+// in sync with the ones normalize applies.
+func TestConfigDefaultsMatchNormalize(t *testing.T) {
+	// Enable every section whose defaults depend on it, with one list item
+	// each; the cronjob profile needs a configuration of its own.
+	configs := []string{`name: web
+profile: web-service
+service_port: 8080
+istio: {enabled: true, gateway: {create: true}}
+gateway_api: {enabled: true, parent_refs: [{name: gateway}], rules: [{path: /}]}
+external_secrets: {enabled: true, secret_store: vault}
+sealed_secrets: {enabled: true, namespace: default}
+certificate: {enabled: true, dns_names: [web.example.com], issuer: {create: true}}
+topology_spread: [{}]
+containers: [{name: sidecar, image_repository: busybox}]
+autoscaling: {enabled: true, max_replicas: 3}
+vpa_enabled: true
+hooks: [{name: migrate, events: [pre-install]}]
+helmfile: {enabled: true}
+`, `name: job
+profile: cronjob
+cron_job: {schedule: "0 * * * *"}
+`}
+	var normalized []interface{}
+	for _, data := range configs {
+		config := loadTestConfig(t, data)
+		if err := normalize(&config, nopLogger{}); err != nil {
+			t.Fatalf("normalize: %v", err)
+		}
+		out, err := yaml.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var root yaml.MapSlice
+		if err := yaml.Unmarshal(out, &root); err != nil {
+			t.Fatal(err)
+		}
+		// Drop unset fields so they count as missing.
+		normalized = append(normalized, pruneEmpty(root))
+	}
+	// The generator syntax defaults come from the embedded template.
+	syntax := map[string]string{
+		"generator.left_delim":    embeddedSyntax.LeftDelim,
+		"generator.right_delim":   embeddedSyntax.RightDelim,
+		"generator.marker_prefix": embeddedSyntax.MarkerPrefix,
+	}
+
+	for path, want := range configDefaults {
+		wantYAML, err := yaml.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, ok := syntax[path]; ok {
+			if value != want {
+				t.Errorf("%s: schema default %v, embedded template uses %v", path, want, value)
+			}
+			continue
+		}
+		var got interface{}
+		for _, root := range normalized {
+			if got = schemaDefaultValue(root, path); got != nil {
+				break
+			}
+		}
+		if got == nil {
+			t.Errorf("%s: normalize applies no default", path)
+			continue
+		}
+		gotYAML, err := yaml.Marshal(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(gotYAML) != string(wantYAML) {
+			t.Errorf("%s: schema default %s, normalize applies %s", path, wantYAML, gotYAML)
+		}
+	}
+}
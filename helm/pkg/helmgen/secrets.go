@@ -0,0 +1,56 @@
+package helmgen
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sealSecrets runs kubeseal for every sealed_secrets.plain_data entry and
+// merges the ciphertext into a copy of EncryptedData. Plain values are passed
+// on stdin, which kubeseal --raw reads by default, so they never touch the
+// disk or the process list.
+func sealSecrets(config *ChartData, logger Logger) error {
+	sealed := &config.SealedSecrets
+	if !sealed.Enabled || len(sealed.PlainData) == 0 {
+		return nil
+	}
+	binary := sealed.Kubeseal.Binary
+	// Merge into a fresh map so the ciphertext never lands in a map shared
+	// with the caller.
+	encrypted := make(map[string]string, len(sealed.EncryptedData)+len(sealed.PlainData))
+	for key, value := range sealed.EncryptedData {
+		encrypted[key] = value
+	}
+	for key, value := range sealed.PlainData {
+		args := []string{"--raw", "--scope", sealed.Scope, "--name", sealed.SecretName}
+		if sealed.Namespace != "" {
+			args = append(args, "--namespace", sealed.Namespace)
+		}
+		if sealed.Kubeseal.Cert != "" {
+			args = append(args, "--cert", sealed.Kubeseal.Cert)
+		} else {
+			if sealed.Kubeseal.ControllerName != "" {
+				args = append(args, "--controller-name", sealed.Kubeseal.ControllerName)
+			}
+			if sealed.Kubeseal.ControllerNamespace != "" {
+				args = append(args, "--controller-namespace", sealed.Kubeseal.ControllerNamespace)
+			}
+		}
+		cmd := exec.Command(binary, args...)
+		cmd.Stdin = strings.NewReader(value)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("sealing secret key '%s' with %s: %v: %s", key, binary, err, strings.TrimSpace(stderr.String()))
+		}
+		encrypted[key] = strings.TrimSpace(string(out))
+		logger.Debug("sealed secret key", "key", key)
+	}
+	sealed.EncryptedData = encrypted
+	// Drop the clear-text values once sealed so nothing downstream can render them.
+	sealed.PlainData = nil
+	return nil
+}
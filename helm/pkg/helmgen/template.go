@@ -0,0 +1,265 @@
+package helmgen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TemplateSyntax describes how a unified template marks file boundaries
+// (marker lines) and generator actions (delimiters).
+type TemplateSyntax struct {
+	LeftDelim    string
+	RightDelim   string
+	MarkerPrefix string
+	MarkerSuffix string
+}
+
+// embeddedSyntax is the syntax of the built-in allTemplates block.
+var embeddedSyntax = TemplateSyntax{LeftDelim: "<<", RightDelim: ">>", MarkerPrefix: "---", MarkerSuffix: "---"}
+
+// markerKey reports whether line is a file marker and returns its path.
+// A bare prefix (e.g. a YAML "---" document separator) is not a marker.
+func (syntax TemplateSyntax) markerKey(line string) (string, bool) {
+	trim := strings.TrimSpace(line)
+	if len(trim) < len(syntax.MarkerPrefix)+len(syntax.MarkerSuffix) ||
+		!strings.HasPrefix(trim, syntax.MarkerPrefix) || !strings.HasSuffix(trim, syntax.MarkerSuffix) {
+		return "", false
+	}
+	key := strings.TrimSuffix(strings.TrimPrefix(trim, syntax.MarkerPrefix), syntax.MarkerSuffix)
+	// Chart paths are slash-separated, even in templates written on Windows.
+	return strings.ReplaceAll(strings.TrimSpace(key), `\`, "/"), true
+}
+
+// parseUnifiedTemplate splits the unified template content into a map,
+// where keys are relative file paths and values are the template content.
+func parseUnifiedTemplate(content string, syntax TemplateSyntax) map[string]string {
+	result := make(map[string]string)
+	lines := strings.Split(content, "\n")
+	var currentKey string
+	var currentLines []string
+	for _, line := range lines {
+		if key, ok := syntax.markerKey(line); ok {
+			if currentKey != "" && len(currentLines) > 0 {
+				result[currentKey] = strings.Join(currentLines, "\n")
+			}
+			currentKey = key
+			currentLines = []string{}
+		} else if currentKey != "" {
+			currentLines = append(currentLines, line)
+		}
+	}
+	if currentKey != "" && len(currentLines) > 0 {
+		result[currentKey] = strings.Join(currentLines, "\n")
+	}
+	return result
+}
+
+// isCoreTemplate reports whether a template belongs to the essential set
+// generated in "core" limit mode.
+func isCoreTemplate(relPath string) bool {
+	if strings.HasPrefix(relPath, "charts/") || strings.HasPrefix(relPath, "templates/istio-") {
+		return false
+	}
+	switch relPath {
+	case "templates/ingress.yaml",
+		"templates/httproute.yaml",
+		"helmfile.yaml.gotmpl",
+		".helmignore",
+		"templates/configmap.yaml",
+		"templates/hooks.yaml",
+		"templates/hpa.yaml",
+		"templates/scaledobject.yaml",
+		"templates/vpa.yaml",
+		"templates/resourcequota.yaml",
+		"templates/limitrange.yaml",
+		"templates/externalsecret.yaml",
+		"templates/sealedsecret.yaml",
+		"templates/certificate.yaml",
+		"templates/issuer.yaml":
+		return false
+	}
+	return true
+}
+
+// templateSkipReason returns why a template is not generated for the given
+// configuration, or an empty string if it should be written.
+func templateSkipReason(relPath string, data ChartData, limit string) string {
+	// In "core" mode, skip non-core files.
+	if limit == "core" && !isCoreTemplate(relPath) {
+		return "limited core output"
+	}
+	switch {
+	// Always skip library chart files if LibraryEnabled is false.
+	case strings.HasPrefix(relPath, "charts/") && !data.LibraryEnabled:
+		return "library_enabled is false"
+	// The cronjob profile replaces the Deployment with a CronJob.
+	case relPath == "templates/deployment.yaml" && data.Profile == profileCronJob:
+		return "profile is cronjob"
+	case relPath == "templates/cronjob.yaml" && data.Profile != profileCronJob:
+		return "profile is not cronjob"
+	// Skip helmfile files unless helmfile output is enabled.
+	case (relPath == "helmfile.yaml.gotmpl" || relPath == ".helmignore") && !data.Helmfile.Enabled:
+		return "helmfile.enabled is false"
+	// Skip the Service for profiles that serve no traffic.
+	case relPath == "templates/service.yaml" && !data.ServesTraffic():
+		return "profile " + data.Profile + " serves no traffic"
+	// Also skip ingress file if ingress is disabled.
+	case relPath == "templates/ingress.yaml" && !data.IngressEnabled:
+		return "ingress_enabled is false"
+	// Skip the HTTPRoute unless the Gateway API is enabled.
+	case relPath == "templates/httproute.yaml" && !data.GatewayAPI.Enabled:
+		return "gateway_api.enabled is false"
+	// Skip Istio resources unless the istio block is enabled.
+	case strings.HasPrefix(relPath, "templates/istio-") && !data.Istio.Enabled:
+		return "istio.enabled is false"
+	// Skip the ExternalSecret unless external_secrets is enabled.
+	case relPath == "templates/externalsecret.yaml" && !data.ExternalSecrets.Enabled:
+		return "external_secrets.enabled is false"
+	// Skip the SealedSecret unless sealed_secrets is enabled.
+	case relPath == "templates/sealedsecret.yaml" && !data.SealedSecrets.Enabled:
+		return "sealed_secrets.enabled is false"
+	// Skip cert-manager resources unless a certificate is requested.
+	case relPath == "templates/certificate.yaml" && !data.Certificate.Enabled:
+		return "certificate.enabled is false"
+	case relPath == "templates/issuer.yaml" && !(data.Certificate.Enabled && data.Certificate.Issuer.Create):
+		return "certificate.issuer.create is false"
+	// Skip the HPA unless autoscaling is enabled.
+	case relPath == "templates/hpa.yaml" && !data.Autoscaling.Enabled:
+		return "autoscaling.enabled is false"
+	// Skip the ScaledObject unless KEDA is enabled.
+	case relPath == "templates/scaledobject.yaml" && !data.Keda.Enabled:
+		return "keda.enabled is false"
+	// Skip the VerticalPodAutoscaler unless vpa_enabled is set.
+	case relPath == "templates/vpa.yaml" && !data.VPAEnabled:
+		return "vpa_enabled is false"
+	// Skip the Namespace unless the chart creates its own.
+	case relPath == "templates/namespace.yaml" && !data.Namespace.Create:
+		return "namespace.create is false"
+	// Skip namespace policies unless configured.
+	case relPath == "templates/resourcequota.yaml" && !data.ResourceQuota.Enabled:
+		return "resource_quota.enabled is false"
+	case relPath == "templates/limitrange.yaml" && !data.LimitRange.Enabled:
+		return "limit_range.enabled is false"
+	// Skip ConfigMaps unless any are configured.
+	case relPath == "templates/configmap.yaml" && len(data.ConfigMaps) == 0:
+		return "configmaps is empty"
+	// Skip hook Jobs unless any are configured.
+	case relPath == "templates/hooks.yaml" && len(data.Hooks) == 0:
+		return "hooks is empty"
+	// Skip the ServiceAccount unless the chart creates one.
+	case relPath == "templates/serviceaccount.yaml" && !data.ServiceAccountCreate:
+		return "service_account_create is false"
+	}
+	return ""
+}
+
+// templateFuncs returns the functions available to the unified template.
+func templateFuncs(data ChartData, limit string) template.FuncMap {
+	return template.FuncMap{
+		"or":        func(a, b bool) bool { return a || b },
+		"gt":        func(a, b int) bool { return a > b },
+		"toYaml":    toYaml,
+		"nindent":   nindent,
+		"join":      strings.Join,
+		"yamlBlock": yamlBlock,
+		// generated reports whether another chart file is written for this
+		// configuration, e.g. to checksum it.
+		"generated": func(relPath string) bool { return templateSkipReason(relPath, data, limit) == "" },
+	}
+}
+
+// renderTemplate renders a single template string using custom delimiters.
+func renderTemplate(relPath, tmplStr string, data ChartData, limit string, syntax TemplateSyntax) ([]byte, error) {
+	tmpl, err := template.New(relPath).
+		Funcs(templateFuncs(data, limit)).
+		Delims(syntax.LeftDelim, syntax.RightDelim).
+		Parse(tmplStr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template for '%s': %v", relPath, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("executing template for '%s': %v", relPath, err)
+	}
+	outContent := buf.String()
+	if strings.Contains(tmplStr, "__CHART_NAME__") {
+		outContent = strings.ReplaceAll(outContent, "__CHART_NAME__", data.Name)
+	}
+	return []byte(outContent), nil
+}
+
+// toYaml marshals a config value into a YAML fragment for embedding in
+// generated files. Nil slices and maps render as [] and {}.
+func toYaml(v interface{}) (string, error) {
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// nindent prefixes every line of s with the given number of spaces and
+// starts it on a new line, mirroring the Sprig function of the same name.
+func nindent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return "\n" + pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// yamlBlock renders v as the value of a YAML key: empty lists and maps stay
+// inline ("key: []"), anything else starts on a new line indented by spaces.
+func yamlBlock(spaces int, v interface{}) (string, error) {
+	out, err := toYaml(v)
+	if err != nil {
+		return "", err
+	}
+	if out == "[]" || out == "{}" {
+		return " " + out, nil
+	}
+	return nindent(spaces, out), nil
+}
+
+// resolveTemplateSource returns the unified template to render and its syntax.
+func resolveTemplateSource(gen GeneratorConfig, logger Logger) (string, TemplateSyntax, error) {
+	syntax := TemplateSyntax{
+		LeftDelim:    gen.LeftDelim,
+		RightDelim:   gen.RightDelim,
+		MarkerPrefix: gen.MarkerPrefix,
+		MarkerSuffix: gen.MarkerSuffix,
+	}
+	if gen.TemplateFile == "" {
+		if syntax != (TemplateSyntax{}) && syntax != embeddedSyntax {
+			return "", syntax, fmt.Errorf("custom delimiters and markers only apply to a custom template file; the embedded template uses << >> and --- path ---")
+		}
+		return allTemplates, embeddedSyntax, nil
+	}
+	if syntax.LeftDelim == "" {
+		syntax.LeftDelim = embeddedSyntax.LeftDelim
+	}
+	if syntax.RightDelim == "" {
+		syntax.RightDelim = embeddedSyntax.RightDelim
+	}
+	if syntax.MarkerPrefix == "" {
+		syntax.MarkerPrefix = embeddedSyntax.MarkerPrefix
+	}
+	if syntax.MarkerSuffix == "" {
+		syntax.MarkerSuffix = syntax.MarkerPrefix
+	}
+	raw, err := ioutil.ReadFile(localPath(gen.TemplateFile))
+	if err != nil {
+		return "", syntax, fmt.Errorf("reading template file '%s': %v", gen.TemplateFile, err)
+	}
+	// Templates saved on Windows have CRLF line endings; the output line
+	// endings are chosen by generator.line_endings instead.
+	content := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	if problems := validateUnifiedTemplate(content, syntax); len(problems) > 0 {
+		return "", syntax, fmt.Errorf("template file '%s' is invalid:\n  %s", gen.TemplateFile, strings.Join(problems, "\n  "))
+	}
+	logger.Debug("using custom template", "file", gen.TemplateFile, "left_delim", syntax.LeftDelim, "right_delim", syntax.RightDelim,
+		"marker_prefix", syntax.MarkerPrefix, "marker_suffix", syntax.MarkerSuffix)
+	return content, syntax, nil
+}
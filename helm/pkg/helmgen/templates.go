@@ -0,0 +1,1236 @@
+package helmgen
+
+// --- Unified Template ---
+// All file templates are embedded below in one single block.
+// Marker lines of the format: --- relative/path/to/file --- separate each file's content.
+const allTemplates = `--- Chart.yaml ---
+apiVersion: v2
+name: <<.Name>>
+description: <<.Description>>
+type: application
+version: <<.ChartVersion>>
+appVersion: "<<.AppVersion>>"
+<<- if .KubeVersion >>
+kubeVersion: "<<.KubeVersion>>"
+<<- end >>
+<<- if .Home >>
+home: <<.Home>>
+<<- end >>
+<<- if .Icon >>
+icon: <<.Icon>>
+<<- end >>
+<<- if .Keywords >>
+keywords:<< yamlBlock 0 .Keywords >>
+<<- end >>
+<<- if .Sources >>
+sources:<< yamlBlock 0 .Sources >>
+<<- end >>
+<<- if .Maintainers >>
+maintainers:<< yamlBlock 0 .Maintainers >>
+<<- end >>
+<<- if .ChartAnnotations >>
+annotations:<< yamlBlock 2 .ChartAnnotations >>
+<<- end >>
+<<- if .DependenciesEnabled >>
+dependencies:
+<<- if .LibraryEnabled >>
+- name: <<.LibraryName>>
+  version: "<<.LibraryVersion>>"
+  repository: "<<.LibraryRepository>>"
+<<- end >>
+<<- range .Subcharts >>
+- name: <<.Name>>
+  version: "<<.Version>>"
+  repository: "<<.Repository>>"
+<<- if .Alias >>
+  alias: <<.Alias>>
+<<- end >>
+<<- if .Condition >>
+  condition: <<.Condition>>
+<<- end >>
+<<- if .Tags >>
+  tags:<< yamlBlock 2 .Tags >>
+<<- end >>
+<<- end >>
+<<- end >>
+--- values.yaml ---
+replicaCount: <<.ReplicaCount>>
+
+image:
+  repository: <<.ImageRepository>>
+  pullPolicy: <<.ImagePullPolicy>>
+  tag: "<<.ImageTag>>"
+
+# Main container entrypoint; empty values keep the image defaults.
+command:<< yamlBlock 2 .Command >>
+args:<< yamlBlock 2 .Args >>
+workingDir: "<<.WorkingDir>>"
+
+# Deployment update strategy.
+strategy:
+  type: <<.Strategy.Type>>
+<<- if eq .Strategy.Type "RollingUpdate" >>
+  rollingUpdate:
+    maxSurge: <<.Strategy.MaxSurge>>
+    maxUnavailable: <<.Strategy.MaxUnavailable>>
+<<- end >>
+<<- if .Containers >>
+
+# Additional containers running alongside the main container.
+containers:
+<<- range .Containers >>
+  - name: <<.Name>>
+    image:
+      repository: <<.ImageRepository>>
+      tag: "<<.ImageTag>>"
+      pullPolicy: <<.ImagePullPolicy>>
+    port: <<.Port>>
+    command:<< yamlBlock 6 .Command >>
+    args:<< yamlBlock 6 .Args >>
+    workingDir: "<<.WorkingDir>>"
+<<- end >>
+<<- end >>
+<<- if .ConfigMaps >>
+
+# ConfigMaps, each named <fullname>-<name>; a non-empty mountPath mounts it
+# into the main container.
+configMaps:
+<<- range .ConfigMaps >>
+  <<.Name>>:
+    mountPath: "<<.MountPath>>"
+    data:<< yamlBlock 6 .Data >>
+<<- end >>
+<<- end >>
+<<- if .Hooks >>
+
+# Helm hook Jobs, e.g. database migrations before install/upgrade.
+hooks:
+<<- range .Hooks >>
+  <<.Name>>:
+    events: "<< join .Events "," >>"
+    weight: "<<.Weight>>"
+    deletePolicy: "<< join .DeletePolicy "," >>"
+    image:
+      repository: <<.ImageRepository>>
+      tag: "<<.ImageTag>>"
+      pullPolicy: <<.ImagePullPolicy>>
+    command:<< yamlBlock 6 .Command >>
+    args:<< yamlBlock 6 .Args >>
+<<- with .BackoffLimit >>
+    backoffLimit: << . >>
+<<- end >>
+    activeDeadlineSeconds: <<.ActiveDeadline>>
+<<- end >>
+<<- end >>
+<<- if eq .Profile "cronjob" >>
+
+cronJob:
+  schedule: "<<.CronJob.Schedule>>"
+  concurrencyPolicy: <<.CronJob.ConcurrencyPolicy>>
+<<- with .CronJob.BackoffLimit >>
+  backoffLimit: << . >>
+<<- end >>
+<<- end >>
+<<- if .ServesTraffic >>
+
+service:
+  type: <<.ServiceType>>
+  port: <<.ServicePort>>
+<<- end >>
+<<- if or .ServiceAccountCreate (ne .ServiceAccountName "") >>
+
+serviceAccount:
+  create: <<.ServiceAccountCreate>>
+  name: "<<.ServiceAccountName>>"
+  annotations:<< yamlBlock 4 .CloudIdentity.Annotations >>
+<<- end >>
+<<- if .Autoscaling.Enabled >>
+
+autoscaling:
+  enabled: true
+  minReplicas: <<.Autoscaling.MinReplicas>>
+  maxReplicas: <<.Autoscaling.MaxReplicas>>
+  targetCPUUtilizationPercentage: <<.Autoscaling.TargetCPUUtilization>>
+  targetMemoryUtilizationPercentage: <<.Autoscaling.TargetMemoryUtilization>>
+  # Additional autoscaling/v2 metric specs (Pods, Object, External).
+  metrics:<< yamlBlock 4 .Autoscaling.Metrics >>
+<<- end >>
+<<- if .Keda.Enabled >>
+
+keda:
+  enabled: true
+  minReplicas: <<.Keda.MinReplicas>>
+  maxReplicas: <<.Keda.MaxReplicas>>
+  pollingInterval: <<.Keda.PollingInterval>>
+  cooldownPeriod: <<.Keda.CooldownPeriod>>
+  triggers:
+<<- range .Keda.Triggers >>
+    - type: <<.Type>>
+      metadata:<< yamlBlock 8 .Metadata >>
+<<- if .AuthenticationRef >>
+      authenticationRef:
+        name: <<.AuthenticationRef>>
+<<- end >>
+<<- end >>
+<<- end >>
+<<- if .VPAEnabled >>
+
+vpa:
+  enabled: true
+  updateMode: "<<.VPAPolicy.UpdateMode>>"
+  controlledResources:<< yamlBlock 4 .VPAPolicy.ControlledResources >>
+  minAllowed:<< yamlBlock 4 .VPAPolicy.MinAllowed >>
+  maxAllowed:<< yamlBlock 4 .VPAPolicy.MaxAllowed >>
+<<- end >>
+<<- if .TopologySpread >>
+
+topologySpreadConstraints:
+<<- range .TopologySpread >>
+  - maxSkew: <<.MaxSkew>>
+    topologyKey: <<.TopologyKey>>
+    whenUnsatisfiable: <<.WhenUnsatisfiable>>
+<<- end >>
+<<- end >>
+<<- if .Istio.Enabled >>
+
+istio:
+  enabled: true
+  gateway:
+    create: <<.Istio.Gateway.Create>>
+<<- if .Istio.Gateway.Create >>
+    port: <<.Istio.Gateway.Port>>
+    selector:<< yamlBlock 6 .Istio.Gateway.Selector >>
+    tlsCredential: "<<.Istio.Gateway.TLSCredential>>"
+<<- end >>
+  gateways:<< yamlBlock 4 .Istio.Gateways >>
+  hosts:<< yamlBlock 4 .Istio.Hosts >>
+  trafficPolicy:<< yamlBlock 4 .Istio.TrafficPolicy >>
+  subsets:<< yamlBlock 4 .Istio.Subsets >>
+<<- end >>
+<<- if .GatewayAPI.Enabled >>
+
+gatewayAPI:
+  enabled: true
+  parentRefs:
+<<- range .GatewayAPI.ParentRefs >>
+    - name: <<.Name>>
+<<- if .Namespace >>
+      namespace: <<.Namespace>>
+<<- end >>
+<<- if .SectionName >>
+      sectionName: <<.SectionName>>
+<<- end >>
+<<- end >>
+  hostnames:<< yamlBlock 4 .GatewayAPI.Hostnames >>
+  rules:
+<<- range .GatewayAPI.Rules >>
+    - path: <<.Path>>
+      pathType: <<.PathType>>
+      port: <<.Port>>
+      filters:<< yamlBlock 8 .Filters >>
+<<- end >>
+<<- end >>
+<<- if .ExternalSecrets.Enabled >>
+
+externalSecrets:
+  enabled: true
+  refreshInterval: <<.ExternalSecrets.RefreshInterval>>
+  secretStoreRef:
+    name: <<.ExternalSecrets.SecretStore>>
+    kind: <<.ExternalSecrets.SecretStoreKind>>
+  data:
+<<- range .ExternalSecrets.Data >>
+    - secretKey: <<.SecretKey>>
+      remoteRef:
+        key: <<.RemoteKey>>
+<<- if .Property >>
+        property: <<.Property>>
+<<- end >>
+<<- end >>
+<<- end >>
+<<- if .SealedSecrets.Enabled >>
+
+sealedSecrets:
+  enabled: true
+  name: <<.SealedSecrets.SecretName>>
+  namespace: "<<.SealedSecrets.Namespace>>"
+  scope: <<.SealedSecrets.Scope>>
+  encryptedData:<< yamlBlock 4 .SealedSecrets.EncryptedData >>
+<<- end >>
+<<- if .Certificate.Enabled >>
+
+certificate:
+  enabled: true
+  secretName: <<.Certificate.SecretName>>
+  dnsNames:<< yamlBlock 4 .Certificate.DNSNames >>
+  issuerRef:
+    name: <<.Certificate.IssuerRef.Name>>
+    kind: <<.Certificate.IssuerRef.Kind>>
+<<- if .Certificate.Issuer.Create >>
+  issuer:
+    create: true
+    acmeEmail: "<<.Certificate.Issuer.ACMEEmail>>"
+    acmeServer: <<.Certificate.Issuer.ACMEServer>>
+    ingressClass: <<.Certificate.Issuer.IngressClass>>
+<<- end >>
+<<- end >>
+
+<<- if .Namespace.Create >>
+
+namespace:
+  create: true
+  labels:<< yamlBlock 4 .Namespace.Labels >>
+  annotations:<< yamlBlock 4 .Namespace.Annotations >>
+<<- end >>
+<<- if .ResourceQuota.Enabled >>
+
+resourceQuota:
+  enabled: true
+  hard:<< yamlBlock 4 .ResourceQuota.Hard >>
+<<- end >>
+<<- if .LimitRange.Enabled >>
+
+limitRange:
+  enabled: true
+  default:<< yamlBlock 4 .LimitRange.Default >>
+  defaultRequest:<< yamlBlock 4 .LimitRange.DefaultRequest >>
+  max:<< yamlBlock 4 .LimitRange.Max >>
+  min:<< yamlBlock 4 .LimitRange.Min >>
+<<- end >>
+<<- if .CommonLabels >>
+
+# Added to the labels of every resource and pod.
+commonLabels:<< yamlBlock 2 .CommonLabels >>
+<<- end >>
+<<- if .CommonAnnotations >>
+
+# Added to the annotations of every resource.
+commonAnnotations:<< yamlBlock 2 .CommonAnnotations >>
+<<- end >>
+<<- if .Global >>
+
+# Shared with every subchart as .Values.global.
+global:<< yamlBlock 2 .Global >>
+<<- end >>
+<<- if .DependenciesEnabled >>
+<<- range .Subcharts >>
+
+# Values passed to the <<.Key>> subchart.
+<<- if not .HasSettings >>
+# Commonly tunable: replicaCount, image.repository, image.tag; unset keys keep the subchart's defaults.
+<<- end >>
+<<.Key>>:<< yamlBlock 2 .Values >>
+<<- end >>
+<<- if .SubchartTags >>
+
+# Subchart tags; setting one to false disables every subchart carrying it
+# that has no condition of its own.
+tags:
+<<- range .SubchartTags >>
+  <<.>>: true
+<<- end >>
+<<- end >>
+<<- end >>
+
+# Optional overrides
+nameOverride: ""
+fullnameOverride: ""
+namespaceOverride: "<<.NamespaceOverride>>"
+--- templates/_helpers.tpl ---
+{{/*
+Return the base chart name.
+*/}}
+{{- define "__CHART_NAME__.name" -}}
+{{- default .Chart.Name .Values.nameOverride | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+
+{{/*
+Return a fully-qualified name.
+*/}}
+{{- define "__CHART_NAME__.fullname" -}}
+{{- if .Values.fullnameOverride -}}
+  {{- .Values.fullnameOverride | trunc 63 | trimSuffix "-" -}}
+{{- else -}}
+  {{- $name := default .Chart.Name .Values.nameOverride -}}
+  {{- printf "%s-%s" .Release.Name $name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+Return the namespace to deploy into.
+*/}}
+{{- define "__CHART_NAME__.namespace" -}}
+{{- default .Release.Namespace .Values.namespaceOverride | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+<<- if or .ServiceAccountCreate (ne .ServiceAccountName "") >>
+
+{{/*
+Return the name of the ServiceAccount to use.
+*/}}
+{{- define "__CHART_NAME__.serviceAccountName" -}}
+{{- if .Values.serviceAccount.create -}}
+  {{- default (include "__CHART_NAME__.fullname" .) .Values.serviceAccount.name -}}
+{{- else -}}
+  {{- default "default" .Values.serviceAccount.name -}}
+{{- end -}}
+{{- end -}}
+<<- end >>
+--- templates/namespace.yaml ---
+{{- if .Values.namespace.create }}
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with merge (dict) (.Values.namespace.labels | default dict) (.Values.commonLabels | default dict) }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with merge (dict) (.Values.namespace.annotations | default dict) (.Values.commonAnnotations | default dict) }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+--- templates/resourcequota.yaml ---
+{{- if .Values.resourceQuota.enabled }}
+apiVersion: v1
+kind: ResourceQuota
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  hard:
+    {{- toYaml .Values.resourceQuota.hard | nindent 4 }}
+{{- end }}
+--- templates/limitrange.yaml ---
+{{- if .Values.limitRange.enabled }}
+apiVersion: v1
+kind: LimitRange
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  limits:
+  - type: Container
+    {{- with .Values.limitRange.default }}
+    default:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.limitRange.defaultRequest }}
+    defaultRequest:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.limitRange.max }}
+    max:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    {{- with .Values.limitRange.min }}
+    min:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+{{- end }}
+--- templates/deployment.yaml ---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+<<- if .Autoscaling.Enabled >>
+  {{- if not .Values.autoscaling.enabled }}
+  replicas: {{ .Values.replicaCount }}
+  {{- end }}
+<<- else if .Keda.Enabled >>
+  {{- if not .Values.keda.enabled }}
+  replicas: {{ .Values.replicaCount }}
+  {{- end }}
+<<- else >>
+  replicas: <<.ReplicaCount>>
+<<- end >>
+  {{- with .Values.strategy }}
+  strategy:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  selector:
+    matchLabels:
+      app: {{ include "__CHART_NAME__.name" . }}
+  template:
+    metadata:
+<<- if or (generated "templates/configmap.yaml") (or (generated "templates/externalsecret.yaml") (generated "templates/sealedsecret.yaml")) >>
+      annotations:
+<<- if generated "templates/configmap.yaml" >>
+        checksum/config: {{ include (print $.Template.BasePath "/configmap.yaml") . | sha256sum }}
+<<- end >>
+<<- if or (generated "templates/externalsecret.yaml") (generated "templates/sealedsecret.yaml") >>
+        checksum/secret: {{ print
+<<- if generated "templates/externalsecret.yaml" >> (include (print $.Template.BasePath "/externalsecret.yaml") .)<<end>>
+<<- if generated "templates/sealedsecret.yaml" >> (include (print $.Template.BasePath "/sealedsecret.yaml") .)<<end>> | sha256sum }}
+<<- end >>
+<<- end >>
+      labels:
+        app: {{ include "__CHART_NAME__.name" . }}
+<<- if .CloudIdentity.AzureClientID >>
+        azure.workload.identity/use: "true"
+<<- end >>
+        {{- with .Values.commonLabels }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+    spec:
+<<- if or .ServiceAccountCreate (ne .ServiceAccountName "") >>
+      serviceAccountName: {{ include "__CHART_NAME__.serviceAccountName" . }}
+<<- end >>
+<<- if .TopologySpread >>
+      {{- with .Values.topologySpreadConstraints }}
+      topologySpreadConstraints:
+      {{- range . }}
+      - maxSkew: {{ .maxSkew }}
+        topologyKey: {{ .topologyKey }}
+        whenUnsatisfiable: {{ .whenUnsatisfiable }}
+        labelSelector:
+          matchLabels:
+            app: {{ include "__CHART_NAME__.name" $ }}
+      {{- end }}
+      {{- end }}
+<<- end >>
+      containers:
+      - name: {{ include "__CHART_NAME__.name" . }}
+        image: "<<.ImageRepository>>:<<.ImageTag>>"
+        imagePullPolicy: <<.ImagePullPolicy>>
+        {{- with .Values.command }}
+        command:
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        {{- with .Values.args }}
+        args:
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        {{- with .Values.workingDir }}
+        workingDir: {{ . }}
+        {{- end }}
+<<- if .ServesTraffic >>
+        ports:
+        - name: <<.ServicePortName>>
+          containerPort: <<.ServicePort>>
+<<- end >>
+<<- if .Probes.Type >>
+        livenessProbe:
+<<- template "probe" .Probes >>
+        readinessProbe:
+<<- template "probe" .Probes >>
+<<- end >>
+<<- if .MountedConfigMaps >>
+        volumeMounts:
+        {{- range $name, $configMap := .Values.configMaps }}
+        {{- if $configMap.mountPath }}
+        - name: configmap-{{ $name }}
+          mountPath: {{ $configMap.mountPath }}
+          readOnly: true
+        {{- end }}
+        {{- end }}
+<<- end >>
+<<- if .Containers >>
+      {{- range .Values.containers }}
+      - name: {{ .name }}
+        image: "{{ .image.repository }}:{{ .image.tag }}"
+        imagePullPolicy: {{ .image.pullPolicy }}
+        {{- with .command }}
+        command:
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        {{- with .args }}
+        args:
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        {{- with .workingDir }}
+        workingDir: {{ . }}
+        {{- end }}
+        {{- if .port }}
+        ports:
+        - containerPort: {{ .port }}
+        {{- end }}
+      {{- end }}
+<<- end >>
+<<- if .MountedConfigMaps >>
+      volumes:
+      {{- range $name, $configMap := .Values.configMaps }}
+      {{- if $configMap.mountPath }}
+      - name: configmap-{{ $name }}
+        configMap:
+          name: {{ include "__CHART_NAME__.fullname" $ }}-{{ $name }}
+      {{- end }}
+      {{- end }}
+<<- end >>
+<<- define "probe" >>
+<<- if eq .Type "http" >>
+          httpGet:
+            path: <<.Path>>
+            port: <<.Port>>
+<<- else if eq .Type "grpc" >>
+          grpc:
+            port: <<.Port>>
+<<- else >>
+          tcpSocket:
+            port: <<.Port>>
+<<- end >>
+<<- end >>
+--- templates/serviceaccount.yaml ---
+{{- if .Values.serviceAccount.create }}
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: {{ include "__CHART_NAME__.serviceAccountName" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with merge (dict) (.Values.serviceAccount.annotations | default dict) (.Values.commonAnnotations | default dict) }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+--- templates/hpa.yaml ---
+{{- if .Values.autoscaling.enabled }}
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ include "__CHART_NAME__.fullname" . }}
+  minReplicas: {{ .Values.autoscaling.minReplicas }}
+  maxReplicas: {{ .Values.autoscaling.maxReplicas }}
+  metrics:
+  {{- with .Values.autoscaling.targetCPUUtilizationPercentage }}
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: {{ . }}
+  {{- end }}
+  {{- with .Values.autoscaling.targetMemoryUtilizationPercentage }}
+  - type: Resource
+    resource:
+      name: memory
+      target:
+        type: Utilization
+        averageUtilization: {{ . }}
+  {{- end }}
+  {{- with .Values.autoscaling.metrics }}
+  {{- toYaml . | nindent 2 }}
+  {{- end }}
+{{- end }}
+--- templates/scaledobject.yaml ---
+{{- if .Values.keda.enabled }}
+apiVersion: keda.sh/v1alpha1
+kind: ScaledObject
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  scaleTargetRef:
+    name: {{ include "__CHART_NAME__.fullname" . }}
+  minReplicaCount: {{ .Values.keda.minReplicas }}
+  maxReplicaCount: {{ .Values.keda.maxReplicas }}
+  pollingInterval: {{ .Values.keda.pollingInterval }}
+  cooldownPeriod: {{ .Values.keda.cooldownPeriod }}
+  triggers:
+  {{- toYaml .Values.keda.triggers | nindent 2 }}
+{{- end }}
+--- templates/vpa.yaml ---
+{{- if .Values.vpa.enabled }}
+apiVersion: autoscaling.k8s.io/v1
+kind: VerticalPodAutoscaler
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  targetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ include "__CHART_NAME__.fullname" . }}
+  updatePolicy:
+    updateMode: {{ .Values.vpa.updateMode | quote }}
+  resourcePolicy:
+    containerPolicies:
+    - containerName: "*"
+      controlledResources:
+      {{- toYaml .Values.vpa.controlledResources | nindent 6 }}
+      {{- with .Values.vpa.minAllowed }}
+      minAllowed:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+      {{- with .Values.vpa.maxAllowed }}
+      maxAllowed:
+        {{- toYaml . | nindent 8 }}
+      {{- end }}
+{{- end }}
+--- templates/service.yaml ---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  type: <<.ServiceType>>
+<<- if .ServiceHeadless >>
+  clusterIP: None
+<<- end >>
+  ports:
+  - port: <<.ServicePort>>
+    targetPort: <<.ServicePort>>
+    protocol: TCP
+    name: <<.ServicePortName>>
+<<- range .Containers >>
+<<- if .Expose >>
+  - port: <<.Port>>
+    targetPort: <<.Port>>
+    protocol: TCP
+    name: <<.Name>>
+<<- end >>
+<<- end >>
+  selector:
+    app: {{ include "__CHART_NAME__.name" . }}
+--- templates/ingress.yaml ---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  rules:
+  - host: <<.IngressHost>>
+    http:
+      paths:
+      - path: <<.IngressPath>>
+        pathType: ImplementationSpecific
+        backend:
+          service:
+            name: {{ include "__CHART_NAME__.fullname" . }}
+            port:
+              number: <<.ServicePort>>
+<<- if .Certificate.Enabled >>
+  {{- if .Values.certificate.enabled }}
+  tls:
+  - hosts:
+    {{- toYaml .Values.certificate.dnsNames | nindent 4 }}
+    secretName: {{ .Values.certificate.secretName }}
+  {{- end }}
+<<- end >>
+--- templates/httproute.yaml ---
+{{- if .Values.gatewayAPI.enabled }}
+apiVersion: gateway.networking.k8s.io/v1
+kind: HTTPRoute
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  parentRefs:
+    {{- toYaml .Values.gatewayAPI.parentRefs | nindent 4 }}
+  {{- with .Values.gatewayAPI.hostnames }}
+  hostnames:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  rules:
+  {{- range .Values.gatewayAPI.rules }}
+  - matches:
+    - path:
+        type: {{ .pathType }}
+        value: {{ .path | quote }}
+    {{- with .filters }}
+    filters:
+      {{- toYaml . | nindent 6 }}
+    {{- end }}
+    backendRefs:
+    - name: {{ include "__CHART_NAME__.fullname" $ }}
+      port: {{ .port }}
+  {{- end }}
+{{- end }}
+--- templates/istio-gateway.yaml ---
+{{- if and .Values.istio.enabled .Values.istio.gateway.create }}
+apiVersion: networking.istio.io/v1beta1
+kind: Gateway
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  selector:
+    {{- toYaml .Values.istio.gateway.selector | nindent 4 }}
+  servers:
+  - port:
+      number: {{ .Values.istio.gateway.port }}
+      name: http
+      protocol: HTTP
+    hosts:
+    {{- toYaml .Values.istio.hosts | nindent 4 }}
+  {{- if .Values.istio.gateway.tlsCredential }}
+  - port:
+      number: 443
+      name: https
+      protocol: HTTPS
+    tls:
+      mode: SIMPLE
+      credentialName: {{ .Values.istio.gateway.tlsCredential }}
+    hosts:
+    {{- toYaml .Values.istio.hosts | nindent 4 }}
+  {{- end }}
+{{- end }}
+--- templates/istio-virtualservice.yaml ---
+{{- if .Values.istio.enabled }}
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  hosts:
+  {{- toYaml .Values.istio.hosts | nindent 2 }}
+  gateways:
+  {{- if .Values.istio.gateway.create }}
+  - {{ include "__CHART_NAME__.fullname" . }}
+  {{- end }}
+  {{- range .Values.istio.gateways }}
+  - {{ . }}
+  {{- end }}
+  http:
+  - route:
+    - destination:
+        host: {{ include "__CHART_NAME__.fullname" . }}
+        port:
+          number: {{ .Values.service.port }}
+{{- end }}
+--- templates/istio-destinationrule.yaml ---
+{{- if and .Values.istio.enabled (or .Values.istio.trafficPolicy .Values.istio.subsets) }}
+apiVersion: networking.istio.io/v1beta1
+kind: DestinationRule
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  host: {{ include "__CHART_NAME__.fullname" . }}
+  {{- with .Values.istio.trafficPolicy }}
+  trafficPolicy:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+  {{- with .Values.istio.subsets }}
+  subsets:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+{{- end }}
+--- templates/externalsecret.yaml ---
+{{- if .Values.externalSecrets.enabled }}
+apiVersion: external-secrets.io/v1beta1
+kind: ExternalSecret
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  refreshInterval: {{ .Values.externalSecrets.refreshInterval }}
+  secretStoreRef:
+    {{- toYaml .Values.externalSecrets.secretStoreRef | nindent 4 }}
+  target:
+    name: {{ include "__CHART_NAME__.fullname" . }}
+    creationPolicy: Owner
+  data:
+    {{- toYaml .Values.externalSecrets.data | nindent 4 }}
+{{- end }}
+--- templates/sealedsecret.yaml ---
+{{- if .Values.sealedSecrets.enabled }}
+apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: {{ .Values.sealedSecrets.name }}
+  {{- with .Values.sealedSecrets.namespace }}
+  namespace: {{ . }}
+  {{- end }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- if or .Values.commonAnnotations (ne .Values.sealedSecrets.scope "strict") }}
+  annotations:
+    {{- if eq .Values.sealedSecrets.scope "namespace-wide" }}
+    sealedsecrets.bitnami.com/namespace-wide: "true"
+    {{- else if eq .Values.sealedSecrets.scope "cluster-wide" }}
+    sealedsecrets.bitnami.com/cluster-wide: "true"
+    {{- end }}
+    {{- with .Values.commonAnnotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- end }}
+spec:
+  encryptedData:
+    {{- toYaml .Values.sealedSecrets.encryptedData | nindent 4 }}
+  template:
+    metadata:
+      name: {{ .Values.sealedSecrets.name }}
+      labels:
+        app: {{ include "__CHART_NAME__.name" . }}
+        {{- with .Values.commonLabels }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+{{- end }}
+--- templates/certificate.yaml ---
+{{- if .Values.certificate.enabled }}
+apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  secretName: {{ .Values.certificate.secretName }}
+  dnsNames:
+  {{- toYaml .Values.certificate.dnsNames | nindent 2 }}
+  issuerRef:
+    name: {{ .Values.certificate.issuerRef.name }}
+    kind: {{ .Values.certificate.issuerRef.kind }}
+    group: cert-manager.io
+{{- end }}
+--- templates/issuer.yaml ---
+{{- if and .Values.certificate.enabled .Values.certificate.issuer.create }}
+apiVersion: cert-manager.io/v1
+kind: Issuer
+metadata:
+  name: {{ .Values.certificate.issuerRef.name }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  acme:
+    server: {{ .Values.certificate.issuer.acmeServer }}
+    email: {{ .Values.certificate.issuer.acmeEmail | quote }}
+    privateKeySecretRef:
+      name: {{ .Values.certificate.issuerRef.name }}-account-key
+    solvers:
+    - http01:
+        ingress:
+          ingressClassName: {{ .Values.certificate.issuer.ingressClass }}
+{{- end }}
+--- templates/cronjob.yaml ---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" . }}
+  namespace: {{ include "__CHART_NAME__.namespace" . }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" . }}
+    {{- with .Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with .Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+spec:
+  schedule: {{ .Values.cronJob.schedule | quote }}
+  concurrencyPolicy: {{ .Values.cronJob.concurrencyPolicy }}
+  jobTemplate:
+    spec:
+      {{- if hasKey .Values.cronJob "backoffLimit" }}
+      backoffLimit: {{ .Values.cronJob.backoffLimit }}
+      {{- end }}
+      template:
+        metadata:
+          labels:
+            app: {{ include "__CHART_NAME__.name" . }}
+            {{- with .Values.commonLabels }}
+            {{- toYaml . | nindent 12 }}
+            {{- end }}
+        spec:
+          restartPolicy: OnFailure
+<<- if or .ServiceAccountCreate (ne .ServiceAccountName "") >>
+          serviceAccountName: {{ include "__CHART_NAME__.serviceAccountName" . }}
+<<- end >>
+          containers:
+          - name: {{ include "__CHART_NAME__.name" . }}
+            image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+            imagePullPolicy: {{ .Values.image.pullPolicy }}
+            {{- with .Values.command }}
+            command:
+            {{- toYaml . | nindent 12 }}
+            {{- end }}
+            {{- with .Values.args }}
+            args:
+            {{- toYaml . | nindent 12 }}
+            {{- end }}
+            {{- with .Values.workingDir }}
+            workingDir: {{ . }}
+            {{- end }}
+<<- if .MountedConfigMaps >>
+            volumeMounts:
+            {{- range $name, $configMap := .Values.configMaps }}
+            {{- if $configMap.mountPath }}
+            - name: configmap-{{ $name }}
+              mountPath: {{ $configMap.mountPath }}
+              readOnly: true
+            {{- end }}
+            {{- end }}
+          volumes:
+          {{- range $name, $configMap := .Values.configMaps }}
+          {{- if $configMap.mountPath }}
+          - name: configmap-{{ $name }}
+            configMap:
+              name: {{ include "__CHART_NAME__.fullname" $ }}-{{ $name }}
+          {{- end }}
+          {{- end }}
+<<- end >>
+--- templates/hooks.yaml ---
+{{- range $name, $hook := .Values.hooks }}
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" $ }}-{{ $name }}
+  namespace: {{ include "__CHART_NAME__.namespace" $ }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" $ }}
+    {{- with $.Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  annotations:
+    helm.sh/hook: {{ $hook.events }}
+    helm.sh/hook-weight: {{ $hook.weight | quote }}
+    helm.sh/hook-delete-policy: {{ $hook.deletePolicy }}
+    {{- with $.Values.commonAnnotations }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+spec:
+  {{- if hasKey $hook "backoffLimit" }}
+  backoffLimit: {{ $hook.backoffLimit }}
+  {{- end }}
+  {{- with $hook.activeDeadlineSeconds }}
+  activeDeadlineSeconds: {{ . }}
+  {{- end }}
+  template:
+    metadata:
+      # No "app" label, so the chart's Service never routes to hook pods.
+      labels:
+        hook: {{ include "__CHART_NAME__.fullname" $ }}-{{ $name }}
+        {{- with $.Values.commonLabels }}
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+    spec:
+      restartPolicy: Never
+<<- if or .ServiceAccountCreate (ne .ServiceAccountName "") >>
+      serviceAccountName: {{ include "__CHART_NAME__.serviceAccountName" $ }}
+<<- end >>
+      containers:
+      - name: {{ $name }}
+        image: "{{ $hook.image.repository }}:{{ $hook.image.tag }}"
+        imagePullPolicy: {{ $hook.image.pullPolicy }}
+        {{- with $hook.command }}
+        command:
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+        {{- with $hook.args }}
+        args:
+        {{- toYaml . | nindent 8 }}
+        {{- end }}
+{{- end }}
+--- templates/configmap.yaml ---
+{{- range $name, $configMap := .Values.configMaps }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ include "__CHART_NAME__.fullname" $ }}-{{ $name }}
+  namespace: {{ include "__CHART_NAME__.namespace" $ }}
+  labels:
+    app: {{ include "__CHART_NAME__.name" $ }}
+    {{- with $.Values.commonLabels }}
+    {{- toYaml . | nindent 4 }}
+    {{- end }}
+  {{- with $.Values.commonAnnotations }}
+  annotations:
+    {{- toYaml . | nindent 4 }}
+  {{- end }}
+data:
+  {{- toYaml $configMap.data | nindent 2 }}
+{{- end }}
+--- helmfile.yaml.gotmpl ---
+# Install with: helmfile -e <environment> apply
+environments:
+<<- range .Helmfile.Environments >>
+  <<.Name>>: {}
+<<- end >>
+---
+releases:
+  - name: <<.Helmfile.ReleaseName>>
+<<- if .Helmfile.Namespace >>
+    namespace: <<.Helmfile.Namespace>>
+<<- end >>
+    chart: .
+    values:
+      - environments/{{ .Environment.Name }}.yaml
+<<- if .Helmfile.Secrets >>
+    secrets:
+      - environments/{{ .Environment.Name }}.secrets.yaml
+<<- end >>
+--- .helmignore ---
+# helmfile files are not part of the chart package.
+helmfile.yaml.gotmpl
+environments/
+--- charts/library/Chart.yaml ---
+apiVersion: v2
+name: <<.LibraryName>>
+description: "Library chart containing common helper templates."
+type: library
+version: <<.LibraryVersion>>
+--- charts/library/values.yaml ---
+# Library chart values (customize if needed).
+--- charts/library/templates/_helpers.tpl ---
+{{/*
+Common helper functions for shared usage.
+Part of the library chart.
+*/}}
+{{- define "common.name" -}}
+{{- default .Chart.Name .Values.nameOverride | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+{{- define "common.fullname" -}}
+{{- if .Values.fullnameOverride -}}
+  {{- .Values.fullnameOverride | trunc 63 | trimSuffix "-" -}}
+{{- else -}}
+  {{- $name := default .Chart.Name .Values.nameOverride -}}
+  {{- printf "%s-%s" .Release.Name $name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+{{- end -}}
+`
@@ -0,0 +1,186 @@
+package helmgen
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// --- Template self-check ---
+// validateUnifiedTemplate catches mistakes in a unified template
+// (orphaned markers, unparseable blocks, references to unknown ChartData
+// fields such as a missing dot in <<ReplicaCount>>) before any file is written.
+// It runs on every start of the CLI and for every custom template file.
+
+// CheckEmbeddedTemplate validates the built-in unified template.
+func CheckEmbeddedTemplate() error {
+	if problems := validateUnifiedTemplate(allTemplates, embeddedSyntax); len(problems) > 0 {
+		return fmt.Errorf("embedded unified template is invalid:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// validateUnifiedTemplate returns one message per problem found in content.
+func validateUnifiedTemplate(content string, syntax TemplateSyntax) []string {
+	var problems []string
+	seen := make(map[string]int)
+	var currentKey string
+	blockLines := 0
+	reportedOrphan := false
+	closeBlock := func(lineNo int) {
+		if currentKey != "" && blockLines == 0 {
+			problems = append(problems, fmt.Sprintf("line %d: marker for '%s' has no content and would be dropped", lineNo, currentKey))
+		}
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lineNo := i + 1
+		trim := strings.TrimSpace(line)
+		key, isMarker := syntax.markerKey(line)
+		switch {
+		case isMarker:
+			closeBlock(lineNo)
+			if key == "" {
+				problems = append(problems, fmt.Sprintf("line %d: marker has no file path; following lines would be dropped", lineNo))
+			} else if first, dup := seen[key]; dup {
+				problems = append(problems, fmt.Sprintf("line %d: duplicate marker for '%s' (first at line %d)", lineNo, key, first))
+			} else {
+				seen[key] = lineNo
+			}
+			// An empty marker was already reported; don't flag every line it swallows.
+			currentKey, blockLines, reportedOrphan = key, 0, key == ""
+		case strings.HasPrefix(trim, syntax.MarkerPrefix+" ") || strings.HasSuffix(trim, " "+syntax.MarkerSuffix):
+			problems = append(problems, fmt.Sprintf("line %d: orphaned marker %q is missing its opening or closing %s", lineNo, trim, syntax.MarkerSuffix))
+		case currentKey == "" && trim != "":
+			if !reportedOrphan {
+				problems = append(problems, fmt.Sprintf("line %d: content outside of any file marker", lineNo))
+				reportedOrphan = true
+			}
+		default:
+			blockLines++
+		}
+	}
+	closeBlock(len(lines))
+
+	// Parse errors and field problems are sorted by file for stable output.
+	var templateProblems []string
+	rootType := reflect.TypeOf(ChartData{})
+	for relPath, tmplContent := range parseUnifiedTemplate(content, syntax) {
+		tmpl, err := template.New(relPath).Funcs(templateFuncs(ChartData{}, "")).Delims(syntax.LeftDelim, syntax.RightDelim).Parse(tmplContent)
+		if err != nil {
+			templateProblems = append(templateProblems, fmt.Sprintf("%s: %v", relPath, err))
+			continue
+		}
+		for _, problem := range checkTemplateFields(tmpl.Tree.Root, rootType, rootType) {
+			templateProblems = append(templateProblems, fmt.Sprintf("%s: %s", relPath, problem))
+		}
+	}
+	sort.Strings(templateProblems)
+	return append(problems, templateProblems...)
+}
+
+// checkTemplateFields walks a parsed template and reports field references
+// that do not exist on the type of dot at that point. A nil dot means the
+// type is unknown (interface{} or a function result) and is not checked.
+func checkTemplateFields(node parse.Node, dot, root reflect.Type) []string {
+	var problems []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			problems = append(problems, checkTemplateFields(child, dot, root)...)
+		}
+	case *parse.ActionNode:
+		_, problems = checkPipe(n.Pipe, dot, root)
+	case *parse.IfNode:
+		_, problems = checkPipe(n.Pipe, dot, root)
+		problems = append(problems, checkTemplateFields(n.List, dot, root)...)
+		problems = append(problems, checkTemplateFields(n.ElseList, dot, root)...)
+	case *parse.WithNode:
+		pipeType, pipeProblems := checkPipe(n.Pipe, dot, root)
+		problems = append(pipeProblems, checkTemplateFields(n.List, pipeType, root)...)
+		problems = append(problems, checkTemplateFields(n.ElseList, dot, root)...)
+	case *parse.RangeNode:
+		pipeType, pipeProblems := checkPipe(n.Pipe, dot, root)
+		var elem reflect.Type
+		if pipeType != nil {
+			switch pipeType.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				elem = pipeType.Elem()
+			}
+		}
+		problems = append(pipeProblems, checkTemplateFields(n.List, elem, root)...)
+		problems = append(problems, checkTemplateFields(n.ElseList, dot, root)...)
+	}
+	return problems
+}
+
+// checkPipe checks every field reference in a pipeline and returns the type
+// the pipeline evaluates to, when it can be determined statically.
+func checkPipe(pipe *parse.PipeNode, dot, root reflect.Type) (reflect.Type, []string) {
+	if pipe == nil {
+		return nil, nil
+	}
+	var problems []string
+	var result reflect.Type
+	for _, cmd := range pipe.Cmds {
+		result = nil
+		for i, arg := range cmd.Args {
+			var argType reflect.Type
+			var err error
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				argType, err = resolveFields(dot, a.Ident)
+			case *parse.VariableNode:
+				if a.Ident[0] == "$" {
+					argType, err = resolveFields(root, a.Ident[1:])
+				}
+			case *parse.DotNode:
+				argType = dot
+			case *parse.PipeNode:
+				var nested []string
+				argType, nested = checkPipe(a, dot, root)
+				problems = append(problems, nested...)
+			}
+			if err != nil {
+				problems = append(problems, err.Error())
+			}
+			// Only a bare field/variable command yields a known type.
+			if i == 0 && len(cmd.Args) == 1 {
+				result = argType
+			}
+		}
+	}
+	return result, problems
+}
+
+// resolveFields follows a chain of field or method names from t.
+func resolveFields(t reflect.Type, idents []string) (reflect.Type, error) {
+	for _, ident := range idents {
+		if t == nil || t.Kind() == reflect.Interface {
+			return nil, nil
+		}
+		if method, ok := t.MethodByName(ident); ok {
+			t = method.Type.Out(0)
+			continue
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			field, ok := t.FieldByName(ident)
+			if !ok {
+				return nil, fmt.Errorf("unknown field %s in %s", ident, t.Name())
+			}
+			t = field.Type
+		default:
+			return nil, fmt.Errorf("cannot access field %s of %s", ident, t)
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,67 @@
+package helmgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckEmbeddedTemplate(t *testing.T) {
+	if err := CheckEmbeddedTemplate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateUnifiedTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string // substring of a reported problem, "" for none
+	}{
+		{
+			name:     "valid",
+			template: "--- templates/a.yaml ---\nreplicas: <<.ReplicaCount>>\n",
+		},
+		{
+			name:     "missing dot",
+			template: "--- templates/a.yaml ---\nreplicas: <<ReplicaCount>>\n",
+			want:     `function "ReplicaCount" not defined`,
+		},
+		{
+			name:     "unknown field",
+			template: "--- templates/a.yaml ---\nreplicas: <<.Replicas>>\n",
+			want:     "unknown field Replicas in ChartData",
+		},
+		{
+			name:     "duplicate marker",
+			template: "--- templates/a.yaml ---\nkind: A\n--- templates/a.yaml ---\nkind: B\n",
+			want:     "duplicate marker for 'templates/a.yaml'",
+		},
+		{
+			name:     "marker without suffix",
+			template: "--- templates/a.yaml ---\nkind: A\n--- templates/b.yaml\nkind: B\n",
+			want:     "is missing its opening or closing",
+		},
+		{
+			name:     "marker without path",
+			template: "--- templates/a.yaml ---\nkind: A\n---  ---\nkind: B\n",
+			want:     "marker has no file path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateUnifiedTemplate(tt.template, embeddedSyntax)
+			if tt.want == "" {
+				if len(problems) > 0 {
+					t.Fatalf("unexpected problems: %q", problems)
+				}
+				return
+			}
+			for _, problem := range problems {
+				if strings.Contains(problem, tt.want) {
+					return
+				}
+			}
+			t.Errorf("problems %q, want one containing %q", problems, tt.want)
+		})
+	}
+}
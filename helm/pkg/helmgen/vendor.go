@@ -0,0 +1,135 @@
+package helmgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// repoIndex is the part of a chart repository's index.yaml used for vendoring.
+type repoIndex struct {
+	Entries map[string][]struct {
+		Version string   `yaml:"version"`
+		URLs    []string `yaml:"urls"`
+	} `yaml:"entries"`
+}
+
+// vendorSubcharts downloads every subchart from its repository, unpacks it
+// under charts/<alias or name>/ and points its repository at that directory,
+// so the chart installs without network access. It returns the unpacked files.
+func vendorSubcharts(config *ChartData, client *http.Client, logger Logger) (map[string][]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	files := make(map[string][]byte)
+	for i := range config.Subcharts {
+		sub := &config.Subcharts[i]
+		if strings.HasPrefix(sub.Repository, "file://") {
+			continue
+		}
+		if !strings.HasPrefix(sub.Repository, "http://") && !strings.HasPrefix(sub.Repository, "https://") {
+			return nil, fmt.Errorf("subchart '%s': cannot vendor from repository '%s'; only http(s) repositories are supported", sub.Name, sub.Repository)
+		}
+		archiveURL, err := chartArchiveURL(client, *sub)
+		if err != nil {
+			return nil, fmt.Errorf("subchart '%s': %v", sub.Name, err)
+		}
+		archive, err := httpGet(client, archiveURL)
+		if err != nil {
+			return nil, fmt.Errorf("subchart '%s': downloading %s: %v", sub.Name, archiveURL, err)
+		}
+		// Key is unique per subchart, so the same chart under two aliases
+		// gets two directories.
+		dir := "charts/" + sub.Key()
+		if err := unpackChart(archive, dir, files); err != nil {
+			return nil, fmt.Errorf("subchart '%s': unpacking %s: %v", sub.Name, archiveURL, err)
+		}
+		logger.Info("vendored subchart", "subchart", sub.Name, "version", sub.Version, "url", archiveURL)
+		sub.Repository = "file://" + dir
+	}
+	return files, nil
+}
+
+// chartArchiveURL looks up the archive of sub's exact version in its
+// repository index.
+func chartArchiveURL(client *http.Client, sub Subchart) (string, error) {
+	base, err := url.Parse(strings.TrimSuffix(sub.Repository, "/") + "/")
+	if err != nil {
+		return "", err
+	}
+	indexURL := base.ResolveReference(&url.URL{Path: "index.yaml"}).String()
+	content, err := httpGet(client, indexURL)
+	if err != nil {
+		return "", fmt.Errorf("fetching %s: %v", indexURL, err)
+	}
+	var index repoIndex
+	if err := yaml.Unmarshal(content, &index); err != nil {
+		return "", fmt.Errorf("parsing %s: %v", indexURL, err)
+	}
+	for _, entry := range index.Entries[sub.Name] {
+		if entry.Version != sub.Version || len(entry.URLs) == 0 {
+			continue
+		}
+		ref, err := url.Parse(entry.URLs[0])
+		if err != nil {
+			return "", err
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+	return "", fmt.Errorf("version '%s' not found in %s (vendoring needs an exact version)", sub.Version, indexURL)
+}
+
+// httpGet returns the body of a successful GET request.
+func httpGet(client *http.Client, rawURL string) ([]byte, error) {
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// unpackChart extracts a packaged chart into files under dir, dropping the
+// archive's top-level <name>/ directory.
+func unpackChart(archive []byte, dir string, files map[string][]byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		slash := strings.IndexByte(name, '/')
+		if slash < 0 || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return fmt.Errorf("unexpected archive entry '%s'", header.Name)
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		files[dir+"/"+name[slash+1:]] = content
+	}
+}
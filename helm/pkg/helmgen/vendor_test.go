@@ -0,0 +1,65 @@
+package helmgen
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testChartArchive packages a chart with only a Chart.yaml.
+func testChartArchive(t *testing.T, name, version string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	content := []byte("apiVersion: v2\nname: " + name + "\nversion: " + version + "\n")
+	if err := tw.WriteHeader(&tar.Header{Name: name + "/Chart.yaml", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVendorSubchartsKeepsAliasesApart(t *testing.T) {
+	archive := testChartArchive(t, "redis", "1.0.0")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.yaml":
+			w.Write([]byte("entries:\n  redis:\n    - version: 1.0.0\n      urls: [redis-1.0.0.tgz]\n"))
+		case "/redis-1.0.0.tgz":
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := loadTestConfig(t, `name: web
+subcharts:
+  - {name: redis, version: 1.0.0, repository: `+server.URL+`, alias: cache}
+  - {name: redis, version: 1.0.0, repository: `+server.URL+`, alias: queue}
+`)
+	files, err := vendorSubcharts(&config, server.Client(), nopLogger{})
+	if err != nil {
+		t.Fatalf("vendorSubcharts: %v", err)
+	}
+	for i, key := range []string{"cache", "queue"} {
+		if _, ok := files["charts/"+key+"/Chart.yaml"]; !ok {
+			t.Errorf("charts/%s/Chart.yaml was not vendored", key)
+		}
+		if want := "file://charts/" + key; config.Subcharts[i].Repository != want {
+			t.Errorf("subchart %s repository = %s, want %s", key, config.Subcharts[i].Repository, want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Argo CD application of a service in an environment, when "argocd" sets
+// no application pattern
+const defaultArgoCDApplication = "{service}-{environment}"
+
+// What Argo CD runs of a service in one environment
+type LiveVersion struct {
+	Environment string
+	Application string
+	Revision    string // git commit, or the chart version of Helm chart sources
+	Chart       string // Helm chart name, "" for git sources
+	Sync        string // e.g. "Synced" or "OutOfSync"
+	Health      string // e.g. "Healthy", "Progressing" or "Degraded"
+	DeployedAt  string // "" when Argo CD never synced the application
+	URL         string // application page in Argo CD
+}
+
+// Check the Argo CD settings before any report is generated
+func (c ArgoCDConfig) validate() error {
+	if c.BaseURL == "" {
+		return errors.New("argocd.base_url is required")
+	}
+	if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("argocd.base_url %q is not an absolute URL", c.BaseURL)
+	}
+	if c.Application != "" && !strings.Contains(c.Application, "{environment}") {
+		return fmt.Errorf("argocd.application %q does not contain {environment}", c.Application)
+	}
+	if c.token() == "" {
+		return errors.New("argocd: no token found: set ARGOCD_AUTH_TOKEN or argocd.token")
+	}
+	return nil
+}
+
+// API token from the ARGOCD_AUTH_TOKEN environment variable (as the argocd
+// CLI reads it), else the config
+func (c ArgoCDConfig) token() string {
+	if token := os.Getenv("ARGOCD_AUTH_TOKEN"); token != "" {
+		return token
+	}
+	return c.Token
+}
+
+// Name of the Argo CD application of a service in an environment: its
+// "argocd_applications" entry, else the application pattern filled in
+func (c ArgoCDConfig) application(service Service, environment string) string {
+	if name, ok := service.ArgoCDApplications[environment]; ok {
+		return name
+	}
+	pattern := c.Application
+	if pattern == "" {
+		pattern = defaultArgoCDApplication
+	}
+	return strings.NewReplacer(
+		"{service}", strings.ReplaceAll(strings.ToLower(service.Service), " ", "-"),
+		"{repo}", path.Base(service.Repo),
+		"{environment}", environment,
+	).Replace(pattern)
+}
+
+// Argo CD API, for the revisions the services run
+type ArgoCDClient struct {
+	client *http.Client
+	config ArgoCDConfig
+}
+
+// Argo CD client with the token of config; a nil client gets the default
+// timeout
+func NewArgoCDClient(client *http.Client, config ArgoCDConfig) *ArgoCDClient {
+	return &ArgoCDClient{client: defaultClient(client), config: config}
+}
+
+// Look up what a service runs in each environment: the configured
+// environments in their order, then those only its "argocd_applications"
+// name. Environments without an application are left out.
+func (api ArgoCDClient) liveVersions(ctx context.Context, service Service, environments []string) ([]LiveVersion, error) {
+	names := append([]string{}, environments...)
+	var extra []string
+	for environment := range service.ArgoCDApplications {
+		if !contains(names, environment) {
+			extra = append(extra, environment)
+		}
+	}
+	sort.Strings(extra)
+	names = append(names, extra...)
+	var versions []LiveVersion
+	for _, environment := range names {
+		version, err := api.liveVersion(ctx, api.config.application(service, environment))
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", environment, err)
+		}
+		if version != nil {
+			version.Environment = environment
+			versions = append(versions, *version)
+		}
+	}
+	return versions, nil
+}
+
+// Fetch the synced revision of an application, or nil when Argo CD has no
+// application of that name
+func (api ArgoCDClient) liveVersion(ctx context.Context, application string) (*LiveVersion, error) {
+	baseURL := strings.TrimSuffix(api.config.BaseURL, "/")
+	resp, err := apiGet(ctx, api.client, baseURL+"/api/v1/applications/"+url.PathEscape(application), "Authorization", "Bearer "+api.config.token())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		settingsOf(ctx).logger.Debug("no Argo CD application", "application", application)
+		return nil, nil
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("Argo CD rejected the token (%s); check ARGOCD_AUTH_TOKEN", resp.Status)
+	case http.StatusForbidden:
+		// Argo CD answers 403 rather than 404 for applications the
+		// token may not see, so both read as "no application".
+		settingsOf(ctx).logger.Debug("Argo CD application not accessible", "application", application, "status", resp.Status)
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("Argo CD returned %s for application %s", resp.Status, application)
+	}
+	var app struct {
+		Spec struct {
+			Source  argoCDSource   `json:"source"`
+			Sources []argoCDSource `json:"sources"`
+		} `json:"spec"`
+		Status struct {
+			Sync struct {
+				Status    string   `json:"status"`
+				Revision  string   `json:"revision"`
+				Revisions []string `json:"revisions"`
+			} `json:"sync"`
+			Health struct {
+				Status string `json:"status"`
+			} `json:"health"`
+			History []struct {
+				DeployedAt string `json:"deployedAt"`
+			} `json:"history"`
+		} `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&app); err != nil {
+		return nil, fmt.Errorf("decoding Argo CD application %s: %v", application, err)
+	}
+	version := &LiveVersion{
+		Application: application,
+		Revision:    app.Status.Sync.Revision,
+		Chart:       app.Spec.Source.Chart,
+		Sync:        app.Status.Sync.Status,
+		Health:      app.Status.Health.Status,
+		URL:         baseURL + "/applications/" + url.PathEscape(application),
+	}
+	// Applications with several sources report a revision per source;
+	// the first source is the service's own.
+	if len(app.Spec.Sources) > 0 && len(app.Status.Sync.Revisions) > 0 {
+		version.Revision, version.Chart = app.Status.Sync.Revisions[0], app.Spec.Sources[0].Chart
+	}
+	if history := app.Status.History; len(history) > 0 {
+		version.DeployedAt = history[len(history)-1].DeployedAt
+	}
+	return version, nil
+}
+
+// Source of an Argo CD application: a git repository or a Helm chart
+type argoCDSource struct {
+	Chart string `json:"chart"`
+}
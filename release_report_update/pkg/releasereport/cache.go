@@ -0,0 +1,162 @@
+package releasereport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache entries unused for this long are removed, so the cache only holds
+// what recent reports asked for
+const cacheMaxAge = 30 * 24 * time.Hour
+
+// Cache directories already pruned by this process; -serve makes a context
+// for every request and should not scan the directory each time
+var prunedCacheDirs sync.Map
+
+// On-disk cache of API responses, revalidated with ETag or Last-Modified so
+// unchanged data costs neither rate limit nor transfer time
+type diskCache struct {
+	dir string
+}
+
+// Cached API response
+type cacheEntry struct {
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	Link         string `json:"link"` // pagination
+	Body         []byte `json:"body"`
+}
+
+// Default cache directory, below the user's cache directory
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "release_report")
+}
+
+// Cache file of a URL; the credentials, or the identity they stand for, are
+// part of the key so users sharing a cache never see each other's private
+// data.
+func (c *diskCache) path(url, identity string) string {
+	sum := sha256.Sum256([]byte(url + "\x00" + identity))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Cached response of a URL, or nil. The entry's modification time is
+// bumped so prune keeps the entries in use.
+func (c *diskCache) load(url, identity string) *cacheEntry {
+	path := c.path(url, identity)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return nil
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return &entry
+}
+
+// Remove the entries, and the leftovers of interrupted writes, not used
+// since cacheMaxAge before now. Each directory is pruned once per process.
+func (c *diskCache) prune(now time.Time) error {
+	if _, done := prunedCacheDirs.LoadOrStore(c.dir, true); done {
+		return nil
+	}
+	files, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !(strings.HasSuffix(name, ".json") || strings.HasPrefix(name, "entry-")) {
+			continue
+		}
+		if now.Sub(file.ModTime()) > cacheMaxAge {
+			if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Cache a successful response that carries a validator. The body is read and
+// put back so the caller can still decode it.
+func (c *diskCache) store(url, identity string, resp *http.Response) error {
+	entry := cacheEntry{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"), Link: resp.Header.Get("Link")}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	entry.Body = body
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	// Write and rename so an interrupted run never leaves a torn entry.
+	tmp, err := ioutil.TempFile(c.dir, "entry-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(url, identity))
+}
+
+// Set the conditional request headers of a cached response
+func (e *cacheEntry) revalidate(req *http.Request) {
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// Turn a 304 Not Modified response into the cached 200 response
+func (e *cacheEntry) response(notModified *http.Response) *http.Response {
+	notModified.Body.Close()
+	resp := *notModified
+	resp.Status = "200 OK"
+	resp.StatusCode = http.StatusOK
+	resp.Header = notModified.Header.Clone()
+	resp.Header.Del("Link")
+	if e.Link != "" {
+		resp.Header.Set("Link", e.Link)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(e.Body))
+	resp.ContentLength = int64(len(e.Body))
+	return &resp
+}
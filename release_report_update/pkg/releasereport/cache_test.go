@@ -0,0 +1,53 @@
+package releasereport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskCachePrune(t *testing.T) {
+	cache := &diskCache{dir: t.TempDir()}
+	now := time.Now()
+	files := map[string]struct {
+		age  time.Duration
+		kept bool
+	}{
+		"stale.json":  {cacheMaxAge + time.Hour, false},
+		"entry-12345": {cacheMaxAge + time.Hour, false}, // interrupted write
+		"recent.json": {cacheMaxAge - time.Hour, true},
+		"used.json":   {cacheMaxAge + time.Hour, true}, // loaded below
+		"notes.txt":   {cacheMaxAge + time.Hour, true}, // not the cache's
+	}
+	for name, file := range files {
+		path := filepath.Join(cache.dir, name)
+		if err := ioutil.WriteFile(path, []byte(`{"etag":"\"v1\""}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-file.age), now.Add(-file.age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// load marks an entry as used.
+	used := cache.path("https://api.github.com/repos/acme/web", "token x")
+	if err := os.Rename(filepath.Join(cache.dir, "used.json"), used); err != nil {
+		t.Fatal(err)
+	}
+	if cache.load("https://api.github.com/repos/acme/web", "token x") == nil {
+		t.Fatal("load found no entry")
+	}
+	files[filepath.Base(used)] = files["used.json"]
+	delete(files, "used.json")
+
+	if err := cache.prune(now); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	for name, file := range files {
+		_, err := os.Stat(filepath.Join(cache.dir, name))
+		if kept := err == nil; kept != file.kept {
+			t.Errorf("%s kept = %v, want %v", name, kept, file.kept)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package releasereport
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Header of a new Keep a Changelog file
+const changelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// Keep a Changelog sections, in display order, with the change types they
+// take. Docs and chores are not notable changes and are left out.
+var changelogSections = []struct {
+	Title string
+	Types []string
+}{
+	{"Added", []string{"feat"}},
+	{"Changed", []string{"refactor", "other"}},
+	{"Fixed", []string{"fix"}},
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// File name friendly form of a service name, e.g. "payments-api"
+func slug(name string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// Keep a Changelog section of one release of a service, or "" when it has no
+// notable changes. Entries are shortened to subjectLimit characters.
+func changelogSection(report ServiceReport, version, date string, subjectLimit int) string {
+	changes := changelogChanges(report, subjectLimit)
+	if changes == "" {
+		return ""
+	}
+	return fmt.Sprintf("## [%s] - %s\n%s", version, date, changes)
+}
+
+// Notable changes of a service as Keep a Changelog "###" sections, or ""
+// when there are none
+func changelogChanges(report ServiceReport, subjectLimit int) string {
+	var b strings.Builder
+	for _, section := range changelogSections {
+		var entries []string
+		for _, group := range report.Groups {
+			for _, t := range section.Types {
+				if group.Type != t {
+					continue
+				}
+				for _, c := range group.Commits {
+					entry := "- " + markdownSummary(c.Message, subjectLimit)
+					if c.URL != "" && len(c.SHA) >= 7 {
+						entry += fmt.Sprintf(" ([%s](%s))", c.SHA[:7], c.URL)
+					}
+					entries = append(entries, entry)
+				}
+			}
+		}
+		if len(entries) > 0 {
+			fmt.Fprintf(&b, "\n### %s\n\n%s\n", section.Title, strings.Join(entries, "\n"))
+		}
+	}
+	return b.String()
+}
+
+// Add the release section of each service to dir/<service>/CHANGELOG.md,
+// newest first below the file header. A release already in the file is not
+// added again.
+func UpdateChangelogs(dir string, reports []ServiceReport, version, date string, opts Options) error {
+	logger := opts.logger()
+	for _, report := range reports {
+		section := changelogSection(report, version, date, opts.subjectLimit())
+		if section == "" {
+			logger.Info("no notable changes; changelog not updated", "service", report.Service)
+			continue
+		}
+		path := filepath.Join(dir, slug(report.Service), "CHANGELOG.md")
+		existing, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		content := string(existing)
+		if content == "" {
+			content = changelogHeader
+		}
+		if strings.Contains(content, fmt.Sprintf("\n## [%s]", version)) {
+			logger.Info("release already in the changelog; not updated", "file", path, "version", version)
+			continue
+		}
+
+		// Insert above the latest release, or at the end of the header.
+		if i := strings.Index(content, "\n## "); i >= 0 {
+			content = content[:i+1] + section + "\n" + content[i+1:]
+		} else {
+			content = strings.TrimRight(content, "\n") + "\n\n" + section
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+		logger.Info("changelog updated", "file", path)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package releasereport
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Size of the commits per day chart, in pixels
+const (
+	chartWidth   = 640
+	chartHeight  = 200
+	chartLeft    = 30 // room for the count labels
+	chartRight   = 10
+	chartTop     = 10
+	chartBottom  = 20 // room for the date labels
+	chartXLabels = 8  // at most
+)
+
+// Line colors after the theme's primary and accent colors
+var chartColors = []string{"#4caf50", "#9c27b0", "#e91e63", "#00bcd4", "#795548", "#607d8b"}
+
+// Inline SVG line chart of the commits per day of each service, laid out
+// here so the template only draws it
+type commitChart struct {
+	Width, Height int
+	Left, Right   int // x of the plot edges
+	Top, Bottom   int // y of the plot edges
+	XLabels       []chartLabel
+	YLabels       []chartLabel
+	Lines         []chartLine
+}
+
+// Axis label at a position
+type chartLabel struct {
+	X, Y int
+	Text string
+}
+
+// Commits of one service as an SVG polyline
+type chartLine struct {
+	Service string
+	Color   string
+	Points  string // SVG points attribute: "x,y x,y ..."
+	Total   int
+}
+
+// Lay out the chart of the commits per day between startDate and endDate,
+// one line per service in the given colors (reused when there are more
+// services) and days labeled in locale. Services reporting a ref range are
+// left out; returns nil when there is nothing to draw.
+func buildCommitChart(reports []ServiceReport, startDate, endDate string, colors []string, locale *Locale) *commitChart {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil
+	}
+	// A single day has no trend to show.
+	days := int(end.Sub(start).Hours()/24) + 1
+	if days < 2 {
+		return nil
+	}
+
+	var counts [][]int
+	var services []ServiceReport
+	highest := 0
+	for _, report := range reports {
+		if report.Range != "" {
+			continue
+		}
+		perDay := make([]int, days)
+		for _, c := range report.Commits {
+			day, err := time.Parse("2006-01-02", dateOf(c.Date, locale.location))
+			if err != nil {
+				continue
+			}
+			i := int(day.Sub(start).Hours() / 24)
+			if i < 0 || i >= days {
+				continue
+			}
+			perDay[i]++
+			if perDay[i] > highest {
+				highest = perDay[i]
+			}
+		}
+		counts = append(counts, perDay)
+		services = append(services, report)
+	}
+	if highest == 0 {
+		return nil
+	}
+
+	chart := &commitChart{Width: chartWidth, Height: chartHeight, Left: chartLeft, Right: chartWidth - chartRight, Top: chartTop, Bottom: chartHeight - chartBottom}
+	x := func(i int) int {
+		return chart.Left + i*(chart.Right-chart.Left)/(days-1)
+	}
+	y := func(count int) int {
+		return chart.Bottom - count*(chart.Bottom-chart.Top)/highest
+	}
+
+	step := (days + chartXLabels - 1) / chartXLabels
+	for i := 0; i < days; i += step {
+		chart.XLabels = append(chart.XLabels, chartLabel{X: x(i), Y: chartHeight - 5, Text: locale.day(start.AddDate(0, 0, i))})
+	}
+	for _, count := range []int{0, highest / 2, highest} {
+		if count == 0 && len(chart.YLabels) > 0 {
+			continue // highest is 1
+		}
+		chart.YLabels = append(chart.YLabels, chartLabel{X: chart.Left - 5, Y: y(count) + 4, Text: fmt.Sprint(count)})
+	}
+
+	for s, perDay := range counts {
+		points := make([]string, days)
+		total := 0
+		for i, count := range perDay {
+			points[i] = fmt.Sprintf("%d,%d", x(i), y(count))
+			total += count
+		}
+		chart.Lines = append(chart.Lines, chartLine{Service: services[s].Service, Color: colors[s%len(colors)], Points: strings.Join(points, " "), Total: total})
+	}
+	return chart
+}
@@ -0,0 +1,166 @@
+package releasereport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// HTTP client settings for API calls
+const (
+	DefaultRequestTimeout = 30 * time.Second // per request, including reading the body
+	maxTransientRetries   = 3                // retries after 5xx responses or network errors
+)
+
+// Create the HTTP client shared by all API calls, going through the proxy
+// and trusting the certificate authorities of network when set
+func NewHTTPClient(timeout time.Duration, network *NetworkConfig) (*http.Client, error) {
+	transport, err := newTransport(network)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// The given client, or one with the default timeout when nil
+func defaultClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: DefaultRequestTimeout}
+	}
+	return client
+}
+
+// Send a GET request to a provider API, authenticated with the given header.
+// Cached responses are revalidated with If-None-Match or If-Modified-Since
+// and served from the cache when the API answers 304 Not Modified.
+// Rate limits are waited out (until the quota reset when it is exhausted, for
+// Retry-After when given, with exponential backoff otherwise), and 5xx
+// responses and network errors are retried with exponential backoff. Waiting
+// stops when ctx is cancelled.
+func apiGet(ctx context.Context, client *http.Client, url, authHeader, authValue string) (*http.Response, error) {
+	return apiGetAs(ctx, client, url, authHeader, authValue, authValue)
+}
+
+// apiGet with the response cache keyed on identity rather than authValue,
+// for credentials that change from run to run but grant the same access
+func apiGetAs(ctx context.Context, client *http.Client, url, authHeader, authValue, identity string) (*http.Response, error) {
+	backoff := time.Second
+	rateLimited, transient := 0, 0
+	settings := settingsOf(ctx)
+	var cached *cacheEntry
+	if settings.cache != nil {
+		cached = settings.cache.load(url, identity)
+	}
+	for {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(authHeader, authValue)
+		if cached != nil {
+			cached.revalidate(req)
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			settings.logger.Debug("API request failed", "method", req.Method, "url", req.URL.String(), "duration", time.Since(start).Round(time.Millisecond), "error", err)
+			if ctx.Err() != nil || transient == maxTransientRetries {
+				return nil, err
+			}
+			transient++
+			settings.logger.Debug("retrying API request", "url", req.URL.String(), "wait", backoff)
+			if err := sleepContext(ctx, backoff); err != nil {
+				return nil, err
+			}
+			backoff *= 2
+			continue
+		}
+		logRequest(resp, time.Since(start))
+
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			settings.logger.Debug("using cached API response", "url", req.URL.String())
+			return cached.response(resp), nil
+		}
+		if resp.StatusCode == http.StatusOK && settings.cache != nil {
+			if err := settings.cache.store(url, identity, resp); err != nil {
+				settings.logger.Debug("caching API response failed", "url", req.URL.String(), "error", err)
+			}
+			return resp, nil
+		}
+
+		switch {
+		case resp.StatusCode >= 500 && transient < maxTransientRetries:
+			resp.Body.Close()
+			transient++
+			settings.logger.Debug("retrying API request", "url", req.URL.String(), "status", resp.StatusCode, "wait", backoff)
+			if err := sleepContext(ctx, backoff); err != nil {
+				return nil, err
+			}
+			backoff *= 2
+			continue
+		case resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests:
+			return resp, nil
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		wait, limited := rateLimitWait(resp, body, backoff)
+		if !limited {
+			// A 403 for another reason, e.g. missing repository access.
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			return resp, nil
+		}
+		if rateLimited == maxRateLimitRetries || wait > maxRateLimitWait {
+			return nil, fmt.Errorf("%w for %s (retry after %s)", errRateLimited, url, wait.Round(time.Second))
+		}
+		rateLimited++
+		settings.logger.Warn("rate limited; retrying", "host", req.URL.Host, "wait", wait.Round(time.Second))
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// Sleep for d, returning early with the context's error when it is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Send a JSON request that changes data (POST, PATCH) to a provider API,
+// authenticated with the given header. Unlike GET requests these are not
+// retried, so a timeout never creates a resource twice.
+func apiSend(ctx context.Context, client *http.Client, method, url, authHeader, authValue string, payload interface{}) (*http.Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(authHeader, authValue)
+	req.Header.Set("Content-Type", "application/json")
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		settingsOf(ctx).logger.Debug("API request failed", "method", method, "url", url, "duration", time.Since(start).Round(time.Millisecond), "error", err)
+		return nil, err
+	}
+	logRequest(resp, time.Since(start))
+	return resp, nil
+}
@@ -0,0 +1,36 @@
+package releasereport
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Split a ref range such as "v1.2.0..v1.3.0" (or with three dots) into its
+// base and head refs
+func ParseRefRange(refRange string) (base, head string, err error) {
+	sep := "..."
+	if !strings.Contains(refRange, sep) {
+		sep = ".."
+	}
+	parts := strings.SplitN(refRange, sep, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid ref range %q (want BASE..HEAD, e.g. v1.2.0..v1.3.0)", refRange)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Ref range a service is compared over: its own "compare" setting, else the
+// -compare flag, else "" to report by date
+func (s Service) RefRange(global string) string {
+	if s.Compare != "" {
+		return s.Compare
+	}
+	return global
+}
+
+// Reverse commits in place, e.g. to list compare results newest first
+func reverseCommits(commits []Commit) {
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+}
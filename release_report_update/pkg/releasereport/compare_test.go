@@ -0,0 +1,49 @@
+package releasereport
+
+import (
+	"testing"
+)
+
+func TestParseRefRange(t *testing.T) {
+	tests := []struct {
+		refRange   string
+		base, head string
+		valid      bool
+	}{
+		{"v1.2.0..v1.3.0", "v1.2.0", "v1.3.0", true},
+		{"v1.2.0...v1.3.0", "v1.2.0", "v1.3.0", true},
+		{"main..release/2.0", "main", "release/2.0", true},
+		{"abc1234..HEAD", "abc1234", "HEAD", true},
+		{"v1.2.0", "", "", false},
+		{"..v1.3.0", "", "", false},
+		{"v1.2.0..", "", "", false},
+		{"v1.2.0...", "", "", false},
+		{"", "", "", false},
+	}
+	for _, test := range tests {
+		base, head, err := ParseRefRange(test.refRange)
+		if (err == nil) != test.valid {
+			t.Errorf("ParseRefRange(%q) error = %v, want valid %v", test.refRange, err, test.valid)
+			continue
+		}
+		if base != test.base || head != test.head {
+			t.Errorf("ParseRefRange(%q) = %q, %q, want %q, %q", test.refRange, base, head, test.base, test.head)
+		}
+	}
+}
+
+func TestServiceRefRange(t *testing.T) {
+	tests := []struct {
+		compare, global, want string
+	}{
+		{"", "", ""},
+		{"", "v1..v2", "v1..v2"},
+		{"v3..v4", "v1..v2", "v3..v4"},
+		{"v3..v4", "", "v3..v4"},
+	}
+	for _, test := range tests {
+		if got := (Service{Compare: test.compare}).RefRange(test.global); got != test.want {
+			t.Errorf("RefRange of %q with -compare %q = %q, want %q", test.compare, test.global, got, test.want)
+		}
+	}
+}
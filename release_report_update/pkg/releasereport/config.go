@@ -0,0 +1,423 @@
+package releasereport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Struct for service configuration
+type Service struct {
+	Service  string `json:"service"`
+	Repo     string `json:"repo"`     // owner/repo on GitHub, the project path on GitLab
+	Provider string `json:"provider"` // "github" (default) or "gitlab"
+	Branch   string `json:"branch"`   // branch to report, default "main"
+	Compare  string `json:"compare"`  // ref range such as "v1.2.0..v1.3.0" to report instead of dates
+	// Environments maps environments to the branch (or branch pattern, e.g.
+	// "release/*") or tag pattern (e.g. "tag:v*") they receive, instead of
+	// their deployments.
+	Environments map[string]string `json:"environments"`
+	// Paths limits a service in a monorepo to the commits changing files
+	// that match one of these patterns, e.g. "services/payments/**".
+	Paths []string `json:"paths"`
+	// HarnessPipelines lists the identifiers of the Harness pipelines that
+	// deploy the service (default: those stored in the .harness/piplines
+	// directory of its repository).
+	HarnessPipelines []string `json:"harness_pipelines"`
+	// HarnessService names the Harness service identifier of the service,
+	// to tell apart the services a shared pipeline deploys.
+	HarnessService string `json:"harness_service"`
+	// ArgoCDApplications maps environments to the Argo CD application of
+	// the service there, instead of the "argocd" application pattern.
+	ArgoCDApplications map[string]string `json:"argocd_applications"`
+	// Images lists the container image repositories of the service, e.g.
+	// "ghcr.io/viaxlabs/payments", for -kube (default: the images named
+	// like its repository).
+	Images []string `json:"images"`
+}
+
+// Struct for the report configuration
+type Config struct {
+	// API root, e.g. https://github.corp.example/api/v3 for GitHub Enterprise Server.
+	APIBaseURL string `json:"api_base_url"`
+	// GitLab API root, e.g. https://gitlab.corp.example/api/v4 for self-managed GitLab.
+	GitLabBaseURL string    `json:"gitlab_base_url"`
+	Services      []Service `json:"services"`
+	// Deployment environments, in promotion order, e.g. ["dev", "stage",
+	// "prod"]; the report then lists the changes deployed to each.
+	Environments []string `json:"environments"`
+	// Sections of pulls mode by pull request label (default: features,
+	// bug fixes, security and infrastructure)
+	PullSections []PullSection `json:"pull_sections"`
+	// Commits left out of the report, such as those of bots
+	CommitFilter
+	// Environment whose deployments the -dora metrics measure (default:
+	// the last of "environments", else "production")
+	DORAEnvironment string `json:"dora_environment"`
+	// SMTP settings to email the HTML report; no email is sent when unset.
+	SMTP *SMTPConfig `json:"smtp"`
+	// Jira instance to link ticket keys found in commit messages; none are
+	// linked when unset.
+	Jira *JiraConfig `json:"jira"`
+	// Branding of the HTML report
+	Theme *ThemeConfig `json:"theme"`
+	// Confluence page to publish the HTML report to; none when unset.
+	Confluence *ConfluenceConfig `json:"confluence"`
+	// GitHub App to authenticate as instead of with a token
+	GitHubApp *GitHubAppConfig `json:"github_app"`
+	// Proxy and TLS settings of the API requests
+	Network *NetworkConfig `json:"network"`
+	// Sprint cadence of -range this-sprint and last-sprint
+	Sprint *SprintConfig `json:"sprint"`
+	// Harness project whose pipeline executions the report lists per
+	// service; none when unset.
+	Harness *HarnessConfig `json:"harness"`
+	// Argo CD instance to show the revision each service runs per
+	// environment; none when unset.
+	ArgoCD *ArgoCDConfig `json:"argocd"`
+	// Kubernetes clusters -kube scans for the image tags running per
+	// environment (default: every namespace of the current kubeconfig
+	// context)
+	Kubernetes *KubernetesConfig `json:"kubernetes"`
+}
+
+// Struct for a pull request section of the report and the labels that put a
+// pull request in it
+type PullSection struct {
+	Title  string   `json:"title"`
+	Labels []string `json:"labels"` // matched ignoring case
+}
+
+// Struct for the commits left out of the report
+type CommitFilter struct {
+	// ExcludeBots leaves out commits of bot accounts such as dependabot and
+	// renovate.
+	ExcludeBots bool `json:"exclude_bots"`
+	// ExcludeAuthors leaves out commits whose author name or email matches
+	// one of these patterns, e.g. "ci-bot@example.com" or "jenkins*"; "*"
+	// is the only wildcard, so "dependabot[bot]" matches literally.
+	ExcludeAuthors []string `json:"exclude_authors"`
+	// SkipMerges leaves out merge commits.
+	SkipMerges bool `json:"skip_merges"`
+	// DedupeSquashMerges leaves out the original commits of pull requests
+	// whose squash (or rebase) merge commit is reported too.
+	DedupeSquashMerges bool `json:"dedupe_squash_merges"`
+}
+
+// Struct for the SMTP settings used to email the HTML report
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`     // 587 (default) or 25 with STARTTLS, 465 with implicit TLS
+	Username string   `json:"username"` // no authentication when empty
+	Password string   `json:"password"` // prefer the SMTP_PASSWORD environment variable
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Cc       []string `json:"cc"`
+	Subject  string   `json:"subject"` // default: "Release Report <start> – <end>"
+}
+
+// Struct for the Jira settings used to link ticket keys found in commit messages
+type JiraConfig struct {
+	BaseURL string `json:"base_url"` // e.g. https://viaxlabs.atlassian.net
+	// Regular expression of ticket keys; when it has a capturing group the
+	// first group is the key.
+	TicketPattern string `json:"ticket_pattern"`
+	// Username (the account email on Jira Cloud) for basic authentication
+	// with an API token; without it the token is sent as a personal access
+	// token (Jira Data Center). Only the ticket statuses of -trace need
+	// credentials.
+	Username string `json:"username"`
+	Token    string `json:"token"` // prefer the JIRA_TOKEN environment variable
+}
+
+// Struct for the branding of the HTML report
+type ThemeConfig struct {
+	LogoURL      string `json:"logo_url"`      // shown next to the title
+	PrimaryColor string `json:"primary_color"` // hex color, default #0073e6
+	AccentColor  string `json:"accent_color"`  // hex color, default #ff9800
+	// CSSFile is a stylesheet added to the report; elements carry classes
+	// such as "service", "commit" and "release" to target.
+	CSSFile string `json:"css_file"`
+	// DarkMode adds a dark stylesheet for readers whose browser or mail
+	// client prefers a dark color scheme.
+	DarkMode bool `json:"dark_mode"`
+}
+
+// Struct for the Confluence page the HTML report is published to
+type ConfluenceConfig struct {
+	BaseURL      string `json:"base_url"`       // e.g. https://viaxlabs.atlassian.net/wiki
+	Space        string `json:"space"`          // space key
+	ParentPageID string `json:"parent_page_id"` // new pages are created below it
+	// Title of the page, which is updated when it exists (default: "Release
+	// Report <period>", a new page per period)
+	Title string `json:"title"`
+	// Username (the account email on Confluence Cloud) for basic
+	// authentication with an API token; without it the token is sent as a
+	// personal access token (Confluence Data Center).
+	Username string `json:"username"`
+	Token    string `json:"token"` // prefer the CONFLUENCE_TOKEN environment variable
+}
+
+// Struct for authenticating to GitHub as a GitHub App installation instead of
+// with a personal access token
+type GitHubAppConfig struct {
+	AppID          int64 `json:"app_id"`
+	InstallationID int64 `json:"installation_id"` // installation of the app in the organization
+	// PEM private key of the app; prefer the GITHUB_APP_PRIVATE_KEY
+	// environment variable, which holds the key itself.
+	PrivateKeyFile string `json:"private_key_file"`
+}
+
+// Struct for reaching the APIs from a corporate network. Without it the
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables still apply.
+type NetworkConfig struct {
+	// Proxy for every API request, e.g. http://proxy.corp.example:3128,
+	// overriding HTTPS_PROXY and NO_PROXY
+	Proxy string `json:"proxy"`
+	// PEM bundle of certificate authorities trusted besides the system
+	// ones, such as the one an egress proxy re-signs TLS traffic with
+	CAFile string `json:"ca_file"`
+	// Minimum TLS version, "1.2" (default) or "1.3"
+	MinTLSVersion string `json:"min_tls_version"`
+	// Skip certificate verification; for debugging only
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+}
+
+// Struct for the sprint cadence the sprint presets of -range follow
+type SprintConfig struct {
+	Start string `json:"start"`       // first day (YYYY-MM-DD) of any sprint
+	Days  int    `json:"length_days"` // default: 14
+}
+
+// Struct for the Harness project whose pipeline executions show what each
+// service actually deployed
+type HarnessConfig struct {
+	BaseURL   string `json:"base_url"`   // default https://app.harness.io
+	AccountID string `json:"account_id"` // account identifier
+	Org       string `json:"org"`        // organization identifier
+	Project   string `json:"project"`    // project identifier
+	APIKey    string `json:"api_key"`    // prefer the HARNESS_API_KEY environment variable
+}
+
+// Struct for the Argo CD instance that shows what each service runs per
+// environment
+type ArgoCDConfig struct {
+	BaseURL string `json:"base_url"` // e.g. https://argocd.viaxlabs.example.com
+	// Application names the Argo CD application of a service in an
+	// environment: {service} is the service name in lower case with dashes
+	// for spaces, {repo} the repository name and {environment} the
+	// environment (default: "{service}-{environment}").
+	Application string `json:"application"`
+	Token       string `json:"token"` // prefer the ARGOCD_AUTH_TOKEN environment variable
+}
+
+// Struct for the Kubernetes clusters -kube scans for the image tags running
+// per environment. Without it every namespace of the current kubeconfig
+// context is scanned, each reported as an environment.
+type KubernetesConfig struct {
+	// Kubeconfig file (default: $KUBECONFIG, else ~/.kube/config)
+	Kubeconfig string `json:"kubeconfig"`
+	// Environments maps environments to the kubeconfig context and
+	// namespaces running them, e.g. {"prod": {"context": "prod-eks",
+	// "namespaces": ["payments"]}}.
+	Environments map[string]KubernetesTarget `json:"environments"`
+}
+
+// Struct for where an environment runs in Kubernetes
+type KubernetesTarget struct {
+	Context    string   `json:"context"`    // kubeconfig context (default: the current one)
+	Namespaces []string `json:"namespaces"` // default: all namespaces
+}
+
+// Load the report configuration from config.json, or from YAML with the
+// same keys (see decodeConfig)
+func LoadConfig(filename string) (Config, error) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	err = decodeConfig(filename, file, &config)
+	return config, err
+}
+
+// Configuration files looked for without -config, in order
+var defaultConfigFiles = []string{"config.json", "config.yaml", "config.yml"}
+
+// Repository names: owner/repo on GitHub; group/project, with any number of
+// subgroups, on GitLab
+var (
+	githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+	gitlabRepoPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)+$`)
+)
+
+// Check the whole configuration and list every problem found, so a broken
+// config.json is fixed in one go rather than producing a half-empty report.
+func (c Config) Validate() []string {
+	var problems []string
+	if len(c.Services) == 0 {
+		problems = append(problems, "no services configured")
+	}
+	names := make(map[string]int)
+	sources := make(map[string]int)
+	for i, service := range c.Services {
+		label := fmt.Sprintf("service #%d", i+1)
+		if service.Service == "" {
+			problems = append(problems, label+": \"service\" name is empty")
+		} else {
+			label = "service " + service.Service
+			if first, ok := names[service.Service]; ok {
+				problems = append(problems, fmt.Sprintf("%s: duplicate name (also service #%d)", label, first+1))
+			} else {
+				names[service.Service] = i
+			}
+		}
+
+		if err := checkProvider(service); err != nil {
+			problems = append(problems, err.Error())
+		}
+		switch {
+		case service.Repo == "":
+			problems = append(problems, label+": \"repo\" is empty")
+		case service.provider() == ProviderGitLab && !gitlabRepoPattern.MatchString(service.Repo):
+			problems = append(problems, fmt.Sprintf("%s: repo %q is not a group/project path", label, service.Repo))
+		case service.provider() != ProviderGitLab && !githubRepoPattern.MatchString(service.Repo):
+			problems = append(problems, fmt.Sprintf("%s: repo %q is not in owner/repo format", label, service.Repo))
+		}
+		// The same repository may be listed more than once for different
+		// branches, ranges or paths, but not twice for the same changes.
+		source := fmt.Sprintf("%s %s %s %s %q", service.provider(), service.Repo, service.branch(), service.Compare, service.Paths)
+		if first, ok := sources[source]; ok && service.Repo != "" {
+			problems = append(problems, fmt.Sprintf("%s: duplicates the repository, branch and paths of service #%d", label, first+1))
+		} else {
+			sources[source] = i
+		}
+
+		if err := checkEnvironmentMapping(service); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if err := checkPaths(service); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if service.Compare != "" {
+			if _, _, err := ParseRefRange(service.Compare); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", label, err))
+			}
+		}
+		if contains(service.Images, "") {
+			problems = append(problems, label+": empty image in \"images\"")
+		}
+		if contains(service.HarnessPipelines, "") {
+			problems = append(problems, label+": empty pipeline identifier in \"harness_pipelines\"")
+		}
+	}
+	if err := checkPullSections(c.PullSections); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if c.Jira != nil {
+		if _, err := c.Jira.compile(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.SMTP != nil {
+		if err := c.SMTP.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.Confluence != nil {
+		if err := c.Confluence.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.GitHubApp != nil {
+		if err := c.GitHubApp.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.Sprint != nil {
+		if err := c.Sprint.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.Network != nil {
+		if err := c.Network.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.Harness != nil {
+		if err := c.Harness.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.ArgoCD != nil {
+		if err := c.ArgoCD.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.Kubernetes != nil {
+		if err := c.Kubernetes.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if c.Theme != nil {
+		if err := c.Theme.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// First of defaultConfigFiles that exists, else config.json for the error
+func FindConfig() string {
+	for _, name := range defaultConfigFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return defaultConfigFiles[0]
+}
+
+// Decode a configuration file into config. Files named .yaml or .yml, or
+// other than .json not starting with "{", are YAML with the keys of the JSON
+// schema: the YAML is converted to JSON first, so both formats share the
+// json tags and their validation.
+func decodeConfig(filename string, data []byte, config interface{}) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".json" || (ext != ".yaml" && ext != ".yml" && bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))) {
+		return json.Unmarshal(data, config)
+	}
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	converted, err := json.Marshal(jsonValue(document))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(converted, config)
+}
+
+// YAML value with its maps keyed by strings, as JSON needs
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+	}
+	return value
+}
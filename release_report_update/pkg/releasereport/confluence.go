@@ -0,0 +1,137 @@
+package releasereport
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Check the Confluence settings before any report is generated
+func (c ConfluenceConfig) validate() error {
+	if c.BaseURL == "" {
+		return errors.New("confluence.base_url is required")
+	}
+	if c.Space == "" {
+		return errors.New("confluence.space is required")
+	}
+	if c.token() == "" {
+		return errors.New("confluence: no token found: set CONFLUENCE_TOKEN or confluence.token")
+	}
+	return nil
+}
+
+// API token from the CONFLUENCE_TOKEN environment variable, else the config
+func (c ConfluenceConfig) token() string {
+	if token := os.Getenv("CONFLUENCE_TOKEN"); token != "" {
+		return token
+	}
+	return c.Token
+}
+
+// Authorization header value of the Confluence REST API
+func (c ConfluenceConfig) authorization() string {
+	if c.Username != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.token()))
+	}
+	return "Bearer " + c.token()
+}
+
+// Parts of the HTML report Confluence storage format (XHTML) cannot take
+var (
+	htmlBody         = regexp.MustCompile(`(?s)<body[^>]*>(.*)</body>`)
+	htmlChartSection = regexp.MustCompile(`(?s)<div class="section chart".*?</div>|<svg.*?</svg>`)
+	htmlVoidElement  = regexp.MustCompile(`<(br|hr|img|meta)\b([^>]*?)\s*/?>`)
+)
+
+// Convert the HTML report to Confluence storage format: the page body only,
+// without the SVG chart and with void elements closed as XHTML requires
+func confluenceStorage(html []byte) string {
+	body := string(html)
+	if match := htmlBody.FindStringSubmatch(body); match != nil {
+		body = match[1]
+	}
+	body = htmlChartSection.ReplaceAllString(body, "")
+	return htmlVoidElement.ReplaceAllString(body, "<$1$2 />")
+}
+
+// Create the report page in Confluence, or update it when a page with the
+// same title exists in the space. Returns the page URL.
+func PublishToConfluence(ctx context.Context, client *http.Client, c ConfluenceConfig, title string, html []byte) (string, error) {
+	baseURL := strings.TrimSuffix(c.BaseURL, "/")
+	query := url.Values{"spaceKey": {c.Space}, "title": {title}, "expand": {"version"}}
+	resp, err := apiGet(ctx, client, baseURL+"/rest/api/content?"+query.Encode(), "Authorization", c.authorization())
+	if err != nil {
+		return "", err
+	}
+	var found struct {
+		Results []struct {
+			ID      string `json:"id"`
+			Version struct {
+				Number int `json:"number"`
+			} `json:"version"`
+		} `json:"results"`
+	}
+	err = confluenceResponse(resp, &found)
+	if err != nil {
+		return "", err
+	}
+
+	page := map[string]interface{}{
+		"type":  "page",
+		"title": title,
+		"space": map[string]string{"key": c.Space},
+		"body":  map[string]interface{}{"storage": map[string]string{"value": confluenceStorage(html), "representation": "storage"}},
+	}
+	method, target := "POST", baseURL+"/rest/api/content"
+	if len(found.Results) > 0 {
+		existing := found.Results[0]
+		method, target = "PUT", baseURL+"/rest/api/content/"+existing.ID
+		page["id"] = existing.ID
+		page["version"] = map[string]int{"number": existing.Version.Number + 1}
+	} else if c.ParentPageID != "" {
+		page["ancestors"] = []map[string]string{{"id": c.ParentPageID}}
+	}
+	resp, err = apiSend(ctx, client, method, target, "Authorization", c.authorization(), page)
+	if err != nil {
+		return "", err
+	}
+	var saved struct {
+		Links struct {
+			Base  string `json:"base"`
+			WebUI string `json:"webui"`
+		} `json:"_links"`
+	}
+	if err := confluenceResponse(resp, &saved); err != nil {
+		return "", err
+	}
+	if saved.Links.Base == "" {
+		saved.Links.Base = baseURL
+	}
+	return saved.Links.Base + saved.Links.WebUI, nil
+}
+
+// Decode a Confluence REST API response, turning error statuses into errors
+// with Confluence's message
+func confluenceResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	var failure struct {
+		Message string `json:"message"`
+	}
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+	if json.Unmarshal(body.Bytes(), &failure) == nil && failure.Message != "" {
+		return fmt.Errorf("Confluence returned %s: %s", resp.Status, failure.Message)
+	}
+	return fmt.Errorf("Confluence returned %s", resp.Status)
+}
@@ -0,0 +1,101 @@
+package releasereport
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Contributor statistics of the report period
+type contributorStats struct {
+	Authors         []authorStat  // by commits, most first
+	NewContributors []authorStat  // authors without earlier commits to a service
+	Services        []serviceStat // by commits, busiest first
+}
+
+// Commits of one author
+type authorStat struct {
+	Name     string
+	Commits  int
+	Services []string
+}
+
+// Activity of one service
+type serviceStat struct {
+	Service string
+	Commits int
+	Authors int
+}
+
+// Key identifying an author across commits: the email, else the name
+func authorKey(c Commit) string {
+	if c.AuthorEmail != "" {
+		return strings.ToLower(c.AuthorEmail)
+	}
+	return c.Author
+}
+
+// Find the authors of commits with no commit to repo before the start date
+func newContributors(ctx context.Context, provider Provider, repo string, commits []Commit, startDate string) ([]string, error) {
+	var authors []string
+	checked := make(map[string]bool)
+	for _, c := range commits {
+		key := authorKey(c)
+		if c.AuthorEmail == "" || checked[key] {
+			continue
+		}
+		checked[key] = true
+		before, err := provider.CommittedBefore(ctx, repo, c.AuthorEmail, startDate)
+		if err != nil {
+			return nil, err
+		}
+		if !before {
+			authors = append(authors, key)
+		}
+	}
+	return authors, nil
+}
+
+// Compute the contributor statistics of the reports
+func computeContributorStats(reports []ServiceReport) *contributorStats {
+	authors := make(map[string]*authorStat)
+	isNew := make(map[string]bool)
+	stats := &contributorStats{}
+	for _, report := range reports {
+		serviceAuthors := make(map[string]bool)
+		for _, c := range report.Commits {
+			key := authorKey(c)
+			author, ok := authors[key]
+			if !ok {
+				author = &authorStat{Name: c.Author}
+				authors[key] = author
+			}
+			author.Commits++
+			author.Services = appendUnique(author.Services, report.Service)
+			serviceAuthors[key] = true
+		}
+		for _, key := range report.NewContributors {
+			isNew[key] = true
+		}
+		stats.Services = append(stats.Services, serviceStat{Service: report.Service, Commits: len(report.Commits), Authors: len(serviceAuthors)})
+	}
+
+	for key, author := range authors {
+		stats.Authors = append(stats.Authors, *author)
+		if isNew[key] {
+			stats.NewContributors = append(stats.NewContributors, *author)
+		}
+	}
+	byCommits := func(list []authorStat) {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Commits != list[j].Commits {
+				return list[i].Commits > list[j].Commits
+			}
+			return list[i].Name < list[j].Name
+		})
+	}
+	byCommits(stats.Authors)
+	byCommits(stats.NewContributors)
+	sort.SliceStable(stats.Services, func(i, j int) bool { return stats.Services[i].Commits > stats.Services[j].Commits })
+	return stats
+}
@@ -0,0 +1,91 @@
+package releasereport
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Change type of a commit parsed from a Conventional Commits subject such as
+// "feat(api)!: add payments endpoint"
+var conventionalSubject = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?:\s*(.+)$`)
+
+// Change type groups of the report, in display order
+var changeTypes = []struct {
+	Type  string
+	Title string
+}{
+	{"feat", "✨ Features"},
+	{"fix", "🐛 Fixes"},
+	{"refactor", "♻️ Refactoring"},
+	{"docs", "📝 Documentation"},
+	{"chore", "🔧 Chores"},
+	{"other", "📦 Other"},
+}
+
+// Commits of one change type
+type ChangeGroup struct {
+	Type    string
+	Title   string
+	Commits []Commit
+}
+
+// Parse the change type of a commit message; subjects without a known
+// Conventional Commits type are "other"
+func changeType(message string) string {
+	subject := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	match := conventionalSubject.FindStringSubmatch(subject)
+	if match == nil {
+		return "other"
+	}
+	kind := strings.ToLower(match[1])
+	for _, t := range changeTypes {
+		if t.Type == kind {
+			return kind
+		}
+	}
+	return "other"
+}
+
+// Group commits by change type, keeping their order and omitting empty groups
+func groupByChangeType(commits []Commit) []ChangeGroup {
+	byType := make(map[string][]Commit)
+	for _, c := range commits {
+		kind := changeType(c.Message)
+		byType[kind] = append(byType[kind], c)
+	}
+	var groups []ChangeGroup
+	for _, t := range changeTypes {
+		if len(byType[t.Type]) > 0 {
+			groups = append(groups, ChangeGroup{Type: t.Type, Title: t.Title, Commits: byType[t.Type]})
+		}
+	}
+	return groups
+}
+
+// Release-note line of a commit: its subject without the change type, e.g.
+// "api: add payments endpoint" for "feat(api): add payments endpoint"
+func changeSummary(message string) string {
+	subject := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	match := conventionalSubject.FindStringSubmatch(subject)
+	if match == nil || changeType(subject) == "other" {
+		return subject
+	}
+	summary := match[4]
+	if scope := strings.Trim(match[2], "()"); scope != "" {
+		summary = scope + ": " + summary
+	}
+	if match[3] == "!" {
+		summary += " ⚠️ breaking change"
+	}
+	return summary
+}
+
+// Whether a commit is a breaking change: marked with "!" after its type or
+// scope, or with a BREAKING CHANGE footer
+func breakingChange(message string) bool {
+	subject := strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+	if match := conventionalSubject.FindStringSubmatch(subject); match != nil && match[3] == "!" {
+		return true
+	}
+	return strings.Contains(message, "\nBREAKING CHANGE:") || strings.Contains(message, "\nBREAKING-CHANGE:")
+}
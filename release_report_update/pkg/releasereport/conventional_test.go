@@ -0,0 +1,58 @@
+package releasereport
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConventionalCommits(t *testing.T) {
+	tests := []struct {
+		message string
+		kind    string
+		summary string
+	}{
+		{"feat: add search", "feat", "add search"},
+		{"feat(api): add payments endpoint", "feat", "api: add payments endpoint"},
+		{"fix(api)!: drop v1 routes", "fix", "api: drop v1 routes ⚠️ breaking change"},
+		{"refactor!: split the parser", "refactor", "split the parser ⚠️ breaking change"},
+		{"FIX:  tidy up", "fix", "tidy up"},
+		{"docs(readme): usage\n\nLonger body.", "docs", "readme: usage"},
+		{"  chore: bump deps  ", "chore", "bump deps"},
+		{"perf: faster start", "other", "perf: faster start"},
+		{"Merge pull request #12 from acme/search", "other", "Merge pull request #12 from acme/search"},
+		{"feat add search", "other", "feat add search"},
+		{"feat:", "other", "feat:"},
+		{"", "other", ""},
+	}
+	for _, test := range tests {
+		if got := changeType(test.message); got != test.kind {
+			t.Errorf("changeType(%q) = %q, want %q", test.message, got, test.kind)
+		}
+		if got := changeSummary(test.message); got != test.summary {
+			t.Errorf("changeSummary(%q) = %q, want %q", test.message, got, test.summary)
+		}
+	}
+}
+
+func TestGroupByChangeType(t *testing.T) {
+	commits := []Commit{
+		{SHA: "1", Message: "fix: one"},
+		{SHA: "2", Message: "update readme"},
+		{SHA: "3", Message: "feat: two"},
+		{SHA: "4", Message: "fix: three"},
+	}
+	var got []string
+	for _, group := range groupByChangeType(commits) {
+		var shas []string
+		for _, c := range group.Commits {
+			shas = append(shas, c.SHA)
+		}
+		got = append(got, fmt.Sprintf("%s%v", group.Type, shas))
+	}
+	if want := "[feat[3] fix[1 4] other[2]]"; fmt.Sprint(got) != want {
+		t.Errorf("groups = %v, want %s", got, want)
+	}
+	if groups := groupByChangeType(nil); groups != nil {
+		t.Errorf("groups of no commits = %v, want none", groups)
+	}
+}
@@ -0,0 +1,139 @@
+package releasereport
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Column headers of the CSV report
+var (
+	csvHeader        = []string{"service", "repo", "sha", "author", "date", "message", "url", "signature", "additions", "deletions", "files", "pull_request", "tickets"}
+	csvReleaseHeader = []string{"service", "repo", "tag", "name", "date", "prerelease", "url", "assets"}
+	csvPullHeader    = []string{"service", "repo", "number", "title", "author", "merged_at", "labels", "reviewers", "issues", "url"}
+)
+
+// Save the report as CSV
+func writeCSVReport(filename string, reports []ServiceReport, opts Options) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := RenderCSV(file, reports, opts); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Render the report as CSV with one row per commit, or per release or pull
+// request in those modes, dated in the time zone of opts
+func RenderCSV(out io.Writer, reports []ServiceReport, opts Options) error {
+	mode, loc := opts.Mode, opts.location()
+	header, rows := csvHeader, [][]string{}
+	for _, report := range reports {
+		traced := make(map[string]TraceLink)
+		for _, link := range report.Trace {
+			traced[link.Commit.SHA] = link
+		}
+		for _, c := range report.Commits {
+			row := []string{report.Service, report.Repo, c.SHA, c.Author, localTime(c.Date, loc), c.Message, c.URL, c.Verification, "", "", "", "", ""}
+			if c.Stats != nil {
+				row[8], row[9], row[10] = strconv.Itoa(c.Stats.Additions), strconv.Itoa(c.Stats.Deletions), strconv.Itoa(c.Stats.Files)
+			}
+			if link, ok := traced[c.SHA]; ok {
+				if link.Pull != nil {
+					row[11] = link.Pull.URL
+				}
+				row[12] = csvTickets(link.Tickets)
+			}
+			rows = append(rows, row)
+		}
+		if len(report.Errors) > 0 {
+			rows = append(rows, csvUnavailable(report, len(csvHeader), 5))
+		}
+	}
+	if mode == ModeReleases {
+		header, rows = csvReleaseHeader, nil
+		for _, report := range reports {
+			for _, r := range report.Releases {
+				var assets []string
+				for _, a := range r.Assets {
+					assets = append(assets, a.URL)
+				}
+				rows = append(rows, []string{report.Service, report.Repo, r.Tag, r.Name, localTime(r.Date, loc), strconv.FormatBool(r.Prerelease), r.URL, strings.Join(assets, " ")})
+			}
+			if len(report.Errors) > 0 {
+				rows = append(rows, csvUnavailable(report, len(header), 3))
+			}
+		}
+	}
+
+	if mode == ModePulls {
+		header, rows = csvPullHeader, nil
+		for _, report := range reports {
+			for _, p := range report.Pulls {
+				var issues []string
+				for _, issue := range p.Issues {
+					issues = append(issues, issue.Ref)
+				}
+				rows = append(rows, []string{report.Service, report.Repo, strconv.Itoa(p.Number), p.Title, p.Author, localTime(p.MergedAt, loc),
+					strings.Join(p.Labels, " "), strings.Join(p.Reviewers, " "), strings.Join(issues, " "), p.URL})
+			}
+			if len(report.Errors) > 0 {
+				rows = append(rows, csvUnavailable(report, len(header), 3))
+			}
+		}
+	}
+
+	w := csv.NewWriter(out)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		for i := range row {
+			row[i] = csvCell(row[i])
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Row of width columns marking the data of a service unavailable, with the
+// failures in the given (message or title) column
+func csvUnavailable(report ServiceReport, width, column int) []string {
+	row := make([]string, width)
+	row[0], row[1] = report.Service, report.Repo
+	var failures []string
+	for _, err := range report.Errors {
+		failures = append(failures, err.Error())
+	}
+	row[column] = "data unavailable: " + strings.Join(failures, "; ")
+	return row
+}
+
+// Ticket keys with their status, e.g. "VIAXAMS-1 (Done); VIAXAMS-2"
+func csvTickets(tickets []Ticket) string {
+	var cells []string
+	for _, t := range tickets {
+		if t.Status != "" {
+			cells = append(cells, t.Key+" ("+t.Status+")")
+		} else {
+			cells = append(cells, t.Key)
+		}
+	}
+	return strings.Join(cells, "; ")
+}
+
+// Quote a cell that spreadsheets would otherwise evaluate as a formula
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,48 @@
+package releasereport
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRenderCSVCommits(t *testing.T) {
+	reports := []ServiceReport{{
+		Service: "web",
+		Repo:    "acme/web",
+		Commits: []Commit{
+			{SHA: "c2", Author: "Ada", Date: "2026-03-10T12:00:00Z", Message: "feat: search, with \"quotes\"", URL: "https://github.com/acme/web/commit/c2"},
+			{SHA: "c1", Author: "Bob", Date: "2026-03-02T08:00:00Z", Message: "=HYPERLINK(\"x\")"},
+		},
+	}}
+	var out bytes.Buffer
+	if err := RenderCSV(&out, reports, Options{Mode: ModeCommits, Location: time.UTC}); err != nil {
+		t.Fatalf("RenderCSV: %v", err)
+	}
+	want := "service,repo,sha,author,date,message,url,signature,additions,deletions,files,pull_request,tickets\n" +
+		"web,acme/web,c2,Ada,2026-03-10T12:00:00Z,\"feat: search, with \"\"quotes\"\"\",https://github.com/acme/web/commit/c2,,,,,,\n" +
+		"web,acme/web,c1,Bob,2026-03-02T08:00:00Z,\"'=HYPERLINK(\"\"x\"\")\",,,,,,,\n"
+	if out.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"", ""},
+		{"fix: typo", "fix: typo"},
+		{"=SUM(A1:A2)", "'=SUM(A1:A2)"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@user", "'@user"},
+		{"\tindented", "'\tindented"},
+		{"a=b", "a=b"},
+	}
+	for _, test := range tests {
+		if got := csvCell(test.value); got != test.want {
+			t.Errorf("csvCell(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
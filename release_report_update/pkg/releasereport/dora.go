@@ -0,0 +1,80 @@
+package releasereport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Environment whose deployments the DORA metrics measure when the config
+// names neither "dora_environment" nor any "environments"
+const DefaultDORAEnvironment = "production"
+
+// DORA metrics of a service, with -dora
+type DORAMetrics struct {
+	Environment string
+	Deployments int    // successful deployments in the period
+	PerWeek     string // deployment frequency
+	LeadTime    string // median time from commit to its deployment, "–" when unknown
+	Changes     int    // commits the lead time was measured on
+}
+
+// Measure the deployment frequency and the lead time for changes of a
+// service from its deployments to the environment. Each deployment in the
+// period delivers the commits since the deployment before it; their lead
+// time runs from the commit to the deployment.
+func computeDORAMetrics(ctx context.Context, provider Provider, service Service, environment, since, until string) (*DORAMetrics, error) {
+	loc := settingsOf(ctx).location
+	deployments, err := provider.Deployments(ctx, service.Repo, environment, since, until)
+	if err != nil {
+		return nil, err
+	}
+	metrics := &DORAMetrics{Environment: environment, LeadTime: "–"}
+	var leadTimes []time.Duration
+	// Deployments are sorted newest first.
+	for i, deployment := range deployments {
+		if dateOf(deployment.Date, loc) < since {
+			break
+		}
+		metrics.Deployments++
+		if i+1 == len(deployments) || deployments[i+1].SHA == deployment.SHA {
+			continue // first deployment, or a redeployment
+		}
+		deployed, err := time.Parse(time.RFC3339, deployment.Date)
+		if err != nil {
+			continue
+		}
+		commits, err := provider.Compare(ctx, service.Repo, deployments[i+1].SHA, deployment.SHA)
+		if err == nil {
+			commits, err = filterByPaths(ctx, provider, service, commits)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range commits {
+			if committed, err := time.Parse(time.RFC3339, c.Date); err == nil && !committed.After(deployed) {
+				leadTimes = append(leadTimes, deployed.Sub(committed))
+			}
+		}
+	}
+	metrics.PerWeek = fmt.Sprintf("%.1f", float64(metrics.Deployments)/periodWeeks(since, until))
+	metrics.Changes = len(leadTimes)
+	if len(leadTimes) > 0 {
+		sort.Slice(leadTimes, func(i, j int) bool { return leadTimes[i] < leadTimes[j] })
+		median := leadTimes[len(leadTimes)/2]
+		if len(leadTimes)%2 == 0 {
+			median = (leadTimes[len(leadTimes)/2-1] + median) / 2
+		}
+		metrics.LeadTime = formatLeadTime(median)
+	}
+	return metrics, nil
+}
+
+// Lead time in hours, or in days from two days on
+func formatLeadTime(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%.1f days", d.Hours()/24)
+	}
+	return fmt.Sprintf("%.1f hours", d.Hours())
+}
@@ -0,0 +1,298 @@
+package releasereport
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources of the deployed version of a drift, in order of preference
+const (
+	driftSourceArgoCD      = "Argo CD"
+	driftSourceMapping     = "environment mapping"
+	driftSourceDeployments = "deployments"
+	driftSourceKubernetes  = "Kubernetes"
+)
+
+// Full commit SHAs, shortened in the drift table; tags are shown as they are
+var fullSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// How far an environment lags behind the branch of a service, with -drift
+type Drift struct {
+	Environment string
+	Deployed    string   // deployed commit, tag or branch; "" when none is known
+	DeployedAt  string   // "" when unknown
+	Source      string   // where the deployed version was found, e.g. "Argo CD"
+	Behind      int      // commits on the branch not deployed
+	Releases    []string // tags of the releases published since the deployment, newest first
+	Oldest      string   // date of the oldest commit not deployed, "" when none
+}
+
+// Deployed version as shown: a short SHA, else the tag or branch
+func (d Drift) Version() string {
+	if fullSHA.MatchString(d.Deployed) {
+		return shortSHA(d.Deployed)
+	}
+	return d.Deployed
+}
+
+// Whether the environment misses commits or releases of the branch
+func (d Drift) Lagging() bool {
+	return d.Behind > 0 || len(d.Releases) > 0
+}
+
+// Environment whose drift marks a service as lagging: the -dora
+// environment, else the last configured one (environments are in promotion
+// order), else DefaultDORAEnvironment
+func productionEnvironment(opts Options) string {
+	if opts.DORAEnvironment != "" {
+		return opts.DORAEnvironment
+	}
+	if len(opts.Environments) > 0 {
+		return opts.Environments[len(opts.Environments)-1]
+	}
+	return DefaultDORAEnvironment
+}
+
+// Environments the drift of a service is measured in: those of its
+// deployments (see serviceEnvironments), then those only Argo CD or
+// Kubernetes report, sorted; the production environment when there are none
+func driftEnvironments(report ServiceReport, service Service, opts Options) []string {
+	names := serviceEnvironments(service, opts.Environments)
+	var extra []string
+	for _, live := range report.Live {
+		if !contains(names, live.Environment) && !contains(extra, live.Environment) {
+			extra = append(extra, live.Environment)
+		}
+	}
+	for _, image := range report.Running {
+		if !contains(names, image.Environment) && !contains(extra, image.Environment) {
+			extra = append(extra, image.Environment)
+		}
+	}
+	sort.Strings(extra)
+	names = append(names, extra...)
+	if len(names) == 0 {
+		names = []string{productionEnvironment(opts)}
+	}
+	return names
+}
+
+// Find what runs in an environment now: the Argo CD revision, else the
+// mapped branch or newest mapped tag, else the latest deployment, else the
+// single image tag Kubernetes runs. Returns a drift without Deployed when
+// none is known.
+func deployedVersion(ctx context.Context, provider Provider, report ServiceReport, service Service, environment, today string) (Drift, error) {
+	drift := Drift{Environment: environment}
+	for _, live := range report.Live {
+		if live.Environment == environment && live.Chart == "" && live.Revision != "" {
+			drift.Deployed, drift.DeployedAt, drift.Source = live.Revision, live.DeployedAt, driftSourceArgoCD
+			return drift, nil
+		}
+	}
+	if ref, ok := service.Environments[environment]; ok {
+		if strings.HasPrefix(ref, tagMappingPrefix) {
+			tags, err := provider.Tags(ctx, service.Repo, strings.TrimPrefix(ref, tagMappingPrefix))
+			if err != nil {
+				return drift, err
+			}
+			for _, tag := range tags {
+				if tag.Date > drift.DeployedAt {
+					drift.Deployed, drift.DeployedAt = tag.Name, tag.Date
+				}
+			}
+		} else if !strings.ContainsAny(ref, "*?[") {
+			drift.Deployed = ref
+		}
+		if drift.Deployed != "" {
+			drift.Source = driftSourceMapping
+		}
+		return drift, nil
+	}
+	deployments, err := provider.Deployments(ctx, service.Repo, environment, today, today)
+	if err != nil {
+		return drift, err
+	}
+	if len(deployments) > 0 {
+		drift.Deployed, drift.DeployedAt, drift.Source = deployments[0].SHA, deployments[0].Date, driftSourceDeployments
+		return drift, nil
+	}
+	var tags []string
+	for _, image := range report.Running {
+		if image.Environment == environment && !contains(tags, image.Tag) {
+			tags = append(tags, image.Tag)
+		}
+	}
+	// Several tags mean a rollout in progress; digests and "latest" name no
+	// git ref.
+	if len(tags) == 1 && tags[0] != "latest" && !strings.Contains(tags[0], ":") {
+		drift.Deployed, drift.Source = tags[0], driftSourceKubernetes
+	}
+	return drift, nil
+}
+
+// Measure the drift of a service in each of its environments: the commits
+// on its branch after the deployed version and the releases published
+// since it was deployed
+func measureDrift(ctx context.Context, provider Provider, report ServiceReport, service Service, opts Options) ([]Drift, error) {
+	today := time.Now().In(opts.location()).Format("2006-01-02")
+	var releases []Release
+	fetchedReleases := false
+	var drifts []Drift
+	for _, environment := range driftEnvironments(report, service, opts) {
+		drift, err := deployedVersion(ctx, provider, report, service, environment, today)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", environment, err)
+		}
+		if drift.Deployed != "" {
+			commits, err := provider.Compare(ctx, service.Repo, drift.Deployed, service.branch())
+			if err == nil {
+				commits, err = filterByPaths(ctx, provider, service, commits)
+			}
+			if err == nil {
+				commits, err = opts.Filter.filter(ctx, provider, service.Repo, commits)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("environment %s: %w", environment, err)
+			}
+			drift.Behind = len(commits)
+			if len(commits) > 0 {
+				drift.Oldest = commits[len(commits)-1].Date
+			}
+
+			if !fetchedReleases {
+				if releases, err = provider.Releases(ctx, service.Repo, "", today); err != nil {
+					return nil, err
+				}
+				fetchedReleases = true
+			}
+			drift.Releases = undeployedReleases(releases, drift)
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// Tags of the releases, newest first, published after the deployed version:
+// after the deployed release when a release is deployed, else after the
+// deployment; none when neither is known
+func undeployedReleases(releases []Release, drift Drift) []string {
+	var tags []string
+	for _, release := range releases {
+		if release.Tag == drift.Deployed {
+			return tags
+		}
+		if release.Prerelease {
+			continue
+		}
+		tags = append(tags, release.Tag)
+	}
+	if drift.DeployedAt == "" {
+		return nil
+	}
+	tags = nil
+	for _, release := range releases {
+		if !release.Prerelease && release.Date > drift.DeployedAt {
+			tags = append(tags, release.Tag)
+		}
+	}
+	return tags
+}
+
+// Fill in the drift of the services reported by date, opts.Concurrency
+// services at a time, once Argo CD and Kubernetes told what runs where.
+// Failures are recorded in the service, or returned with opts.FailFast.
+func fillDrift(ctx context.Context, providers map[string]Provider, services []Service, reports []ServiceReport, opts Options) error {
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	slots := make(chan struct{}, workers)
+	var (
+		mu       sync.Mutex
+		fatalErr error
+		wg       sync.WaitGroup
+	)
+	for i := range reports {
+		if reports[i].Range != "" {
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			drifts, err := measureDrift(ctx, providers[services[i].provider()], reports[i], services[i], opts)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				reports[i].Drift = drifts
+			case fatal(ctx, err) || opts.FailFast:
+				if fatalErr == nil {
+					fatalErr = fmt.Errorf("%s: drift: %w", services[i].Service, err)
+				}
+			default:
+				reports[i].skip(ctx, "drift", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return fatalErr
+}
+
+// Drift of every service per environment, for the drift table
+type driftTable struct {
+	Environments []string
+	Production   string // environment whose drift marks a service lagging
+	Rows         []driftRow
+}
+
+// Drift of one service, one cell per environment
+type driftRow struct {
+	Service string
+	Cells   []*Drift // nil where the drift was not measured
+	Lagging bool     // behind in the production environment
+}
+
+// Build the drift table of the services with a measured drift: the
+// configured environments first, in their order, then the others sorted
+func buildDriftTable(reports []ServiceReport, opts Options) *driftTable {
+	table := &driftTable{Environments: append([]string{}, opts.Environments...), Production: productionEnvironment(opts)}
+	var extra []string
+	for _, report := range reports {
+		for _, drift := range report.Drift {
+			if !contains(table.Environments, drift.Environment) && !contains(extra, drift.Environment) {
+				extra = append(extra, drift.Environment)
+			}
+		}
+	}
+	sort.Strings(extra)
+	table.Environments = append(table.Environments, extra...)
+	for _, report := range reports {
+		if len(report.Drift) == 0 {
+			continue
+		}
+		row := driftRow{Service: report.Service, Cells: make([]*Drift, len(table.Environments))}
+		for i := range report.Drift {
+			drift := &report.Drift[i]
+			for j, environment := range table.Environments {
+				if environment == drift.Environment {
+					row.Cells[j] = drift
+				}
+			}
+			if drift.Environment == table.Production && drift.Lagging() {
+				row.Lagging = true
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	if len(table.Rows) == 0 {
+		return nil
+	}
+	return table
+}
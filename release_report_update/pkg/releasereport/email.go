@@ -0,0 +1,140 @@
+package releasereport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default SMTP submission port (STARTTLS)
+const defaultSMTPPort = 587
+
+// Check the SMTP settings before any report is generated
+func (c SMTPConfig) validate() error {
+	if c.Host == "" {
+		return errors.New("smtp.host is required")
+	}
+	if c.From == "" {
+		return errors.New("smtp.from is required")
+	}
+	if len(c.To) == 0 {
+		return errors.New("smtp.to needs at least one recipient")
+	}
+	for _, address := range append(append([]string{c.From}, c.To...), c.Cc...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("smtp: invalid address %q: %v", address, err)
+		}
+	}
+	return nil
+}
+
+// Build the email with the HTML report as its body
+func buildReportEmail(c SMTPConfig, subject string, html []byte) ([]byte, error) {
+	var msg bytes.Buffer
+	header := func(name, value string) { fmt.Fprintf(&msg, "%s: %s\r\n", name, value) }
+	header("From", c.From)
+	header("To", strings.Join(c.To, ", "))
+	if len(c.Cc) > 0 {
+		header("Cc", strings.Join(c.Cc, ", "))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/html; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "quoted-printable")
+	msg.WriteString("\r\n")
+
+	// Quoted-printable keeps lines within the SMTP limit of 998 characters.
+	body := quotedprintable.NewWriter(&msg)
+	if _, err := body.Write(html); err != nil {
+		return nil, err
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// SMTP port, defaulting to submission with STARTTLS
+func (c SMTPConfig) port() int {
+	if c.Port == 0 {
+		return defaultSMTPPort
+	}
+	return c.Port
+}
+
+// Email the HTML report to the configured recipients
+func SendEmail(ctx context.Context, c SMTPConfig, timeout time.Duration, subject string, html []byte) error {
+	msg, err := buildReportEmail(c, subject, html)
+	if err != nil {
+		return err
+	}
+	port := c.port()
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(port))
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	tlsConfig := &tls.Config{ServerName: c.Host}
+	if port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if c.Username != "" {
+		password := os.Getenv("SMTP_PASSWORD")
+		if password == "" {
+			password = c.Password
+		}
+		// PlainAuth refuses to send credentials without TLS, except to localhost.
+		if err := client.Auth(smtp.PlainAuth("", c.Username, password, c.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(c.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range append(append([]string{}, c.To...), c.Cc...) {
+		to, _ := mail.ParseAddress(recipient)
+		if err := client.Rcpt(to.Address); err != nil {
+			return fmt.Errorf("recipient %s: %v", to.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
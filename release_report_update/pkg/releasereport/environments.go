@@ -0,0 +1,166 @@
+package releasereport
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Prefix of an environment mapping that selects tags instead of branches
+const tagMappingPrefix = "tag:"
+
+// Changes of a service deployed to one environment in the report period
+type EnvironmentReport struct {
+	Name     string
+	Deployed *Deployment // latest deployment (or mapped tag) in the period, nil when none
+	Previous *Deployment // last deployment (or mapped tag) before the period, nil when none
+	Branches []string    // mapped branches the changes are on, instead of deployments
+	Commits  []Commit    // commits between Previous and Deployed, or on Branches in the period
+	Groups   []ChangeGroup
+}
+
+// Check the environment mapping of a service
+func checkEnvironmentMapping(service Service) error {
+	for environment, ref := range service.Environments {
+		pattern := strings.TrimPrefix(ref, tagMappingPrefix)
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("service %s: invalid ref %q for environment %s", service.Service, ref, environment)
+		}
+	}
+	return nil
+}
+
+// Environments of a service: the configured environments in their order,
+// then those only the service maps, sorted
+func serviceEnvironments(service Service, environments []string) []string {
+	names := append([]string{}, environments...)
+	var extra []string
+	for environment := range service.Environments {
+		if !contains(names, environment) {
+			extra = append(extra, environment)
+		}
+	}
+	sort.Strings(extra)
+	return append(names, extra...)
+}
+
+// Find what each environment received in the period. Environments the
+// service maps to branches or tags are compared by those refs; the others
+// by their deployments: the commits between the last deployment before the
+// period and the latest one in it.
+func environmentReports(ctx context.Context, provider Provider, service Service, environments []string, since, until string) ([]EnvironmentReport, error) {
+	var reports []EnvironmentReport
+	for _, environment := range serviceEnvironments(service, environments) {
+		var report EnvironmentReport
+		var err error
+		if ref, ok := service.Environments[environment]; ok {
+			report, err = mappedEnvironmentReport(ctx, provider, service, ref, since, until)
+		} else {
+			var deployments []Deployment
+			deployments, err = provider.Deployments(ctx, service.Repo, environment, since, until)
+			if err == nil {
+				report, err = comparedEnvironmentReport(ctx, provider, service, deployments, since)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", environment, err)
+		}
+		report.Name = environment
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// Report the changes between the newest deployment in the period and the
+// newest one before it, from deployments sorted newest first
+func comparedEnvironmentReport(ctx context.Context, provider Provider, service Service, deployments []Deployment, since string) (EnvironmentReport, error) {
+	loc := settingsOf(ctx).location
+	var report EnvironmentReport
+	for i := range deployments {
+		if dateOf(deployments[i].Date, loc) >= since {
+			if report.Deployed == nil {
+				report.Deployed = &deployments[i]
+			}
+			continue
+		}
+		report.Previous = &deployments[i]
+		break
+	}
+	if report.Deployed != nil && report.Previous != nil && report.Deployed.SHA != report.Previous.SHA {
+		commits, err := provider.Compare(ctx, service.Repo, report.Previous.SHA, report.Deployed.SHA)
+		if err == nil {
+			commits, err = filterByPaths(ctx, provider, service, commits)
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Commits = commits
+		report.Groups = groupByChangeType(commits)
+	}
+	return report, nil
+}
+
+// Report an environment mapped to a branch, a branch pattern such as
+// "release/*" or a tag pattern such as "tag:v*"
+func mappedEnvironmentReport(ctx context.Context, provider Provider, service Service, ref, since, until string) (EnvironmentReport, error) {
+	loc := settingsOf(ctx).location
+	if strings.HasPrefix(ref, tagMappingPrefix) {
+		tags, err := provider.Tags(ctx, service.Repo, strings.TrimPrefix(ref, tagMappingPrefix))
+		if err != nil {
+			return EnvironmentReport{}, err
+		}
+		var deployments []Deployment
+		for _, tag := range tags {
+			if dateOf(tag.Date, loc) <= until {
+				deployments = append(deployments, Deployment{SHA: tag.SHA, Ref: tag.Name, Date: tag.Date})
+			}
+		}
+		sort.SliceStable(deployments, func(i, j int) bool { return deployments[i].Date > deployments[j].Date })
+		return comparedEnvironmentReport(ctx, provider, service, deployments, since)
+	}
+
+	branches := []string{ref}
+	if strings.ContainsAny(ref, "*?[") {
+		all, err := provider.Branches(ctx, service.Repo)
+		if err != nil {
+			return EnvironmentReport{}, err
+		}
+		branches = nil
+		for _, branch := range all {
+			if ok, _ := path.Match(ref, branch); ok {
+				branches = append(branches, branch)
+			}
+		}
+		sort.Strings(branches)
+	}
+
+	report := EnvironmentReport{Branches: branches}
+	seen := make(map[string]bool)
+	for _, branch := range branches {
+		onBranch := service
+		onBranch.Branch = branch
+		commits, err := fetchServiceCommits(ctx, provider, onBranch, since, until)
+		if err != nil {
+			return report, fmt.Errorf("branch %s: %w", branch, err)
+		}
+		for _, c := range commits {
+			if !seen[c.SHA] {
+				seen[c.SHA] = true
+				report.Commits = append(report.Commits, c)
+			}
+		}
+	}
+	sort.SliceStable(report.Commits, func(i, j int) bool { return report.Commits[i].Date > report.Commits[j].Date })
+	report.Groups = groupByChangeType(report.Commits)
+	return report, nil
+}
+
+// Abbreviated commit SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,67 @@
+package releasereport
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Authors excluded with "exclude_bots", matched case-insensitively against
+// the commit author's name and email
+var botAuthorPatterns = []string{"*[bot]", "*[bot]@*", "dependabot*", "renovate*"}
+
+// Whether the filter leaves out the commit
+func (f CommitFilter) excludes(c Commit) bool {
+	if f.SkipMerges && c.Merge {
+		return true
+	}
+	patterns := f.ExcludeAuthors
+	if f.ExcludeBots {
+		patterns = append(append([]string{}, patterns...), botAuthorPatterns...)
+	}
+	for _, pattern := range patterns {
+		for _, author := range []string{c.Author, c.AuthorEmail} {
+			if author != "" && matchAuthor(pattern, author) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Commits the filter keeps
+func (f CommitFilter) apply(commits []Commit) []Commit {
+	var kept []Commit
+	for _, c := range commits {
+		if !f.excludes(c) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// Whether an author name or email matches a pattern with "*" wildcards,
+// ignoring case
+func matchAuthor(pattern, author string) bool {
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+	matched, _ := regexp.MatchString("(?i)^"+expr+"$", author)
+	return matched
+}
+
+// Commits of a repository the filter keeps, with squash merges deduplicated
+// when configured. A failed deduplication is printed and keeps the commits;
+// only errors that abort the report are returned.
+func (f CommitFilter) filter(ctx context.Context, provider Provider, repo string, commits []Commit) ([]Commit, error) {
+	commits = f.apply(commits)
+	if !f.DedupeSquashMerges {
+		return commits, nil
+	}
+	deduped, err := dedupeSquashMerges(ctx, provider, repo, commits)
+	if fatal(ctx, err) {
+		return nil, err
+	}
+	if err != nil {
+		settingsOf(ctx).logger.Warn("skipping squash-merge deduplication", "repo", repo, "error", err)
+	}
+	return deduped, nil
+}
@@ -0,0 +1,67 @@
+package releasereport
+
+import (
+	"testing"
+)
+
+func TestCommitFilterExcludes(t *testing.T) {
+	human := Commit{Author: "Ada Lovelace", AuthorEmail: "ada@example.com"}
+	merge := Commit{Author: "Ada Lovelace", AuthorEmail: "ada@example.com", Merge: true}
+	dependabot := Commit{Author: "dependabot[bot]", AuthorEmail: "49699333+dependabot[bot]@users.noreply.github.com"}
+	renovate := Commit{Author: "Renovate Bot", AuthorEmail: "bot@renovateapp.com"}
+	ci := Commit{Author: "CI", AuthorEmail: "ci@example.com"}
+	tests := []struct {
+		filter CommitFilter
+		commit Commit
+		want   bool
+	}{
+		{CommitFilter{}, human, false},
+		{CommitFilter{}, merge, false},
+		{CommitFilter{}, dependabot, false},
+		{CommitFilter{SkipMerges: true}, merge, true},
+		{CommitFilter{SkipMerges: true}, human, false},
+		{CommitFilter{ExcludeBots: true}, dependabot, true},
+		{CommitFilter{ExcludeBots: true}, renovate, true},
+		{CommitFilter{ExcludeBots: true}, human, false},
+		{CommitFilter{ExcludeAuthors: []string{"ci@*"}}, ci, true},
+		{CommitFilter{ExcludeAuthors: []string{"ADA*"}}, human, true},
+		{CommitFilter{ExcludeAuthors: []string{"ada"}}, human, false},
+		{CommitFilter{ExcludeAuthors: []string{"ci@*"}, ExcludeBots: true}, ci, true},
+	}
+	for _, test := range tests {
+		if got := test.filter.excludes(test.commit); got != test.want {
+			t.Errorf("%+v excludes %s <%s> (merge %v) = %v, want %v", test.filter, test.commit.Author, test.commit.AuthorEmail, test.commit.Merge, got, test.want)
+		}
+	}
+}
+
+func TestCommitFilterKeepsExcludeAuthors(t *testing.T) {
+	authors := make([]string, 1, 8)
+	authors[0] = "ci@*"
+	filter := CommitFilter{ExcludeAuthors: authors, ExcludeBots: true}
+	filter.excludes(Commit{Author: "Ada"})
+	if extra := authors[:cap(authors)][1]; extra != "" {
+		t.Errorf("excludes wrote %q past the configured exclude_authors", extra)
+	}
+}
+
+func TestMatchAuthor(t *testing.T) {
+	tests := []struct {
+		pattern, author string
+		want            bool
+	}{
+		{"ci@example.com", "ci@example.com", true},
+		{"ci@example.com", "CI@Example.com", true},
+		{"ci@example.com", "ci@example.com.evil", false},
+		{"*[bot]", "github-actions[bot]", true},
+		{"*[bot]", "robot", false},
+		{"*.bot@*", "deploy.bot@example.com", true},
+		{"*.bot@*", "deploybot@example.com", false},
+		{"release (ci)", "release (ci)", true},
+	}
+	for _, test := range tests {
+		if got := matchAuthor(test.pattern, test.author); got != test.want {
+			t.Errorf("matchAuthor(%q, %q) = %v, want %v", test.pattern, test.author, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,682 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default GitHub API root
+const DefaultAPIBaseURL = "https://api.github.com"
+
+// Connection settings of the GitHub (Enterprise Server) API
+type githubAPI struct {
+	client  *http.Client
+	baseURL string // without a trailing slash
+	token   string
+	app     *githubAppAuth // authenticates instead of token when set
+}
+
+// GitHub provider authenticating with a token. baseURL is the API root, e.g.
+// DefaultAPIBaseURL; a nil client gets the default timeout.
+func NewGitHub(client *http.Client, baseURL, token string) Provider {
+	return githubAPI{client: defaultClient(client), baseURL: strings.TrimSuffix(baseURL, "/"), token: token}
+}
+
+// Commit as returned by the GitHub commits API
+type githubCommit struct {
+	SHA    string `json:"sha"`
+	URL    string `json:"html_url"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+			Date  string `json:"date"`
+		} `json:"author"`
+		Verification struct {
+			Verified bool   `json:"verified"`
+			Reason   string `json:"reason"`
+		} `json:"verification"`
+	} `json:"commit"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+// Provider-neutral form of a GitHub commit
+func (c githubCommit) normalize() Commit {
+	return Commit{
+		SHA:          c.SHA,
+		Message:      c.Commit.Message,
+		Author:       c.Commit.Author.Name,
+		AuthorEmail:  c.Commit.Author.Email,
+		URL:          c.URL,
+		Date:         c.Commit.Author.Date,
+		Merge:        len(c.Parents) > 1,
+		Verification: c.verification(),
+	}
+}
+
+// Signature verification of a GitHub commit; "" when the API left it out
+func (c githubCommit) verification() string {
+	if c.Commit.Verification.Verified {
+		return verifiedSignature
+	}
+	return c.Commit.Verification.Reason
+}
+
+// Authorization header value: the GitHub App installation token when
+// authenticating as an app, else the token
+func (api githubAPI) authorization(ctx context.Context) (string, error) {
+	if api.app == nil {
+		return "token " + api.token, nil
+	}
+	token, err := api.app.installationToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "token " + token, nil
+}
+
+// Identity the response cache is keyed on: the app installation when
+// authenticating as an app, whose tokens change every run, else the
+// authorization itself
+func (api githubAPI) cacheIdentity(authorization string) string {
+	if api.app == nil {
+		return authorization
+	}
+	return fmt.Sprintf("app:%d/%d", api.app.appID, api.app.installationID)
+}
+
+// Fetch commits from GitHub API, following pagination
+func (api githubAPI) Commits(ctx context.Context, repo, branch, since, until, path string) ([]Commit, error) {
+	loc := settingsOf(ctx).location
+	query := url.Values{"sha": {branch}, "since": {periodStart(since, loc)}, "until": {periodEnd(until, loc)}, "per_page": {"100"}}
+	if path != "" {
+		query.Set("path", path)
+	}
+	next := fmt.Sprintf("%s/repos/%s/commits?%s", api.baseURL, repo, query.Encode())
+	var commits []Commit
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []githubCommit
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub commits of %s: %v", repo, err)
+		}
+		for _, c := range page {
+			commits = append(commits, c.normalize())
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// GET next and every following page, passing each page body to page, until
+// page returns errLastPage. Failures name repo.
+func (api githubAPI) getPages(ctx context.Context, next, repo string, page func(body io.Reader) error) error {
+	for next != "" {
+		authorization, err := api.authorization(ctx)
+		if err != nil {
+			return err
+		}
+		resp, err := apiGetAs(ctx, api.client, next, "Authorization", authorization, api.cacheIdentity(authorization))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			resp.Body.Close()
+			return fmt.Errorf("GitHub rejected the token (%w); check -token, GITHUB_TOKEN or `gh auth status`", errUnauthorized)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("GitHub API returned %s for %s", resp.Status, repo)
+		}
+		err = page(resp.Body)
+		resp.Body.Close()
+		if err == errLastPage {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		next = nextPageURL(resp)
+	}
+	return nil
+}
+
+// Release as returned by the GitHub releases API
+type githubRelease struct {
+	ID          int64  `json:"id"`
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	URL         string `json:"html_url"`
+	Body        string `json:"body"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
+	PublishedAt string `json:"published_at"`
+	Assets      []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// Fetch the releases published in the period from GitHub API
+func (api githubAPI) Releases(ctx context.Context, repo, since, until string) ([]Release, error) {
+	loc := settingsOf(ctx).location
+	next := fmt.Sprintf("%s/repos/%s/releases?per_page=100", api.baseURL, repo)
+	var releases []Release
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []githubRelease
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub releases of %s: %v", repo, err)
+		}
+		// Releases are listed newest first, so paging stops after a page
+		// whose releases were all published before the period.
+		older := len(page) > 0
+		for _, r := range page {
+			if r.Draft || r.PublishedAt == "" || dateOf(r.PublishedAt, loc) >= since {
+				older = false
+			}
+			if r.Draft || !inPeriod(r.PublishedAt, since, until, loc) {
+				continue
+			}
+			release := Release{Tag: r.TagName, Name: r.Name, URL: r.URL, Date: r.PublishedAt, Notes: r.Body, Prerelease: r.Prerelease}
+			for _, a := range r.Assets {
+				release.Assets = append(release.Assets, Asset{Name: a.Name, URL: a.URL})
+			}
+			releases = append(releases, release)
+		}
+		if older {
+			return errLastPage
+		}
+		return nil
+	})
+	return releases, err
+}
+
+// Fetch the commits between two refs from GitHub compare API
+func (api githubAPI) Compare(ctx context.Context, repo, base, head string) ([]Commit, error) {
+	next := fmt.Sprintf("%s/repos/%s/compare/%s...%s?per_page=100", api.baseURL, repo, url.PathEscape(base), url.PathEscape(head))
+	var commits []Commit
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page struct {
+			Commits []githubCommit `json:"commits"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub comparison of %s: %v", repo, err)
+		}
+		for _, c := range page.Commits {
+			commits = append(commits, c.normalize())
+		}
+		return nil
+	})
+	// GitHub lists compared commits oldest first.
+	reverseCommits(commits)
+	return commits, err
+}
+
+// Pull request as returned by the GitHub pulls API
+type githubPull struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	URL       string `json:"html_url"`
+	MergedAt  string `json:"merged_at"`
+	UpdatedAt string `json:"updated_at"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+// Pull request of the report
+func (p githubPull) pullRequest(repo string) PullRequest {
+	pull := PullRequest{Number: p.Number, Title: p.Title, Author: p.User.Login, URL: p.URL, MergedAt: p.MergedAt, Description: p.Body}
+	for _, label := range p.Labels {
+		pull.Labels = append(pull.Labels, label.Name)
+	}
+	pull.Issues = githubPullIssues(p.Body, p.URL, repo, p.Number)
+	return pull
+}
+
+// Issues closed by a pull request description, linked next to the pull
+// request at pullURL
+func githubPullIssues(body, pullURL, repo string, number int) []Issue {
+	return linkedIssues(body, func(issueRepo, issue string) string {
+		if issueRepo == "" {
+			return strings.TrimSuffix(pullURL, fmt.Sprintf("/pull/%d", number)) + "/issues/" + issue
+		}
+		return strings.TrimSuffix(pullURL, fmt.Sprintf("/%s/pull/%d", repo, number)) + "/" + issueRepo + "/issues/" + issue
+	})
+}
+
+// Fetch the pull requests merged in the period from GitHub API, with the
+// reviewers of each
+func (api githubAPI) PullRequests(ctx context.Context, repo, since, until string) ([]PullRequest, error) {
+	loc := settingsOf(ctx).location
+	// Closed pull requests, most recently updated first; one merged in the
+	// period was updated in it or later, so paging stops at older updates.
+	next := fmt.Sprintf("%s/repos/%s/pulls?state=closed&sort=updated&direction=desc&per_page=100", api.baseURL, repo)
+	var pulls []PullRequest
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []githubPull
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub pull requests of %s: %v", repo, err)
+		}
+		for _, p := range page {
+			if p.UpdatedAt != "" && dateOf(p.UpdatedAt, loc) < since {
+				return errLastPage
+			}
+			if p.MergedAt == "" || !inPeriod(p.MergedAt, since, until, loc) {
+				continue
+			}
+			pulls = append(pulls, p.pullRequest(repo))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pulls {
+		next := fmt.Sprintf("%s/repos/%s/pulls/%d/reviews?per_page=100", api.baseURL, repo, pulls[i].Number)
+		err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+			var reviews []struct {
+				User struct {
+					Login string `json:"login"`
+				} `json:"user"`
+			}
+			if err := json.NewDecoder(body).Decode(&reviews); err != nil {
+				return fmt.Errorf("decoding GitHub reviews of %s#%d: %v", repo, pulls[i].Number, err)
+			}
+			for _, review := range reviews {
+				if review.User.Login != pulls[i].Author {
+					pulls[i].Reviewers = appendUnique(pulls[i].Reviewers, review.User.Login)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(pulls, func(i, j int) bool { return pulls[i].MergedAt > pulls[j].MergedAt })
+	return pulls, nil
+}
+
+// Issue as returned by the GitHub issues API, which lists pull requests too
+type githubIssue struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	URL         string `json:"html_url"`
+	ClosedAt    string `json:"closed_at"`
+	PullRequest *struct {
+		URL      string `json:"html_url"`
+		MergedAt string `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+// GitHub closes the issues a pull request references when it merges, so an
+// issue closed within this long of a referencing pull request's merge was
+// closed by it
+const closedByMergeWindow = time.Minute
+
+// Fetch the issues closed in the period by merged pull requests from GitHub
+// API: closed issues with a timeline cross-reference from a pull request
+// merged when the issue closed
+func (api githubAPI) ClosedIssues(ctx context.Context, repo, since, until string) ([]ClosedIssue, error) {
+	loc := settingsOf(ctx).location
+	// Issues closed in the period were updated in it or later.
+	query := url.Values{"state": {"closed"}, "since": {periodStart(since, loc)}, "sort": {"updated"}, "per_page": {"100"}}
+	var candidates []githubIssue
+	err := api.getPages(ctx, fmt.Sprintf("%s/repos/%s/issues?%s", api.baseURL, repo, query.Encode()), repo, func(body io.Reader) error {
+		var page []githubIssue
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub issues of %s: %v", repo, err)
+		}
+		for _, issue := range page {
+			if issue.PullRequest == nil && inPeriod(issue.ClosedAt, since, until, loc) {
+				candidates = append(candidates, issue)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []ClosedIssue
+	for _, issue := range candidates {
+		closed, err := time.Parse(time.RFC3339, issue.ClosedAt)
+		if err != nil {
+			continue
+		}
+		var closer *githubIssue
+		next := fmt.Sprintf("%s/repos/%s/issues/%d/timeline?per_page=100", api.baseURL, repo, issue.Number)
+		err = api.getPages(ctx, next, repo, func(body io.Reader) error {
+			var events []struct {
+				Event  string `json:"event"`
+				Source struct {
+					Issue githubIssue `json:"issue"`
+				} `json:"source"`
+			}
+			if err := json.NewDecoder(body).Decode(&events); err != nil {
+				return fmt.Errorf("decoding GitHub timeline of %s#%d: %v", repo, issue.Number, err)
+			}
+			for _, event := range events {
+				pull := event.Source.Issue
+				if event.Event != "cross-referenced" || pull.PullRequest == nil {
+					continue
+				}
+				merged, err := time.Parse(time.RFC3339, pull.PullRequest.MergedAt)
+				if err == nil && closed.Sub(merged) >= -closedByMergeWindow && closed.Sub(merged) <= closedByMergeWindow {
+					closer = &pull
+					return errLastPage
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if closer != nil {
+			issues = append(issues, ClosedIssue{Number: issue.Number, Title: issue.Title, URL: issue.URL, ClosedAt: issue.ClosedAt, Pull: closer.Number, PullURL: closer.PullRequest.URL})
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].ClosedAt > issues[j].ClosedAt })
+	return issues, nil
+}
+
+// Check with GitHub API whether an author committed to repo before date
+func (api githubAPI) CommittedBefore(ctx context.Context, repo, email, date string) (bool, error) {
+	loc := settingsOf(ctx).location
+	query := url.Values{"author": {email}, "until": {periodStart(date, loc)}, "per_page": {"1"}}
+	found := false
+	err := api.getPages(ctx, fmt.Sprintf("%s/repos/%s/commits?%s", api.baseURL, repo, query.Encode()), repo, func(body io.Reader) error {
+		var page []githubCommit
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub commits of %s: %v", repo, err)
+		}
+		found = len(page) > 0
+		return errLastPage
+	})
+	return found, err
+}
+
+// Fetch the files changed by a commit from GitHub API
+func (api githubAPI) CommitFiles(ctx context.Context, repo, sha string) ([]string, error) {
+	var files []string
+	err := api.getPages(ctx, fmt.Sprintf("%s/repos/%s/commits/%s?per_page=100", api.baseURL, repo, sha), repo, func(body io.Reader) error {
+		var commit struct {
+			Files []struct {
+				Filename         string `json:"filename"`
+				PreviousFilename string `json:"previous_filename"`
+			} `json:"files"`
+		}
+		if err := json.NewDecoder(body).Decode(&commit); err != nil {
+			return fmt.Errorf("decoding GitHub commit %s of %s: %v", sha, repo, err)
+		}
+		for _, f := range commit.Files {
+			files = appendUnique(files, f.Filename)
+			files = appendUnique(files, f.PreviousFilename)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Deployment as returned by the GitHub deployments API
+type githubDeployment struct {
+	ID        int64  `json:"id"`
+	SHA       string `json:"sha"`
+	Ref       string `json:"ref"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Fetch the successful deployments to an environment from GitHub
+// Deployments and Deployment Statuses APIs
+func (api githubAPI) Deployments(ctx context.Context, repo, environment, since, until string) ([]Deployment, error) {
+	loc := settingsOf(ctx).location
+	query := url.Values{"environment": {environment}, "per_page": {"100"}}
+	next := fmt.Sprintf("%s/repos/%s/deployments?%s", api.baseURL, repo, query.Encode())
+	var candidates []githubDeployment
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []githubDeployment
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub deployments of %s: %v", repo, err)
+		}
+		candidates = append(candidates, page...)
+		// Deployments are listed newest first; older ones are not needed once
+		// one was created before the period.
+		if len(page) > 0 && dateOf(page[len(page)-1].CreatedAt, loc) < since {
+			return errLastPage
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deployments []Deployment
+	for _, d := range candidates {
+		if dateOf(d.CreatedAt, loc) > until {
+			continue
+		}
+		var success string
+		next := fmt.Sprintf("%s/repos/%s/deployments/%d/statuses?per_page=100", api.baseURL, repo, d.ID)
+		err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+			var statuses []struct {
+				State     string `json:"state"`
+				CreatedAt string `json:"created_at"`
+			}
+			if err := json.NewDecoder(body).Decode(&statuses); err != nil {
+				return fmt.Errorf("decoding GitHub deployment statuses of %s: %v", repo, err)
+			}
+			for _, status := range statuses {
+				if status.State == "success" {
+					success = status.CreatedAt
+					return errLastPage
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if success == "" || dateOf(success, loc) > until {
+			continue
+		}
+		deployments = append(deployments, Deployment{SHA: d.SHA, Ref: d.Ref, Environment: environment, Date: success})
+		if dateOf(success, loc) < since {
+			break
+		}
+	}
+	return deployments, nil
+}
+
+// Fetch the branch names from GitHub API
+func (api githubAPI) Branches(ctx context.Context, repo string) ([]string, error) {
+	var branches []string
+	err := api.getPages(ctx, fmt.Sprintf("%s/repos/%s/branches?per_page=100", api.baseURL, repo), repo, func(body io.Reader) error {
+		var page []struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub branches of %s: %v", repo, err)
+		}
+		for _, b := range page {
+			branches = append(branches, b.Name)
+		}
+		return nil
+	})
+	return branches, err
+}
+
+// Object a GitHub tag points to: the commit itself, or for an annotated tag
+// the tag object pointing to it
+type githubTagTarget struct {
+	OID           string           `json:"oid"`
+	CommittedDate string           `json:"committedDate"`
+	Target        *githubTagTarget `json:"target"`
+}
+
+// Fetch the matching tags, with the date of each tagged commit, from the
+// GitHub GraphQL API: the REST API has no tag dates, which would take a
+// request per tag.
+func (api githubAPI) Tags(ctx context.Context, repo, pattern string) ([]Tag, error) {
+	owner, name := splitRepo(repo)
+	var tags []Tag
+	after := "null"
+	for {
+		query := fmt.Sprintf(`query { repository(owner: %s, name: %s) { refs(refPrefix: "refs/tags/", first: 100, after: %s) {
+    pageInfo { hasNextPage endCursor }
+    nodes { name target { oid ... on Commit { committedDate } ... on Tag { target { oid ... on Commit { committedDate } } } } }
+  } } }`, graphQLString(owner), graphQLString(name), after)
+		var data struct {
+			Repository *struct {
+				Refs struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						Name   string          `json:"name"`
+						Target githubTagTarget `json:"target"`
+					} `json:"nodes"`
+				} `json:"refs"`
+			} `json:"repository"`
+		}
+		if err := api.graphQL(ctx, query, &data); err != nil {
+			return nil, fmt.Errorf("tags of %s: %w", repo, err)
+		}
+		if data.Repository == nil {
+			return nil, fmt.Errorf("GitHub GraphQL API found no repository %s", repo)
+		}
+		for _, ref := range data.Repository.Refs.Nodes {
+			if ok, _ := path.Match(pattern, ref.Name); !ok {
+				continue
+			}
+			target := ref.Target
+			for target.Target != nil {
+				target = *target.Target
+			}
+			tags = append(tags, Tag{Name: ref.Name, SHA: target.OID, Date: target.CommittedDate})
+		}
+		if !data.Repository.Refs.PageInfo.HasNextPage {
+			return tags, nil
+		}
+		after = graphQLString(data.Repository.Refs.PageInfo.EndCursor)
+	}
+}
+
+// Find the pull request a commit squash- or rebase-merged with the GitHub
+// API of pull requests associated with a commit, and list its original
+// commits
+func (api githubAPI) SquashedCommits(ctx context.Context, repo, sha string) ([]string, error) {
+	number := 0
+	next := fmt.Sprintf("%s/repos/%s/commits/%s/pulls?per_page=100", api.baseURL, repo, sha)
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []struct {
+			Number         int    `json:"number"`
+			MergeCommitSHA string `json:"merge_commit_sha"`
+			MergedAt       string `json:"merged_at"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub pull requests of commit %s: %v", shortSHA(sha), err)
+		}
+		for _, p := range page {
+			if p.MergedAt != "" && p.MergeCommitSHA == sha {
+				number = p.Number
+				return errLastPage
+			}
+		}
+		return nil
+	})
+	if err != nil || number == 0 {
+		return nil, err
+	}
+
+	var originals []string
+	next = fmt.Sprintf("%s/repos/%s/pulls/%d/commits?per_page=100", api.baseURL, repo, number)
+	err = api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []githubCommit
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub commits of pull request #%d: %v", number, err)
+		}
+		for _, c := range page {
+			if c.SHA != sha {
+				originals = append(originals, c.SHA)
+			}
+		}
+		return nil
+	})
+	return originals, err
+}
+
+// Find the merged pull request of a commit with the GitHub API of pull
+// requests associated with a commit
+func (api githubAPI) CommitPull(ctx context.Context, repo, sha string) (*PullRequest, error) {
+	var pull *PullRequest
+	next := fmt.Sprintf("%s/repos/%s/commits/%s/pulls?per_page=100", api.baseURL, repo, sha)
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []githubPull
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub pull requests of commit %s: %v", shortSHA(sha), err)
+		}
+		for _, p := range page {
+			if p.MergedAt != "" {
+				merged := p.pullRequest(repo)
+				pull = &merged
+				return errLastPage
+			}
+		}
+		return nil
+	})
+	return pull, err
+}
+
+// Fetch the change size of a commit from GitHub API, which pages the list of
+// changed files of large commits
+func (api githubAPI) CommitStats(ctx context.Context, repo, sha string) (CommitStats, error) {
+	var stats CommitStats
+	err := api.getPages(ctx, fmt.Sprintf("%s/repos/%s/commits/%s?per_page=100", api.baseURL, repo, sha), repo, func(body io.Reader) error {
+		var commit struct {
+			Stats struct {
+				Additions int `json:"additions"`
+				Deletions int `json:"deletions"`
+			} `json:"stats"`
+			Files []json.RawMessage `json:"files"`
+		}
+		if err := json.NewDecoder(body).Decode(&commit); err != nil {
+			return fmt.Errorf("decoding GitHub commit %s of %s: %v", sha, repo, err)
+		}
+		// Every page repeats the totals.
+		stats.Additions, stats.Deletions = commit.Stats.Additions, commit.Stats.Deletions
+		stats.Files += len(commit.Files)
+		return nil
+	})
+	return stats, err
+}
+
+// Fetch the signature verification of a commit from GitHub API
+func (api githubAPI) Verification(ctx context.Context, repo, sha string) (string, error) {
+	var verification string
+	err := api.getPages(ctx, fmt.Sprintf("%s/repos/%s/commits/%s", api.baseURL, repo, sha), repo, func(body io.Reader) error {
+		var commit githubCommit
+		if err := json.NewDecoder(body).Decode(&commit); err != nil {
+			return fmt.Errorf("decoding GitHub commit %s of %s: %v", sha, repo, err)
+		}
+		verification = commit.verification()
+		return nil
+	})
+	return verification, err
+}
@@ -0,0 +1,150 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGitHubReleasesStopsAtOlderPage(t *testing.T) {
+	pages := []string{
+		`[{"tag_name": "v3", "published_at": "2026-03-10T12:00:00Z"}, {"tag_name": "draft", "draft": true}]`,
+		`[{"tag_name": "v2", "published_at": "2026-03-01T08:00:00Z"}, {"tag_name": "v1", "published_at": "2026-01-05T08:00:00Z"}]`,
+		`[{"tag_name": "v0", "published_at": "2025-12-01T08:00:00Z"}]`,
+		`[{"tag_name": "v-1", "published_at": "2025-11-01T08:00:00Z"}]`,
+	}
+	requested := make([]bool, len(pages))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := 0
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		requested[page] = true
+		if page+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/repos/acme/web/releases?per_page=100&page=%d>; rel="next"`, server.URL, page+1))
+		}
+		fmt.Fprint(w, pages[page])
+	}))
+	defer server.Close()
+
+	releases, err := NewGitHub(server.Client(), server.URL, "token").Releases(context.Background(), "acme/web", "2026-03-01", "2026-03-31")
+	if err != nil {
+		t.Fatalf("Releases: %v", err)
+	}
+	var tags []string
+	for _, release := range releases {
+		tags = append(tags, release.Tag)
+	}
+	if fmt.Sprint(tags) != "[v3 v2]" {
+		t.Errorf("releases = %v, want [v3 v2]", tags)
+	}
+	if !requested[2] || requested[3] {
+		t.Errorf("requested pages %v; want paging to stop after the first page before the period", requested)
+	}
+}
+
+func TestGitHubTagsDatedInOneQueryPerPage(t *testing.T) {
+	pages := []string{
+		`{"data": {"repository": {"refs": {"pageInfo": {"hasNextPage": true, "endCursor": "c1"}, "nodes": [
+			{"name": "v2", "target": {"oid": "tag2", "target": {"oid": "sha2", "committedDate": "2026-03-10T12:00:00Z"}}},
+			{"name": "nightly", "target": {"oid": "sha9", "committedDate": "2026-03-11T00:00:00Z"}}]}}}}`,
+		`{"data": {"repository": {"refs": {"pageInfo": {"hasNextPage": false}, "nodes": [
+			{"name": "v1", "target": {"oid": "sha1", "committedDate": "2026-02-01T08:00:00Z"}}]}}}}`,
+	}
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			t.Errorf("REST request %s, want GraphQL queries only", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		queries = append(queries, body.Query)
+		fmt.Fprint(w, pages[len(queries)-1])
+	}))
+	defer server.Close()
+
+	tags, err := NewGitHub(server.Client(), server.URL, "token").Tags(context.Background(), "acme/web", "v*")
+	if err != nil {
+		t.Fatalf("Tags: %v", err)
+	}
+	// The annotated v2 is dated by the commit it points to.
+	want := []Tag{{Name: "v2", SHA: "sha2", Date: "2026-03-10T12:00:00Z"}, {Name: "v1", SHA: "sha1", Date: "2026-02-01T08:00:00Z"}}
+	if fmt.Sprint(tags) != fmt.Sprint(want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], "after: null") || !strings.Contains(queries[1], `after: "c1"`) {
+		t.Errorf("queries = %q, want two pages, the second after cursor c1", queries)
+	}
+}
+
+// Records the messages of one run
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msg)
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record(msg) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record(msg) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record(msg) }
+
+func TestFetchKeepsRunSettingsApart(t *testing.T) {
+	// 01:30 on April 1st two hours east of UTC
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"tag_name": "v1", "published_at": "2026-03-31T23:30:00Z"}]`)
+	}))
+	defer server.Close()
+	providers := map[string]Provider{ProviderGitHub: NewGitHub(server.Client(), server.URL, "token")}
+	services := []Service{{Service: "web", Repo: "acme/web"}}
+
+	runs := []struct {
+		location *time.Location
+		logger   *recordingLogger
+		releases int
+	}{
+		{time.UTC, &recordingLogger{}, 1},
+		{time.FixedZone("UTC+2", 2*60*60), &recordingLogger{}, 0},
+	}
+	var wg sync.WaitGroup
+	for _, run := range runs {
+		wg.Add(1)
+		go func(location *time.Location, logger Logger, want int) {
+			defer wg.Done()
+			opts := Options{Mode: ModeReleases, StartDate: "2026-03-01", EndDate: "2026-03-31", Location: location, Logger: logger}
+			reports, err := Fetch(context.Background(), providers, services, opts)
+			if err != nil {
+				t.Errorf("Fetch in %s: %v", location, err)
+				return
+			}
+			if got := len(reports[0].Releases); got != want {
+				t.Errorf("releases in %s = %d, want %d", location, got, want)
+			}
+		}(run.location, run.logger, run.releases)
+	}
+	wg.Wait()
+	for _, run := range runs {
+		requests := 0
+		for _, msg := range run.logger.messages {
+			if msg == "API request" {
+				requests++
+			}
+		}
+		if requests != 1 {
+			t.Errorf("logger of the run in %s saw %d API requests, want 1", run.location, requests)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package releasereport
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Installation tokens are renewed this long before they expire, so no
+// request goes out with a token about to lapse
+const appTokenMargin = 5 * time.Minute
+
+// Check the GitHub App settings before any report is generated
+func (c GitHubAppConfig) validate() error {
+	if c.AppID <= 0 {
+		return errors.New("github_app.app_id is required")
+	}
+	if c.InstallationID <= 0 {
+		return errors.New("github_app.installation_id is required")
+	}
+	if os.Getenv("GITHUB_APP_PRIVATE_KEY") == "" && c.PrivateKeyFile == "" {
+		return errors.New("github_app: no private key found: set GITHUB_APP_PRIVATE_KEY or github_app.private_key_file")
+	}
+	return nil
+}
+
+// Load the private key from GITHUB_APP_PRIVATE_KEY, else the key file
+func (c GitHubAppConfig) privateKey() (*rsa.PrivateKey, error) {
+	data := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if len(data) == 0 {
+		var err error
+		if data, err = ioutil.ReadFile(c.PrivateKeyFile); err != nil {
+			return nil, fmt.Errorf("github_app: %w", err)
+		}
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("github_app: private key is not PEM encoded")
+	}
+	// GitHub hands out PKCS #1 keys; converted ones are often PKCS #8.
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("github_app: parsing private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github_app: private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Installation access tokens of a GitHub App, renewed as they expire. Shared
+// by every copy of the githubAPI using it.
+type githubAppAuth struct {
+	client         *http.Client
+	baseURL        string
+	appID          int64
+	installationID int64
+	key            *rsa.PrivateKey
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Set up GitHub App authentication against the API at baseURL
+func newGitHubAppAuth(client *http.Client, baseURL string, config GitHubAppConfig) (*githubAppAuth, error) {
+	key, err := config.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &githubAppAuth{client: client, baseURL: baseURL, appID: config.AppID, installationID: config.InstallationID, key: key}, nil
+}
+
+// GitHub provider authenticating as the installation of a GitHub App, with
+// short-lived installation tokens renewed as needed
+func NewGitHubApp(client *http.Client, baseURL string, config GitHubAppConfig) (Provider, error) {
+	api := githubAPI{client: defaultClient(client), baseURL: strings.TrimSuffix(baseURL, "/")}
+	app, err := newGitHubAppAuth(api.client, api.baseURL, config)
+	if err != nil {
+		return nil, err
+	}
+	api.app = app
+	return api, nil
+}
+
+// JSON Web Token signed with the app's key, which authenticates the app
+// itself for up to 10 minutes
+func (a *githubAppAuth) jwt(now time.Time) (string, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(), // allow for clock drift
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := encode([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + encode(signature), nil
+}
+
+// Current installation access token, exchanging a new JWT for a fresh one
+// when it is about to expire
+func (a *githubAppAuth) installationToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Until(a.expires) > appTokenMargin {
+		return a.token, nil
+	}
+	jwt, err := a.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	url := fmt.Sprintf("%s/app/installations/%d/access_tokens", a.baseURL, a.installationID)
+	resp, err := apiSend(ctx, a.client, "POST", url, "Authorization", "Bearer "+jwt, struct{}{})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("GitHub rejected the app credentials (%w, %s); check github_app and its private key", errUnauthorized, resp.Status)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("GitHub API returned %s for the app installation token", resp.Status)
+	}
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding GitHub app installation token: %v", err)
+	}
+	a.token, a.expires = token.Token, token.ExpiresAt
+	return a.token, nil
+}
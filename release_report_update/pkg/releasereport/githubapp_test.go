@@ -0,0 +1,181 @@
+package releasereport
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testAppKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// Check the signature of a JWT and return its claims
+func verifyJWT(t *testing.T, token string, key *rsa.PublicKey) map[string]int64 {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWT %q does not have three parts", token)
+	}
+	decode := func(part string) []byte {
+		data, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			t.Fatalf("JWT part %q: %v", part, err)
+		}
+		return data
+	}
+	var header map[string]string
+	if err := json.Unmarshal(decode(parts[0]), &header); err != nil || header["alg"] != "RS256" || header["typ"] != "JWT" {
+		t.Fatalf("JWT header %s, want RS256 JWT (%v)", decode(parts[0]), err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], decode(parts[2])); err != nil {
+		t.Fatalf("JWT signature: %v", err)
+	}
+	var claims map[string]int64
+	if err := json.Unmarshal(decode(parts[1]), &claims); err != nil {
+		t.Fatalf("JWT claims: %v", err)
+	}
+	return claims
+}
+
+func TestGitHubAppJWT(t *testing.T) {
+	key := testAppKey(t)
+	app := &githubAppAuth{appID: 1234, key: key}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	token, err := app.jwt(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := verifyJWT(t, token, &key.PublicKey)
+	if claims["iss"] != 1234 {
+		t.Errorf("iss = %d, want the app ID 1234", claims["iss"])
+	}
+	if iat := claims["iat"]; iat >= now.Unix() || iat < now.Add(-2*time.Minute).Unix() {
+		t.Errorf("iat = %d, want it backdated by up to 2 minutes from %d for clock drift", iat, now.Unix())
+	}
+	// GitHub rejects tokens that live longer than 10 minutes.
+	if exp := claims["exp"]; exp <= now.Unix() || exp-claims["iat"] > 10*60 {
+		t.Errorf("exp = %d, want it in the future and at most 10 minutes after iat %d", exp, claims["iat"])
+	}
+}
+
+func TestGitHubAppInstallationToken(t *testing.T) {
+	key := testAppKey(t)
+	var requests int32
+	var expiresIn, authorization atomic.Value
+	expiresIn.Store(time.Hour)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			t.Errorf("request %s %s, want POST /app/installations/42/access_tokens", r.Method, r.URL.Path)
+		}
+		authorization.Store(r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, n, time.Now().Add(expiresIn.Load().(time.Duration)).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+	app := &githubAppAuth{client: server.Client(), baseURL: server.URL, appID: 7, installationID: 42, key: key}
+	ctx := context.Background()
+
+	token := func() string {
+		t.Helper()
+		token, err := app.installationToken(ctx)
+		if err != nil {
+			t.Fatalf("installationToken: %v", err)
+		}
+		// The app authenticates with a JWT it signs itself.
+		jwt := strings.TrimPrefix(authorization.Load().(string), "Bearer ")
+		if claims := verifyJWT(t, jwt, &key.PublicKey); claims["iss"] != 7 {
+			t.Errorf("JWT iss = %d, want 7", claims["iss"])
+		}
+		return token
+	}
+	if got := token(); got != "ghs_1" {
+		t.Fatalf("token = %q, want ghs_1", got)
+	}
+	if got := token(); got != "ghs_1" || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("token = %q after %d requests, want the cached ghs_1 after 1", got, requests)
+	}
+
+	// A token within appTokenMargin of its expiry is renewed.
+	expiresIn.Store(appTokenMargin / 2)
+	app.expires = time.Now().Add(appTokenMargin - time.Second)
+	if got := token(); got != "ghs_2" {
+		t.Fatalf("token = %q, want the renewed ghs_2", got)
+	}
+	if got := token(); got != "ghs_3" || atomic.LoadInt32(&requests) != 3 {
+		t.Fatalf("token = %q after %d requests, want ghs_3 after 3: a short-lived token is not cached", got, requests)
+	}
+}
+
+func TestGitHubAppInstallationTokenRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"A JSON web token could not be decoded"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	app := &githubAppAuth{client: server.Client(), baseURL: server.URL, appID: 7, installationID: 42, key: testAppKey(t)}
+	if _, err := app.installationToken(context.Background()); !errors.Is(err, errUnauthorized) {
+		t.Errorf("installationToken error = %v, want errUnauthorized", err)
+	}
+}
+
+func TestGitHubAppCacheOutlivesTokens(t *testing.T) {
+	key := testAppKey(t)
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})))
+	var tokens, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/app/installations/42/access_tokens" {
+			n := atomic.AddInt32(&tokens, 1)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, n, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[{"tag_name": "v1", "published_at": "2026-03-10T12:00:00Z"}]`)
+	}))
+	defer server.Close()
+	ctx := Options{CacheDir: t.TempDir(), Location: time.UTC}.Context(context.Background())
+
+	// Every run sets up its own provider and so mints its own token.
+	for run := 1; run <= 2; run++ {
+		provider, err := NewGitHubApp(server.Client(), server.URL, GitHubAppConfig{AppID: 7, InstallationID: 42})
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases, err := provider.Releases(ctx, "acme/web", "2026-03-01", "2026-03-31")
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if len(releases) != 1 {
+			t.Errorf("run %d: %d releases, want the cached 1", run, len(releases))
+		}
+	}
+	if tokens != 2 || notModified != 1 {
+		t.Errorf("%d tokens and %d revalidated responses, want 2 and 1: the cache is keyed on the app installation, not its token", tokens, notModified)
+	}
+}
@@ -0,0 +1,506 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Default GitLab API root
+const DefaultGitLabBaseURL = "https://gitlab.com/api/v4"
+
+// Connection settings of the GitLab (self-managed) API
+type gitlabAPI struct {
+	client  *http.Client
+	baseURL string // without a trailing slash
+	token   string
+}
+
+// GitLab provider authenticating with a token. baseURL is the API root, e.g.
+// DefaultGitLabBaseURL; a nil client gets the default timeout.
+func NewGitLab(client *http.Client, baseURL, token string) Provider {
+	return gitlabAPI{client: defaultClient(client), baseURL: strings.TrimSuffix(baseURL, "/"), token: token}
+}
+
+// Commit as returned by the GitLab repository commits API
+type gitlabCommit struct {
+	ID          string   `json:"id"`
+	Message     string   `json:"message"`
+	AuthorName  string   `json:"author_name"`
+	AuthorEmail string   `json:"author_email"`
+	AuthoredAt  string   `json:"authored_date"`
+	WebURL      string   `json:"web_url"`
+	ParentIDs   []string `json:"parent_ids"`
+}
+
+// Provider-neutral form of a GitLab commit
+func (c gitlabCommit) normalize() Commit {
+	return Commit{
+		SHA:         c.ID,
+		Message:     c.Message,
+		Author:      c.AuthorName,
+		AuthorEmail: c.AuthorEmail,
+		URL:         c.WebURL,
+		Date:        c.AuthoredAt,
+		Merge:       len(c.ParentIDs) > 1,
+	}
+}
+
+// Fetch commits from GitLab API, following pagination. repo is the project
+// path (group/subgroup/project) or its numeric ID.
+func (api gitlabAPI) Commits(ctx context.Context, repo, branch, since, until, path string) ([]Commit, error) {
+	loc := settingsOf(ctx).location
+	query := url.Values{"ref_name": {branch}, "since": {periodStart(since, loc)}, "until": {periodEnd(until, loc)}, "per_page": {"100"}}
+	if path != "" {
+		query.Set("path", path)
+	}
+	next := fmt.Sprintf("%s/projects/%s/repository/commits?%s", api.baseURL, url.PathEscape(repo), query.Encode())
+	var commits []Commit
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []gitlabCommit
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab commits of %s: %v", repo, err)
+		}
+		for _, c := range page {
+			commits = append(commits, c.normalize())
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// GET next and every following page, passing each page body to page, until
+// page returns errLastPage. Failures name repo.
+func (api gitlabAPI) getPages(ctx context.Context, next, repo string, page func(body io.Reader) error) error {
+	for next != "" {
+		resp, err := apiGet(ctx, api.client, next, "PRIVATE-TOKEN", api.token)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			resp.Body.Close()
+			return fmt.Errorf("GitLab rejected the token (%w); check -gitlab-token or GITLAB_TOKEN", errUnauthorized)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("GitLab API returned %s for %s", resp.Status, repo)
+		}
+		err = page(resp.Body)
+		resp.Body.Close()
+		if err == errLastPage {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		next = nextPageURL(resp)
+	}
+	return nil
+}
+
+// Release as returned by the GitLab releases API
+type gitlabRelease struct {
+	TagName         string `json:"tag_name"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	ReleasedAt      string `json:"released_at"`
+	UpcomingRelease bool   `json:"upcoming_release"`
+	Links           struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+// Fetch the releases published in the period from GitLab API
+func (api gitlabAPI) Releases(ctx context.Context, repo, since, until string) ([]Release, error) {
+	loc := settingsOf(ctx).location
+	next := fmt.Sprintf("%s/projects/%s/releases?per_page=100", api.baseURL, url.PathEscape(repo))
+	var releases []Release
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []gitlabRelease
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab releases of %s: %v", repo, err)
+		}
+		for _, r := range page {
+			if r.UpcomingRelease || !inPeriod(r.ReleasedAt, since, until, loc) {
+				continue
+			}
+			release := Release{Tag: r.TagName, Name: r.Name, URL: r.Links.Self, Date: r.ReleasedAt, Notes: r.Description}
+			for _, a := range r.Assets.Links {
+				release.Assets = append(release.Assets, Asset{Name: a.Name, URL: a.URL})
+			}
+			releases = append(releases, release)
+		}
+		return nil
+	})
+	return releases, err
+}
+
+// Fetch the commits between two refs from GitLab compare API
+func (api gitlabAPI) Compare(ctx context.Context, repo, base, head string) ([]Commit, error) {
+	query := url.Values{"from": {base}, "to": {head}}
+	next := fmt.Sprintf("%s/projects/%s/repository/compare?%s", api.baseURL, url.PathEscape(repo), query.Encode())
+	var commits []Commit
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page struct {
+			Commits []gitlabCommit `json:"commits"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab comparison of %s: %v", repo, err)
+		}
+		for _, c := range page.Commits {
+			commits = append(commits, c.normalize())
+		}
+		return nil
+	})
+	// GitLab lists compared commits oldest first.
+	reverseCommits(commits)
+	return commits, err
+}
+
+// Merge request as returned by the GitLab merge requests API
+type gitlabMergeRequest struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	WebURL      string   `json:"web_url"`
+	MergedAt    string   `json:"merged_at"`
+	Labels      []string `json:"labels"`
+	Author      struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	Reviewers []struct {
+		Username string `json:"username"`
+	} `json:"reviewers"`
+}
+
+// Merge request of the report
+func (mr gitlabMergeRequest) pullRequest(repo string) PullRequest {
+	pull := PullRequest{Number: mr.IID, Title: mr.Title, Author: mr.Author.Username, URL: mr.WebURL, MergedAt: mr.MergedAt, Labels: mr.Labels, Description: mr.Description}
+	for _, reviewer := range mr.Reviewers {
+		pull.Reviewers = appendUnique(pull.Reviewers, reviewer.Username)
+	}
+	projectURL := strings.TrimSuffix(mr.WebURL, fmt.Sprintf("/-/merge_requests/%d", mr.IID))
+	pull.Issues = linkedIssues(mr.Description, func(issueRepo, number string) string {
+		if issueRepo == "" {
+			return projectURL + "/-/issues/" + number
+		}
+		return strings.TrimSuffix(projectURL, "/"+repo) + "/" + issueRepo + "/-/issues/" + number
+	})
+	return pull
+}
+
+// Fetch the merge requests merged in the period from GitLab API
+func (api gitlabAPI) PullRequests(ctx context.Context, repo, since, until string) ([]PullRequest, error) {
+	loc := settingsOf(ctx).location
+	// A merge request merged in the period was last updated in it or later.
+	query := url.Values{"state": {"merged"}, "updated_after": {periodStart(since, loc)}, "per_page": {"100"}}
+	next := fmt.Sprintf("%s/projects/%s/merge_requests?%s", api.baseURL, url.PathEscape(repo), query.Encode())
+	var pulls []PullRequest
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []gitlabMergeRequest
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab merge requests of %s: %v", repo, err)
+		}
+		for _, mr := range page {
+			if !inPeriod(mr.MergedAt, since, until, loc) {
+				continue
+			}
+			pulls = append(pulls, mr.pullRequest(repo))
+		}
+		return nil
+	})
+	sort.SliceStable(pulls, func(i, j int) bool { return pulls[i].MergedAt > pulls[j].MergedAt })
+	return pulls, err
+}
+
+// Fetch the issues closed in the period by merged merge requests from GitLab
+// API, which lists the issues each merge request closes
+func (api gitlabAPI) ClosedIssues(ctx context.Context, repo, since, until string) ([]ClosedIssue, error) {
+	pulls, err := api.PullRequests(ctx, repo, since, until)
+	if err != nil {
+		return nil, err
+	}
+	var issues []ClosedIssue
+	seen := make(map[int]bool) // issues closed by several merge requests
+	for _, pull := range pulls {
+		next := fmt.Sprintf("%s/projects/%s/merge_requests/%d/closes_issues?per_page=100", api.baseURL, url.PathEscape(repo), pull.Number)
+		err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+			var page []struct {
+				IID      int    `json:"iid"`
+				Title    string `json:"title"`
+				WebURL   string `json:"web_url"`
+				State    string `json:"state"`
+				ClosedAt string `json:"closed_at"`
+			}
+			if err := json.NewDecoder(body).Decode(&page); err != nil {
+				return fmt.Errorf("decoding GitLab issues closed by %s!%d: %v", repo, pull.Number, err)
+			}
+			for _, issue := range page {
+				if issue.State == "closed" && !seen[issue.IID] {
+					seen[issue.IID] = true
+					issues = append(issues, ClosedIssue{Number: issue.IID, Title: issue.Title, URL: issue.WebURL, ClosedAt: issue.ClosedAt, Pull: pull.Number, PullURL: pull.URL})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].ClosedAt > issues[j].ClosedAt })
+	return issues, nil
+}
+
+// Check with GitLab API whether an author committed to repo before date
+func (api gitlabAPI) CommittedBefore(ctx context.Context, repo, email, date string) (bool, error) {
+	loc := settingsOf(ctx).location
+	query := url.Values{"author": {email}, "until": {periodStart(date, loc)}, "per_page": {"1"}}
+	found := false
+	err := api.getPages(ctx, fmt.Sprintf("%s/projects/%s/repository/commits?%s", api.baseURL, url.PathEscape(repo), query.Encode()), repo, func(body io.Reader) error {
+		var page []gitlabCommit
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab commits of %s: %v", repo, err)
+		}
+		found = len(page) > 0
+		return errLastPage
+	})
+	return found, err
+}
+
+// Fetch the files changed by a commit from GitLab API
+func (api gitlabAPI) CommitFiles(ctx context.Context, repo, sha string) ([]string, error) {
+	var files []string
+	next := fmt.Sprintf("%s/projects/%s/repository/commits/%s/diff?per_page=100", api.baseURL, url.PathEscape(repo), sha)
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var diffs []struct {
+			OldPath string `json:"old_path"`
+			NewPath string `json:"new_path"`
+		}
+		if err := json.NewDecoder(body).Decode(&diffs); err != nil {
+			return fmt.Errorf("decoding GitLab diff of %s in %s: %v", sha, repo, err)
+		}
+		for _, d := range diffs {
+			files = appendUnique(files, d.NewPath)
+			files = appendUnique(files, d.OldPath)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Fetch the successful deployments to an environment from GitLab
+// deployments API
+func (api gitlabAPI) Deployments(ctx context.Context, repo, environment, since, until string) ([]Deployment, error) {
+	loc := settingsOf(ctx).location
+	query := url.Values{"environment": {environment}, "status": {"success"}, "order_by": {"finished_at"}, "sort": {"desc"}, "per_page": {"100"}}
+	next := fmt.Sprintf("%s/projects/%s/deployments?%s", api.baseURL, url.PathEscape(repo), query.Encode())
+	var deployments []Deployment
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []struct {
+			SHA        string `json:"sha"`
+			Ref        string `json:"ref"`
+			FinishedAt string `json:"finished_at"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab deployments of %s: %v", repo, err)
+		}
+		for _, d := range page {
+			if d.FinishedAt == "" || dateOf(d.FinishedAt, loc) > until {
+				continue
+			}
+			deployments = append(deployments, Deployment{SHA: d.SHA, Ref: d.Ref, Environment: environment, Date: d.FinishedAt})
+			if dateOf(d.FinishedAt, loc) < since {
+				return errLastPage
+			}
+		}
+		return nil
+	})
+	return deployments, err
+}
+
+// Fetch the branch names from GitLab API
+func (api gitlabAPI) Branches(ctx context.Context, repo string) ([]string, error) {
+	var branches []string
+	next := fmt.Sprintf("%s/projects/%s/repository/branches?per_page=100", api.baseURL, url.PathEscape(repo))
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab branches of %s: %v", repo, err)
+		}
+		for _, b := range page {
+			branches = append(branches, b.Name)
+		}
+		return nil
+	})
+	return branches, err
+}
+
+// Fetch the matching tags from GitLab API
+func (api gitlabAPI) Tags(ctx context.Context, repo, pattern string) ([]Tag, error) {
+	var tags []Tag
+	next := fmt.Sprintf("%s/projects/%s/repository/tags?per_page=100", api.baseURL, url.PathEscape(repo))
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				ID            string `json:"id"`
+				CommittedDate string `json:"committed_date"`
+			} `json:"commit"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab tags of %s: %v", repo, err)
+		}
+		for _, t := range page {
+			if ok, _ := path.Match(pattern, t.Name); ok {
+				tags = append(tags, Tag{Name: t.Name, SHA: t.Commit.ID, Date: t.Commit.CommittedDate})
+			}
+		}
+		return nil
+	})
+	return tags, err
+}
+
+// Find the merge request a commit squash- or rebase-merged with the GitLab
+// API of merge requests associated with a commit, and list its original
+// commits
+func (api gitlabAPI) SquashedCommits(ctx context.Context, repo, sha string) ([]string, error) {
+	iid := 0
+	next := fmt.Sprintf("%s/projects/%s/repository/commits/%s/merge_requests?per_page=100", api.baseURL, url.PathEscape(repo), sha)
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []struct {
+			IID             int    `json:"iid"`
+			State           string `json:"state"`
+			MergeCommitSHA  string `json:"merge_commit_sha"`
+			SquashCommitSHA string `json:"squash_commit_sha"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab merge requests of commit %s: %v", shortSHA(sha), err)
+		}
+		for _, mr := range page {
+			if mr.State == "merged" && (mr.SquashCommitSHA == sha || mr.MergeCommitSHA == sha) {
+				iid = mr.IID
+				return errLastPage
+			}
+		}
+		return nil
+	})
+	if err != nil || iid == 0 {
+		return nil, err
+	}
+
+	var originals []string
+	next = fmt.Sprintf("%s/projects/%s/merge_requests/%d/commits?per_page=100", api.baseURL, url.PathEscape(repo), iid)
+	err = api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []gitlabCommit
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab commits of merge request !%d: %v", iid, err)
+		}
+		for _, c := range page {
+			if c.ID != sha {
+				originals = append(originals, c.ID)
+			}
+		}
+		return nil
+	})
+	return originals, err
+}
+
+// Fetch the change size of a commit from GitLab API: the line counts of the
+// commit and the files of its diff
+func (api gitlabAPI) CommitStats(ctx context.Context, repo, sha string) (CommitStats, error) {
+	var stats CommitStats
+	err := api.getPages(ctx, fmt.Sprintf("%s/projects/%s/repository/commits/%s", api.baseURL, url.PathEscape(repo), sha), repo, func(body io.Reader) error {
+		var commit struct {
+			Stats struct {
+				Additions int `json:"additions"`
+				Deletions int `json:"deletions"`
+			} `json:"stats"`
+		}
+		if err := json.NewDecoder(body).Decode(&commit); err != nil {
+			return fmt.Errorf("decoding GitLab commit %s of %s: %v", sha, repo, err)
+		}
+		stats.Additions, stats.Deletions = commit.Stats.Additions, commit.Stats.Deletions
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+	next := fmt.Sprintf("%s/projects/%s/repository/commits/%s/diff?per_page=100", api.baseURL, url.PathEscape(repo), sha)
+	err = api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var diffs []json.RawMessage
+		if err := json.NewDecoder(body).Decode(&diffs); err != nil {
+			return fmt.Errorf("decoding GitLab diff of %s in %s: %v", sha, repo, err)
+		}
+		stats.Files += len(diffs)
+		return nil
+	})
+	return stats, err
+}
+
+// Find the merged merge request of a commit with the GitLab API of merge
+// requests associated with a commit
+func (api gitlabAPI) CommitPull(ctx context.Context, repo, sha string) (*PullRequest, error) {
+	var pull *PullRequest
+	next := fmt.Sprintf("%s/projects/%s/repository/commits/%s/merge_requests?per_page=100", api.baseURL, url.PathEscape(repo), sha)
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []struct {
+			gitlabMergeRequest
+			State string `json:"state"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitLab merge requests of commit %s: %v", shortSHA(sha), err)
+		}
+		for _, mr := range page {
+			if mr.State == "merged" {
+				merged := mr.pullRequest(repo)
+				pull = &merged
+				return errLastPage
+			}
+		}
+		return nil
+	})
+	return pull, err
+}
+
+// Fetch the signature verification of a commit from GitLab API, which
+// answers 404 for unsigned commits
+func (api gitlabAPI) Verification(ctx context.Context, repo, sha string) (string, error) {
+	resp, err := apiGet(ctx, api.client, fmt.Sprintf("%s/projects/%s/repository/commits/%s/signature", api.baseURL, url.PathEscape(repo), sha), "PRIVATE-TOKEN", api.token)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "unsigned", nil
+	case http.StatusUnauthorized:
+		return "", fmt.Errorf("GitLab rejected the token (%w); check -gitlab-token or GITLAB_TOKEN", errUnauthorized)
+	default:
+		return "", fmt.Errorf("GitLab API returned %s for %s", resp.Status, repo)
+	}
+	var signature struct {
+		VerificationStatus string `json:"verification_status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&signature); err != nil {
+		return "", fmt.Errorf("decoding GitLab signature of commit %s of %s: %v", sha, repo, err)
+	}
+	if signature.VerificationStatus == "verified" || signature.VerificationStatus == "verified_system" {
+		return verifiedSignature, nil
+	}
+	return signature.VerificationStatus, nil
+}
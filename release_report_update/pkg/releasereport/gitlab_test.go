@@ -0,0 +1,60 @@
+package releasereport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitLabCommitsFollowsPages(t *testing.T) {
+	pages := []string{
+		`[{"id": "c2", "message": "feat: search", "author_name": "Ada", "author_email": "ada@example.com", "authored_date": "2026-03-10T12:00:00Z", "web_url": "https://gitlab.example/c2", "parent_ids": ["c1", "b1"]}]`,
+		`[{"id": "c1", "message": "fix: typo", "author_name": "Bob", "authored_date": "2026-03-02T08:00:00Z", "parent_ids": ["c0"]}]`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fweb/repository/commits" {
+			t.Errorf("path = %s", r.URL.EscapedPath())
+		}
+		if token := r.Header.Get("PRIVATE-TOKEN"); token != "token" {
+			t.Errorf("PRIVATE-TOKEN = %q", token)
+		}
+		query := r.URL.Query()
+		if query.Get("ref_name") != "main" || query.Get("path") != "web/" {
+			t.Errorf("query = %s", r.URL.RawQuery)
+		}
+		page := 0
+		fmt.Sscan(query.Get("page"), &page)
+		if page+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v4/projects/group%%2Fweb/repository/commits?ref_name=main&path=web%%2F&page=%d>; rel="next"`, server.URL, page+1))
+		}
+		fmt.Fprint(w, pages[page])
+	}))
+	defer server.Close()
+
+	commits, err := NewGitLab(server.Client(), server.URL+"/api/v4/", "token").Commits(context.Background(), "group/web", "main", "2026-03-01", "2026-03-31", "web/")
+	if err != nil {
+		t.Fatalf("Commits: %v", err)
+	}
+	want := []Commit{
+		{SHA: "c2", Message: "feat: search", Author: "Ada", AuthorEmail: "ada@example.com", URL: "https://gitlab.example/c2", Date: "2026-03-10T12:00:00Z", Merge: true},
+		{SHA: "c1", Message: "fix: typo", Author: "Bob", Date: "2026-03-02T08:00:00Z"},
+	}
+	if fmt.Sprint(commits) != fmt.Sprint(want) {
+		t.Errorf("commits = %+v, want %+v", commits, want)
+	}
+}
+
+func TestGitLabRejectedToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewGitLab(server.Client(), server.URL, "bad").Commits(context.Background(), "group/web", "main", "2026-03-01", "2026-03-31", "")
+	if !fatal(context.Background(), err) {
+		t.Errorf("Commits with a rejected token = %v, want an error that aborts the report", err)
+	}
+}
@@ -0,0 +1,475 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repository fields per GraphQL query; each brings up to 100 commits, pull
+// requests or deployments, well within GitHub's node limits
+const graphQLBatch = 10
+
+// Provider that fetches the data of many services in a few requests before
+// the per-service calls, which it then answers from memory
+type batchPrefetcher interface {
+	prefetch(ctx context.Context, services []Service, opts Options) error
+}
+
+// GitHub provider for -graphql: fetches the commits, pull requests and
+// deployments of many repositories per GraphQL query (see prefetch), and
+// everything else, including what a query could not fetch completely, with
+// the REST API
+type githubGraphQL struct {
+	githubAPI
+
+	mu          sync.Mutex
+	commits     map[string][]Commit
+	pulls       map[string][]PullRequest
+	deployments map[string][]Deployment
+}
+
+// GraphQL provider next to the REST API of api
+func newGitHubGraphQL(api githubAPI) *githubGraphQL {
+	return &githubGraphQL{githubAPI: api}
+}
+
+// GraphQL endpoint next to the REST API
+func (api githubAPI) graphQLEndpoint() string {
+	if strings.HasSuffix(api.baseURL, "/api/v3") {
+		// GitHub Enterprise Server
+		return strings.TrimSuffix(api.baseURL, "/v3") + "/graphql"
+	}
+	return api.baseURL + "/graphql"
+}
+
+// Run a GraphQL query and decode its data into data. Errors that still
+// return data, such as one missing repository of several, leave the
+// affected fields null.
+func (api githubAPI) graphQL(ctx context.Context, query string, data interface{}) error {
+	authorization, err := api.authorization(ctx)
+	if err != nil {
+		return err
+	}
+	resp, err := apiSend(ctx, api.client, "POST", api.graphQLEndpoint(), "Authorization", authorization, map[string]string{"query": query})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("GitHub rejected the token (%w); check -token, GITHUB_TOKEN or `gh auth status`", errUnauthorized)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub GraphQL API returned %s", resp.Status)
+	}
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding GitHub GraphQL response: %v", err)
+	}
+	if (len(result.Data) == 0 || string(result.Data) == "null") && len(result.Errors) > 0 {
+		return errors.New("GitHub GraphQL API: " + result.Errors[0].Message)
+	}
+	if err := json.Unmarshal(result.Data, data); err != nil {
+		return fmt.Errorf("decoding GitHub GraphQL response: %v", err)
+	}
+	return nil
+}
+
+// Provider batching GitHub queries through GraphQL (see -graphql) when
+// provider comes from NewGitHub or NewGitHubApp; others are returned as is
+func WithGraphQL(provider Provider) Provider {
+	if api, ok := provider.(githubAPI); ok {
+		return newGitHubGraphQL(api)
+	}
+	return provider
+}
+
+// REST API of a GitHub provider, for calls GraphQL does not cover
+func githubREST(provider Provider) (githubAPI, bool) {
+	switch api := provider.(type) {
+	case githubAPI:
+		return api, true
+	case *githubGraphQL:
+		return api.githubAPI, true
+	}
+	return githubAPI{}, false
+}
+
+// Key of prefetched data
+func batchKey(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
+
+// Commits from the prefetched ones when there are, else from REST
+func (g *githubGraphQL) Commits(ctx context.Context, repo, branch, since, until, path string) ([]Commit, error) {
+	g.mu.Lock()
+	commits, ok := g.commits[batchKey(repo, branch, since, until)]
+	g.mu.Unlock()
+	if !ok || path != "" {
+		return g.githubAPI.Commits(ctx, repo, branch, since, until, path)
+	}
+	// Callers change the commits they get.
+	return append([]Commit(nil), commits...), nil
+}
+
+// Pull requests from the prefetched ones when there are, else from REST
+func (g *githubGraphQL) PullRequests(ctx context.Context, repo, since, until string) ([]PullRequest, error) {
+	g.mu.Lock()
+	pulls, ok := g.pulls[batchKey(repo, since, until)]
+	g.mu.Unlock()
+	if !ok {
+		return g.githubAPI.PullRequests(ctx, repo, since, until)
+	}
+	return append([]PullRequest(nil), pulls...), nil
+}
+
+// Deployments from the prefetched ones when there are, else from REST
+func (g *githubGraphQL) Deployments(ctx context.Context, repo, environment, since, until string) ([]Deployment, error) {
+	g.mu.Lock()
+	deployments, ok := g.deployments[batchKey(repo, environment, since, until)]
+	g.mu.Unlock()
+	if !ok {
+		return g.githubAPI.Deployments(ctx, repo, environment, since, until)
+	}
+	return append([]Deployment(nil), deployments...), nil
+}
+
+// One repository field of a batched query and what to do with its result
+type graphQLField struct {
+	repo      string
+	selection string                      // inside repository { }
+	store     func(json.RawMessage) error // called with the repository object
+}
+
+// Fetch the commits (with their deployments) or pull requests of every
+// GitHub service in batched GraphQL queries. Data of repositories with more
+// than a page, or that could not be queried, is left to the REST API.
+func (g *githubGraphQL) prefetch(ctx context.Context, services []Service, opts Options) error {
+	g.mu.Lock()
+	g.commits = make(map[string][]Commit)
+	g.pulls = make(map[string][]PullRequest)
+	g.deployments = make(map[string][]Deployment)
+	g.mu.Unlock()
+
+	loc := opts.location()
+	var fields []graphQLField
+	for _, service := range services {
+		if service.provider() != ProviderGitHub || service.RefRange(opts.Compare) != "" {
+			continue
+		}
+		switch opts.Mode {
+		case ModeCommits:
+			if len(service.Paths) == 0 {
+				fields = append(fields, g.commitsField(service.Repo, service.branch(), opts.StartDate, opts.EndDate, loc))
+			}
+			for _, environment := range deploymentEnvironments(service, opts) {
+				fields = append(fields, g.deploymentsField(service.Repo, environment, opts.StartDate, opts.EndDate, loc))
+			}
+		case ModePulls:
+			fields = append(fields, g.pullsField(service.Repo, opts.StartDate, opts.EndDate, loc))
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	requests := (len(fields) + graphQLBatch - 1) / graphQLBatch
+	opts.logger().Info("batching repository lookups", "lookups", len(fields), "graphql_requests", requests)
+	for start := 0; start < len(fields); start += graphQLBatch {
+		end := start + graphQLBatch
+		if end > len(fields) {
+			end = len(fields)
+		}
+		if err := g.query(ctx, fields[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Environments whose deployments a commits report of service looks up: those
+// not mapped to refs, and the -dora one
+func deploymentEnvironments(service Service, opts Options) []string {
+	var environments []string
+	if len(opts.Environments) > 0 || len(service.Environments) > 0 {
+		for _, environment := range serviceEnvironments(service, opts.Environments) {
+			if _, mapped := service.Environments[environment]; !mapped {
+				environments = append(environments, environment)
+			}
+		}
+	}
+	if opts.DORAEnvironment != "" && !contains(environments, opts.DORAEnvironment) {
+		environments = append(environments, opts.DORAEnvironment)
+	}
+	return environments
+}
+
+// Run one batched query and store the result of each field. Fields of
+// missing repositories or branches come back empty and are skipped.
+func (g *githubGraphQL) query(ctx context.Context, fields []graphQLField) error {
+	var query strings.Builder
+	query.WriteString("query {")
+	for i, field := range fields {
+		owner, name := splitRepo(field.repo)
+		fmt.Fprintf(&query, "\n  f%d: repository(owner: %s, name: %s) {%s}", i, graphQLString(owner), graphQLString(name), field.selection)
+	}
+	query.WriteString("\n}")
+
+	var result map[string]json.RawMessage
+	if err := g.graphQL(ctx, query.String(), &result); err != nil {
+		return err
+	}
+	for i, field := range fields {
+		data := result[fmt.Sprintf("f%d", i)]
+		if len(data) == 0 || string(data) == "null" {
+			continue
+		}
+		if err := field.store(data); err != nil {
+			return fmt.Errorf("decoding GitHub GraphQL data of %s: %v", field.repo, err)
+		}
+	}
+	return nil
+}
+
+// Owner and name of an owner/repo repository
+func splitRepo(repo string) (owner, name string) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) < 2 {
+		return repo, ""
+	}
+	return parts[0], parts[1]
+}
+
+// GraphQL string literal, which JSON string syntax is valid for
+func graphQLString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// Commit as returned by the GitHub GraphQL API
+type graphQLCommit struct {
+	OID     string `json:"oid"`
+	URL     string `json:"url"`
+	Message string `json:"message"`
+	Author  struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Date  string `json:"date"`
+	} `json:"author"`
+	Parents struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"parents"`
+	Signature *struct {
+		IsValid bool   `json:"isValid"`
+		State   string `json:"state"`
+	} `json:"signature"`
+}
+
+// Provider-neutral form of a GraphQL commit, with the verification in the
+// terms of the REST API
+func (c graphQLCommit) normalize() Commit {
+	commit := Commit{
+		SHA:          c.OID,
+		Message:      c.Message,
+		Author:       c.Author.Name,
+		AuthorEmail:  c.Author.Email,
+		URL:          c.URL,
+		Date:         c.Author.Date,
+		Merge:        c.Parents.TotalCount > 1,
+		Verification: "unsigned",
+	}
+	if c.Signature != nil {
+		commit.Verification = strings.ToLower(c.Signature.State)
+		if c.Signature.IsValid {
+			commit.Verification = verifiedSignature
+		}
+	}
+	return commit
+}
+
+// Field of the commits on branch in the period
+func (g *githubGraphQL) commitsField(repo, branch, since, until string, loc *time.Location) graphQLField {
+	selection := fmt.Sprintf(` ref(qualifiedName: %s) { target { ... on Commit { history(first: 100, since: %s, until: %s) {
+    pageInfo { hasNextPage }
+    nodes { oid url message author { name email date } parents(first: 2) { totalCount } signature { isValid state } }
+  } } } } `, graphQLString("refs/heads/"+branch), graphQLString(periodStart(since, loc)), graphQLString(periodEnd(until, loc)))
+	return graphQLField{repo: repo, selection: selection, store: func(data json.RawMessage) error {
+		var repository struct {
+			Ref *struct {
+				Target struct {
+					History struct {
+						PageInfo struct {
+							HasNextPage bool `json:"hasNextPage"`
+						} `json:"pageInfo"`
+						Nodes []graphQLCommit `json:"nodes"`
+					} `json:"history"`
+				} `json:"target"`
+			} `json:"ref"`
+		}
+		if err := json.Unmarshal(data, &repository); err != nil {
+			return err
+		}
+		if repository.Ref == nil || repository.Ref.Target.History.PageInfo.HasNextPage {
+			return nil
+		}
+		commits := []Commit{}
+		for _, c := range repository.Ref.Target.History.Nodes {
+			commits = append(commits, c.normalize())
+		}
+		g.mu.Lock()
+		g.commits[batchKey(repo, branch, since, until)] = commits
+		g.mu.Unlock()
+		return nil
+	}}
+}
+
+// Field of the pull requests merged in the period, with their reviewers
+func (g *githubGraphQL) pullsField(repo, since, until string, loc *time.Location) graphQLField {
+	selection := ` pullRequests(states: MERGED, first: 100, orderBy: {field: UPDATED_AT, direction: DESC}) {
+    pageInfo { hasNextPage }
+    nodes { number title body url mergedAt updatedAt author { login } labels(first: 50) { nodes { name } } reviews(first: 100) { nodes { author { login } } } }
+  } `
+	return graphQLField{repo: repo, selection: selection, store: func(data json.RawMessage) error {
+		var repository struct {
+			PullRequests struct {
+				PageInfo struct {
+					HasNextPage bool `json:"hasNextPage"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					Number    int    `json:"number"`
+					Title     string `json:"title"`
+					Body      string `json:"body"`
+					URL       string `json:"url"`
+					MergedAt  string `json:"mergedAt"`
+					UpdatedAt string `json:"updatedAt"`
+					Author    struct {
+						Login string `json:"login"`
+					} `json:"author"`
+					Labels struct {
+						Nodes []struct {
+							Name string `json:"name"`
+						} `json:"nodes"`
+					} `json:"labels"`
+					Reviews struct {
+						Nodes []struct {
+							Author struct {
+								Login string `json:"login"`
+							} `json:"author"`
+						} `json:"nodes"`
+					} `json:"reviews"`
+				} `json:"nodes"`
+			} `json:"pullRequests"`
+		}
+		if err := json.Unmarshal(data, &repository); err != nil {
+			return err
+		}
+		// As with REST, paging could stop at the first pull request last
+		// updated before the period.
+		complete := !repository.PullRequests.PageInfo.HasNextPage
+		pulls := []PullRequest{}
+		for _, p := range repository.PullRequests.Nodes {
+			if p.UpdatedAt != "" && dateOf(p.UpdatedAt, loc) < since {
+				complete = true
+				break
+			}
+			if !inPeriod(p.MergedAt, since, until, loc) {
+				continue
+			}
+			pull := PullRequest{Number: p.Number, Title: p.Title, Author: p.Author.Login, URL: p.URL, MergedAt: p.MergedAt, Description: p.Body}
+			for _, label := range p.Labels.Nodes {
+				pull.Labels = append(pull.Labels, label.Name)
+			}
+			for _, review := range p.Reviews.Nodes {
+				if review.Author.Login != pull.Author {
+					pull.Reviewers = appendUnique(pull.Reviewers, review.Author.Login)
+				}
+			}
+			pull.Issues = githubPullIssues(p.Body, p.URL, repo, p.Number)
+			pulls = append(pulls, pull)
+		}
+		if !complete {
+			return nil
+		}
+		sort.SliceStable(pulls, func(i, j int) bool { return pulls[i].MergedAt > pulls[j].MergedAt })
+		g.mu.Lock()
+		g.pulls[batchKey(repo, since, until)] = pulls
+		g.mu.Unlock()
+		return nil
+	}}
+}
+
+// Field of the deployments to environment up to the end of the period, with
+// their statuses
+func (g *githubGraphQL) deploymentsField(repo, environment, since, until string, loc *time.Location) graphQLField {
+	selection := fmt.Sprintf(` deployments(environments: [%s], first: 100, orderBy: {field: CREATED_AT, direction: DESC}) {
+    pageInfo { hasNextPage }
+    nodes { commitOid createdAt ref { name } statuses(first: 100) { nodes { state createdAt } } }
+  } `, graphQLString(environment))
+	return graphQLField{repo: repo, selection: selection, store: func(data json.RawMessage) error {
+		var repository struct {
+			Deployments struct {
+				PageInfo struct {
+					HasNextPage bool `json:"hasNextPage"`
+				} `json:"pageInfo"`
+				Nodes []struct {
+					CommitOID string `json:"commitOid"`
+					CreatedAt string `json:"createdAt"`
+					Ref       *struct {
+						Name string `json:"name"`
+					} `json:"ref"`
+					Statuses struct {
+						Nodes []struct {
+							State     string `json:"state"`
+							CreatedAt string `json:"createdAt"`
+						} `json:"nodes"`
+					} `json:"statuses"`
+				} `json:"nodes"`
+			} `json:"deployments"`
+		}
+		if err := json.Unmarshal(data, &repository); err != nil {
+			return err
+		}
+		nodes := repository.Deployments.Nodes
+		// The REST API pages until a deployment created before the period.
+		if repository.Deployments.PageInfo.HasNextPage && (len(nodes) == 0 || dateOf(nodes[len(nodes)-1].CreatedAt, loc) >= since) {
+			return nil
+		}
+		deployments := []Deployment{}
+		for _, d := range nodes {
+			if dateOf(d.CreatedAt, loc) > until {
+				continue
+			}
+			var success string
+			for _, status := range d.Statuses.Nodes {
+				if status.State == "SUCCESS" && status.CreatedAt > success {
+					success = status.CreatedAt
+				}
+			}
+			if success == "" || dateOf(success, loc) > until {
+				continue
+			}
+			ref := d.CommitOID
+			if d.Ref != nil {
+				ref = d.Ref.Name
+			}
+			deployments = append(deployments, Deployment{SHA: d.CommitOID, Ref: ref, Environment: environment, Date: success})
+			if dateOf(success, loc) < since {
+				break
+			}
+		}
+		g.mu.Lock()
+		g.deployments[batchKey(repo, environment, since, until)] = deployments
+		g.mu.Unlock()
+		return nil
+	}}
+}
@@ -0,0 +1,120 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGraphQLEndpoint(t *testing.T) {
+	tests := []struct {
+		baseURL, endpoint string
+	}{
+		{DefaultAPIBaseURL, "https://api.github.com/graphql"},
+		{"https://github.corp.example/api/v3", "https://github.corp.example/api/graphql"},
+		{"https://github.corp.example/api/v3/", "https://github.corp.example/api/graphql"},
+	}
+	for _, test := range tests {
+		g := WithGraphQL(NewGitHub(nil, test.baseURL, "token")).(*githubGraphQL)
+		if endpoint := g.graphQLEndpoint(); endpoint != test.endpoint {
+			t.Errorf("GraphQL endpoint of %s = %s, want %s", test.baseURL, endpoint, test.endpoint)
+		}
+	}
+	if gitlab := NewGitLab(nil, DefaultGitLabBaseURL, "token"); WithGraphQL(gitlab) != gitlab {
+		t.Error("WithGraphQL changed a GitLab provider")
+	}
+}
+
+func TestGraphQLStrings(t *testing.T) {
+	tests := []struct {
+		repo, owner, name, literal string
+	}{
+		{"acme/web", "acme", "web", `"acme/web"`},
+		{"acme/web/extra", "acme", "web/extra", `"acme/web/extra"`},
+		{"web", "web", "", `"web"`},
+		{`acme/"quoted"\`, "acme", `"quoted"\`, `"acme/\"quoted\"\\"`},
+	}
+	for _, test := range tests {
+		if owner, name := splitRepo(test.repo); owner != test.owner || name != test.name {
+			t.Errorf("splitRepo(%q) = %q, %q, want %q, %q", test.repo, owner, name, test.owner, test.name)
+		}
+		if got := graphQLString(test.repo); got != test.literal {
+			t.Errorf("graphQLString(%q) = %s, want %s", test.repo, got, test.literal)
+		}
+	}
+}
+
+func TestGraphQLPrefetchCommits(t *testing.T) {
+	var queries, restCalls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/graphql" {
+			restCalls = append(restCalls, r.URL.Path)
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		queries = append(queries, body.Query)
+		// acme/web fits on a page; acme/api has more commits than a page.
+		fmt.Fprint(w, `{"data": {
+			"f0": {"ref": {"target": {"history": {"pageInfo": {"hasNextPage": false}, "nodes": [
+				{"oid": "c1", "url": "https://github.com/acme/web/commit/c1", "message": "feat: search", "author": {"name": "Ada", "email": "ada@example.com", "date": "2026-03-02T10:00:00Z"}, "parents": {"totalCount": 2}, "signature": {"isValid": true, "state": "VALID"}}
+			]}}}},
+			"f1": {"ref": {"target": {"history": {"pageInfo": {"hasNextPage": true}, "nodes": []}}}},
+			"f2": null
+		}}`)
+	}))
+	defer server.Close()
+
+	g := WithGraphQL(NewGitHub(server.Client(), server.URL, "token")).(*githubGraphQL)
+	services := []Service{
+		{Service: "web", Repo: "acme/web"},
+		{Service: "api", Repo: "acme/api"},
+		{Service: "gone", Repo: "acme/gone"},
+		{Service: "docs", Repo: "acme/docs", Provider: ProviderGitLab},
+		{Service: "lib", Repo: "acme/lib", Compare: "v1..v2"},
+	}
+	opts := Options{Mode: ModeCommits, StartDate: "2026-03-01", EndDate: "2026-03-31"}
+	ctx := context.Background()
+	if err := g.prefetch(ctx, services, opts); err != nil {
+		t.Fatalf("prefetch: %v", err)
+	}
+	if len(queries) != 1 {
+		t.Fatalf("GraphQL queries = %d, want 1", len(queries))
+	}
+	for _, repo := range []string{`name: "web"`, `name: "api"`, `name: "gone"`} {
+		if !strings.Contains(queries[0], repo) {
+			t.Errorf("query does not look up %s:\n%s", repo, queries[0])
+		}
+	}
+	if strings.Contains(queries[0], `"docs"`) || strings.Contains(queries[0], `"lib"`) {
+		t.Errorf("query looks up a GitLab or compared service:\n%s", queries[0])
+	}
+
+	commits, err := g.Commits(ctx, "acme/web", "main", opts.StartDate, opts.EndDate, "")
+	if err != nil {
+		t.Fatalf("Commits of acme/web: %v", err)
+	}
+	want := []Commit{{SHA: "c1", Message: "feat: search", Author: "Ada", AuthorEmail: "ada@example.com", URL: "https://github.com/acme/web/commit/c1", Date: "2026-03-02T10:00:00Z", Merge: true, Verification: verifiedSignature}}
+	if fmt.Sprint(commits) != fmt.Sprint(want) {
+		t.Errorf("commits of acme/web = %+v, want %+v", commits, want)
+	}
+	if len(restCalls) != 0 {
+		t.Errorf("prefetched commits called REST: %v", restCalls)
+	}
+	for _, repo := range []string{"acme/api", "acme/gone"} {
+		restCalls = nil
+		if _, err := g.Commits(ctx, repo, "main", opts.StartDate, opts.EndDate, ""); err != nil {
+			t.Fatalf("Commits of %s: %v", repo, err)
+		}
+		if fmt.Sprint(restCalls) != "[/repos/"+repo+"/commits]" {
+			t.Errorf("Commits of %s called %v, want the REST commits API", repo, restCalls)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Harness SaaS host, used when "harness" sets no base_url
+const DefaultHarnessBaseURL = "https://app.harness.io"
+
+// Directories of a repository holding its Harness pipeline YAML, as the
+// repo hygiene check requires it (".harness/piplines", spelled that way)
+var harnessPipelineDirs = []string{".harness/piplines/", ".harness/pipelines/"}
+
+// Executions listed per request of the pipeline execution API
+const harnessPageSize = 100
+
+// Pipeline execution that deployed (or tried to deploy) a service
+type HarnessExecution struct {
+	ID           string // plan execution ID
+	Pipeline     string // pipeline identifier
+	Name         string // pipeline name
+	Status       string // e.g. "Success", "Failed" or "Aborted"
+	Started      string
+	Finished     string   // "" while running
+	Environments []string // environment identifiers deployed to
+	Services     []string // Harness service identifiers deployed
+	TriggeredBy  string
+	URL          string
+
+	// Where the pipeline YAML is stored, for services without
+	// harness_pipelines
+	repo, file string
+}
+
+// Whether the execution finished successfully, i.e. deployed
+func (e HarnessExecution) Succeeded() bool {
+	return e.Status == "Success"
+}
+
+// Check the Harness settings before any report is generated
+func (c HarnessConfig) validate() error {
+	for _, setting := range []struct{ key, value string }{{"account_id", c.AccountID}, {"org", c.Org}, {"project", c.Project}} {
+		if setting.value == "" {
+			return fmt.Errorf("harness.%s is required", setting.key)
+		}
+	}
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("harness.base_url %q is not an absolute URL", c.BaseURL)
+		}
+	}
+	if c.apiKey() == "" {
+		return errors.New("harness: no API key found: set HARNESS_API_KEY or harness.api_key")
+	}
+	return nil
+}
+
+// API key from the HARNESS_API_KEY environment variable, else the config
+func (c HarnessConfig) apiKey() string {
+	if key := os.Getenv("HARNESS_API_KEY"); key != "" {
+		return key
+	}
+	return c.APIKey
+}
+
+// Harness host, defaulting to Harness SaaS
+func (c HarnessConfig) baseURL() string {
+	if c.BaseURL == "" {
+		return DefaultHarnessBaseURL
+	}
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+// Harness pipeline API, for the executions that deployed the services
+type HarnessClient struct {
+	client *http.Client
+	config HarnessConfig
+}
+
+// Harness client with the API key of config; a nil client gets the default
+// timeout
+func NewHarnessClient(client *http.Client, config HarnessConfig) *HarnessClient {
+	return &HarnessClient{client: defaultClient(client), config: config}
+}
+
+// Fetch the pipeline executions of the project started between since and
+// until (YYYY-MM-DD, inclusive), newest first
+func (api HarnessClient) executions(ctx context.Context, since, until string) ([]HarnessExecution, error) {
+	loc := settingsOf(ctx).location
+	query := url.Values{
+		"accountIdentifier": {api.config.AccountID},
+		"orgIdentifier":     {api.config.Org},
+		"projectIdentifier": {api.config.Project},
+		"size":              {fmt.Sprint(harnessPageSize)},
+	}
+	filter := map[string]string{"filterType": "PipelineExecution"}
+	var executions []HarnessExecution
+	for page := 0; ; page++ {
+		query.Set("page", fmt.Sprint(page))
+		target := api.config.baseURL() + "/pipeline/api/pipelines/execution/summary?" + query.Encode()
+		// Listing executions is a POST (the body is the filter) but
+		// changes nothing.
+		resp, err := apiSend(ctx, api.client, http.MethodPost, target, "x-api-key", api.config.apiKey(), filter)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Data struct {
+				Content    []harnessExecutionSummary `json:"content"`
+				TotalPages int                       `json:"totalPages"`
+			} `json:"data"`
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			err = json.NewDecoder(resp.Body).Decode(&result)
+		case http.StatusUnauthorized, http.StatusForbidden:
+			err = fmt.Errorf("Harness rejected the API key (%s); check HARNESS_API_KEY and its access to project %s", resp.Status, api.config.Project)
+		default:
+			err = fmt.Errorf("Harness returned %s listing the executions of project %s", resp.Status, api.config.Project)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// Executions are listed newest first; older pages are not needed
+		// once one started before the period.
+		done := page+1 >= result.Data.TotalPages
+		for _, summary := range result.Data.Content {
+			execution := summary.execution(api.config)
+			if dateOf(execution.Started, loc) < since {
+				done = true
+				break
+			}
+			if dateOf(execution.Started, loc) <= until {
+				executions = append(executions, execution)
+			}
+		}
+		if done || len(result.Data.Content) == 0 {
+			return executions, nil
+		}
+	}
+}
+
+// Execution as listed by the pipeline execution summary API
+type harnessExecutionSummary struct {
+	PlanExecutionID      string `json:"planExecutionId"`
+	PipelineIdentifier   string `json:"pipelineIdentifier"`
+	Name                 string `json:"name"`
+	Status               string `json:"status"`
+	StartTs              int64  `json:"startTs"` // milliseconds
+	EndTs                int64  `json:"endTs"`
+	ExecutionTriggerInfo struct {
+		TriggeredBy struct {
+			Identifier string `json:"identifier"`
+		} `json:"triggeredBy"`
+	} `json:"executionTriggerInfo"`
+	GitDetails struct {
+		RepoName string `json:"repoName"`
+		FilePath string `json:"filePath"`
+	} `json:"gitDetails"`
+	ModuleInfo struct {
+		CD struct {
+			ServiceIdentifiers []string `json:"serviceIdentifiers"`
+			EnvIdentifiers     []string `json:"envIdentifiers"`
+		} `json:"cd"`
+	} `json:"moduleInfo"`
+}
+
+// Execution of the report, linked to its page in Harness
+func (s harnessExecutionSummary) execution(config HarnessConfig) HarnessExecution {
+	execution := HarnessExecution{
+		ID:           s.PlanExecutionID,
+		Pipeline:     s.PipelineIdentifier,
+		Name:         s.Name,
+		Status:       s.Status,
+		Started:      time.UnixMilli(s.StartTs).UTC().Format(time.RFC3339),
+		Environments: s.ModuleInfo.CD.EnvIdentifiers,
+		Services:     s.ModuleInfo.CD.ServiceIdentifiers,
+		TriggeredBy:  s.ExecutionTriggerInfo.TriggeredBy.Identifier,
+		URL: fmt.Sprintf("%s/ng/account/%s/cd/orgs/%s/projects/%s/pipelines/%s/executions/%s/pipeline", config.baseURL(),
+			url.PathEscape(config.AccountID), url.PathEscape(config.Org), url.PathEscape(config.Project), url.PathEscape(s.PipelineIdentifier), url.PathEscape(s.PlanExecutionID)),
+		repo: s.GitDetails.RepoName,
+		file: strings.TrimPrefix(s.GitDetails.FilePath, "/"),
+	}
+	if s.EndTs > 0 {
+		execution.Finished = time.UnixMilli(s.EndTs).UTC().Format(time.RFC3339)
+	}
+	return execution
+}
+
+// Whether an execution deploys a service: it ran one of the service's
+// harness_pipelines, by default a pipeline stored in the .harness/piplines
+// directory of its repository, and, with a harness_service, deployed that
+// Harness service
+func (e HarnessExecution) deploys(service Service) bool {
+	if len(service.HarnessPipelines) > 0 {
+		if !contains(service.HarnessPipelines, e.Pipeline) {
+			return false
+		}
+	} else if !e.storedIn(service.Repo) {
+		return false
+	}
+	return service.HarnessService == "" || contains(e.Services, service.HarnessService)
+}
+
+// Whether the pipeline YAML of an execution is stored in the pipeline
+// directory of repo. Harness names GitHub repositories without their owner.
+func (e HarnessExecution) storedIn(repo string) bool {
+	if e.repo == "" || (e.repo != repo && e.repo != path.Base(repo)) {
+		return false
+	}
+	for _, dir := range harnessPipelineDirs {
+		if strings.HasPrefix(e.file, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fill in the Harness executions of the services reported by date, listing
+// the project's executions once for all of them. A failure is recorded in
+// every such service, or returned with opts.FailFast.
+func fillHarnessExecutions(ctx context.Context, api HarnessClient, services []Service, reports []ServiceReport, opts Options) error {
+	var dated []int
+	for i := range reports {
+		if reports[i].Range == "" {
+			dated = append(dated, i)
+		}
+	}
+	if len(dated) == 0 {
+		return nil
+	}
+	executions, err := api.executions(ctx, opts.StartDate, opts.EndDate)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if opts.FailFast {
+			return fmt.Errorf("Harness executions: %w", err)
+		}
+		for _, i := range dated {
+			reports[i].skip(ctx, "Harness executions", err)
+		}
+		return nil
+	}
+	opts.logger().Info("fetched Harness executions", "project", api.config.Project, "executions", len(executions))
+	for _, i := range dated {
+		for _, execution := range executions {
+			if execution.deploys(services[i]) {
+				reports[i].HarnessExecutions = append(reports[i].HarnessExecutions, execution)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package releasereport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// One archived report run, saved with -history as <mode>_<start>_<end>.json
+type historyRun struct {
+	Generated time.Time
+	Mode      string
+	StartDate string
+	EndDate   string
+	Services  []ServiceReport
+}
+
+// Change volume of a service against the previous archived period
+type PeriodChange struct {
+	Period   string // of the previous run
+	Previous int
+	Current  int
+	Delta    string // e.g. "+3" or "-2"
+	Percent  string // e.g. "+25%", "–" without changes in the previous period
+}
+
+// Archive file of a run
+func historyFile(dir, mode, startDate, endDate string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s_%s_%s.json", mode, startDate, endDate))
+}
+
+// Latest archived run in mode that ended before startDate, or nil
+func previousRun(dir, mode, startDate string) (*historyRun, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	latest, latestEnd := "", ""
+	for _, file := range files {
+		parts := strings.Split(strings.TrimSuffix(file.Name(), ".json"), "_")
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") || len(parts) != 3 || parts[0] != mode {
+			continue
+		}
+		if end := parts[2]; ValidDate(end) && end < startDate && end > latestEnd {
+			latest, latestEnd = file.Name(), end
+		}
+	}
+	if latest == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, latest))
+	if err != nil {
+		return nil, err
+	}
+	var run historyRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("%s: %w", latest, err)
+	}
+	return &run, nil
+}
+
+// Set the change volume of every service against a previous run; services
+// missing from it count as having had no changes
+func compareWithPrevious(reports []ServiceReport, previous *historyRun, mode string) {
+	counts := make(map[string]int)
+	for _, report := range previous.Services {
+		counts[report.Service] = changeCount(report, mode)
+	}
+	for i := range reports {
+		change := &PeriodChange{
+			Period:   previous.StartDate + " – " + previous.EndDate,
+			Previous: counts[reports[i].Service],
+			Current:  changeCount(reports[i], mode),
+			Percent:  "–",
+		}
+		change.Delta = fmt.Sprintf("%+d", change.Current-change.Previous)
+		if change.Previous > 0 {
+			change.Percent = fmt.Sprintf("%+.0f%%", float64(change.Current-change.Previous)*100/float64(change.Previous))
+		}
+		reports[i].History = change
+	}
+}
+
+// Archive a run in dir, replacing an earlier run of the same period
+func saveHistory(dir string, reports []ServiceReport, opts Options) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	run := historyRun{Generated: time.Now().UTC(), Mode: opts.Mode, StartDate: opts.StartDate, EndDate: opts.EndDate, Services: reports}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	filename := historyFile(dir, opts.Mode, opts.StartDate, opts.EndDate)
+	return filename, ioutil.WriteFile(filename, data, 0644)
+}
+
+// Compare the reports with the previous period archived in opts.History and
+// archive them. Failures are logged; the report goes on without history.
+func recordHistory(reports []ServiceReport, opts Options) {
+	logger := opts.logger()
+	for _, report := range reports {
+		if report.Range != "" {
+			logger.Info("skipping the report history: compare ranges have no period")
+			return
+		}
+	}
+	previous, err := previousRun(opts.History, opts.Mode, opts.StartDate)
+	if err != nil {
+		logger.Warn("reading the report history failed", "dir", opts.History, "error", err)
+	} else if previous == nil {
+		logger.Info("no earlier period in the report history to compare with", "dir", opts.History)
+	} else {
+		compareWithPrevious(reports, previous, opts.Mode)
+		logger.Info("compared with the previous period", "start", previous.StartDate, "end", previous.EndDate)
+	}
+	if filename, err := saveHistory(opts.History, reports, opts); err != nil {
+		logger.Warn("saving the report history failed", "dir", opts.History, "error", err)
+	} else {
+		logger.Info("report saved to the history", "file", filename)
+	}
+}
@@ -0,0 +1,140 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Default pattern of Jira ticket keys, e.g. VIAXAMS-1234
+const defaultTicketPattern = `\b[A-Z][A-Z0-9]+-[0-9]+\b`
+
+// Jira ticket referenced by a commit
+type Ticket struct {
+	Key    string
+	URL    string // "" without a Jira base URL
+	Status string // with -trace and Jira credentials, e.g. "Done"
+}
+
+// API token from the JIRA_TOKEN environment variable, else the config
+func (c JiraConfig) token() string {
+	if token := os.Getenv("JIRA_TOKEN"); token != "" {
+		return token
+	}
+	return c.Token
+}
+
+// Whether an API token is configured, needed for ticket statuses
+func (c JiraConfig) HasToken() bool {
+	return c.token() != ""
+}
+
+// Authorization header value of the Jira REST API
+func (c JiraConfig) authorization() string {
+	if c.Username != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.token()))
+	}
+	return "Bearer " + c.token()
+}
+
+// Compile the ticket pattern, checking the Jira settings
+func (c JiraConfig) compile() (*regexp.Regexp, error) {
+	if c.BaseURL == "" {
+		return nil, fmt.Errorf("jira.base_url is required")
+	}
+	if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("jira.base_url %q is not an absolute URL", c.BaseURL)
+	}
+	pattern := c.TicketPattern
+	if pattern == "" {
+		pattern = defaultTicketPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("jira.ticket_pattern: %v", err)
+	}
+	return re, nil
+}
+
+// Extract the unique ticket keys of commits, in order of first mention, as
+// links to the Jira instance at baseURL
+func extractTickets(commits []Commit, pattern *regexp.Regexp, baseURL string) []Ticket {
+	var messages []string
+	for _, c := range commits {
+		messages = append(messages, c.Message)
+	}
+	return findTickets(pattern, baseURL, messages...)
+}
+
+// Unique ticket keys mentioned in texts, in order of first mention, as links
+// to the Jira instance at baseURL unless it is empty
+func findTickets(pattern *regexp.Regexp, baseURL string, texts ...string) []Ticket {
+	var tickets []Ticket
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+			key := match[0]
+			if len(match) > 1 {
+				key = match[1]
+			}
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			ticket := Ticket{Key: key}
+			if baseURL != "" {
+				ticket.URL = strings.TrimSuffix(baseURL, "/") + "/browse/" + url.PathEscape(key)
+			}
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets
+}
+
+// Jira REST API, for the status of traced tickets
+type JiraClient struct {
+	client *http.Client
+	config JiraConfig
+}
+
+// Jira client with the credentials of config; a nil client gets the default
+// timeout
+func NewJiraClient(client *http.Client, config JiraConfig) *JiraClient {
+	return &JiraClient{client: defaultClient(client), config: config}
+}
+
+// Fetch the status of a ticket from Jira, or "not found" for an unknown key
+func (api JiraClient) status(ctx context.Context, key string) (string, error) {
+	target := strings.TrimSuffix(api.config.BaseURL, "/") + "/rest/api/2/issue/" + url.PathEscape(key) + "?fields=status"
+	resp, err := apiGet(ctx, api.client, target, "Authorization", api.config.authorization())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "not found", nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "", fmt.Errorf("Jira rejected the credentials (%s); check JIRA_TOKEN and jira.username", resp.Status)
+	default:
+		return "", fmt.Errorf("Jira returned %s for %s", resp.Status, key)
+	}
+	var issue struct {
+		Fields struct {
+			Status struct {
+				Name string `json:"name"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return "", fmt.Errorf("decoding Jira ticket %s: %v", key, err)
+	}
+	return issue.Fields.Status.Name, nil
+}
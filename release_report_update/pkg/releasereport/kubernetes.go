@@ -0,0 +1,303 @@
+package releasereport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Pods listed per request of the Kubernetes API
+const kubernetesPageSize = 500
+
+// Container image of a service running in an environment
+type RunningImage struct {
+	Environment string
+	Namespace   string
+	Workload    string // owning deployment, stateful set, daemon set or job, else the pod
+	Image       string // repository, without the tag
+	Tag         string // tag, else the digest
+	Pods        int    // running pods of the workload with this image
+}
+
+// Check the Kubernetes settings before any report is generated
+func (c KubernetesConfig) validate() error {
+	for environment, target := range c.Environments {
+		if environment == "" {
+			return errors.New("kubernetes.environments: empty environment name")
+		}
+		if contains(target.Namespaces, "") {
+			return fmt.Errorf("kubernetes.environments.%s: empty namespace", environment)
+		}
+	}
+	return nil
+}
+
+// Scans Kubernetes clusters for the images running per environment, with
+// the credentials of the kubeconfig
+type KubernetesScanner struct {
+	targets []kubernetesTarget
+}
+
+// Namespaces of one kubeconfig context to scan
+type kubernetesTarget struct {
+	environment string // "" to report each namespace as an environment
+	context     string
+	namespaces  []string // nil for all
+	clientset   kubernetes.Interface
+}
+
+// Load the kubeconfig and connect to the contexts of config; timeout limits
+// each request
+func NewKubernetesScanner(config KubernetesConfig, timeout time.Duration) (*KubernetesScanner, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if config.Kubeconfig != "" {
+		rules.ExplicitPath = config.Kubeconfig
+	}
+	clientsets := make(map[string]kubernetes.Interface)
+	connect := func(context string) (kubernetes.Interface, error) {
+		if clientset, ok := clientsets[context]; ok {
+			return clientset, nil
+		}
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+		restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		restConfig.Timeout = timeout
+		clientset, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		clientsets[context] = clientset
+		return clientset, nil
+	}
+
+	scanner := &KubernetesScanner{}
+	if len(config.Environments) == 0 {
+		clientset, err := connect("")
+		if err != nil {
+			return nil, err
+		}
+		scanner.targets = append(scanner.targets, kubernetesTarget{clientset: clientset})
+		return scanner, nil
+	}
+	environments := make([]string, 0, len(config.Environments))
+	for environment := range config.Environments {
+		environments = append(environments, environment)
+	}
+	sort.Strings(environments)
+	for _, environment := range environments {
+		target := config.Environments[environment]
+		clientset, err := connect(target.Context)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", environment, err)
+		}
+		scanner.targets = append(scanner.targets, kubernetesTarget{environment: environment, context: target.Context, namespaces: target.Namespaces, clientset: clientset})
+	}
+	return scanner, nil
+}
+
+// List the images of the running pods of every target, one entry per
+// workload and image
+func (s KubernetesScanner) scan(ctx context.Context) ([]RunningImage, error) {
+	var images []RunningImage
+	for _, target := range s.targets {
+		namespaces := target.namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{metav1.NamespaceAll}
+		}
+		for _, namespace := range namespaces {
+			pods, err := listRunningPods(ctx, target.clientset, namespace)
+			if err != nil {
+				if target.environment != "" {
+					err = fmt.Errorf("environment %s: %w", target.environment, err)
+				}
+				return nil, err
+			}
+			settingsOf(ctx).logger.Info("scanned Kubernetes pods", "environment", target.environment, "context", target.context, "namespace", namespace, "pods", len(pods))
+			images = appendRunningImages(images, pods, target.environment)
+		}
+	}
+	return images, nil
+}
+
+// Running pods of a namespace (all with metav1.NamespaceAll), page by page
+func listRunningPods(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+	options := metav1.ListOptions{FieldSelector: "status.phase=Running", Limit: kubernetesPageSize}
+	for {
+		list, err := clientset.CoreV1().Pods(namespace).List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, list.Items...)
+		if list.Continue == "" {
+			return pods, nil
+		}
+		options.Continue = list.Continue
+	}
+}
+
+// Add the images of pods, counting the pods of a workload running the same
+// image once; without an environment the namespace is the environment
+func appendRunningImages(images []RunningImage, pods []corev1.Pod, environment string) []RunningImage {
+	index := make(map[RunningImage]int)
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			image, tag := splitImage(container.Image)
+			running := RunningImage{Environment: environment, Namespace: pod.Namespace, Workload: podWorkload(pod), Image: image, Tag: tag}
+			if running.Environment == "" {
+				running.Environment = pod.Namespace
+			}
+			if i, ok := index[running]; ok {
+				images[i].Pods++
+				continue
+			}
+			index[running] = len(images)
+			running.Pods = 1
+			images = append(images, running)
+		}
+	}
+	return images
+}
+
+// Workload a pod belongs to: the deployment of its replica set (named
+// after it with a hash suffix), its other controller, else the pod itself
+func podWorkload(pod corev1.Pod) string {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		if owner.Kind == "ReplicaSet" {
+			if i := strings.LastIndex(owner.Name, "-"); i > 0 {
+				return owner.Name[:i]
+			}
+		}
+		return owner.Name
+	}
+	return pod.Name
+}
+
+// Split an image reference into its repository and its tag, else its digest
+// (or "latest" with neither). A registry port is not a tag.
+func splitImage(reference string) (image, tag string) {
+	image = reference
+	if i := strings.Index(image, "@"); i >= 0 {
+		image, tag = image[:i], image[i+1:]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image, tag = image[:i], image[i+1:]
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	return image, tag
+}
+
+// Whether an image belongs to a service: one of its "images", by default
+// an image named like its repository
+func (r RunningImage) runs(service Service) bool {
+	if len(service.Images) > 0 {
+		return contains(service.Images, r.Image)
+	}
+	return path.Base(r.Image) == path.Base(service.Repo)
+}
+
+// Fill in the images each service runs, scanning the clusters once for all
+// of them. A failure is recorded in every service, or returned with
+// opts.FailFast.
+func fillRunningImages(ctx context.Context, scanner KubernetesScanner, services []Service, reports []ServiceReport, opts Options) error {
+	images, err := scanner.scan(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if opts.FailFast {
+			return fmt.Errorf("Kubernetes scan: %w", err)
+		}
+		for i := range reports {
+			reports[i].skip(ctx, "Kubernetes scan", err)
+		}
+		return nil
+	}
+	for i := range reports {
+		for _, image := range images {
+			if image.runs(services[i]) {
+				reports[i].Running = append(reports[i].Running, image)
+			}
+		}
+	}
+	return nil
+}
+
+// Tags running per service and environment, for the deployed version matrix
+type versionMatrix struct {
+	Environments []string
+	Rows         []versionRow
+}
+
+// Tags of one service, one cell per environment
+type versionRow struct {
+	Service string
+	Cells   [][]string // distinct tags per environment, none where it runs nothing
+}
+
+// Build the deployed version matrix of the services running images: the
+// configured environments first, in their order, then the others sorted
+func buildVersionMatrix(reports []ServiceReport, environments []string) *versionMatrix {
+	var extra []string
+	for _, report := range reports {
+		for _, image := range report.Running {
+			if !contains(environments, image.Environment) && !contains(extra, image.Environment) {
+				extra = append(extra, image.Environment)
+			}
+		}
+	}
+	sort.Strings(extra)
+	matrix := &versionMatrix{}
+	for _, environment := range append(append([]string{}, environments...), extra...) {
+		for _, report := range reports {
+			if runsIn(report, environment) {
+				matrix.Environments = append(matrix.Environments, environment)
+				break
+			}
+		}
+	}
+	for _, report := range reports {
+		if len(report.Running) == 0 {
+			continue
+		}
+		row := versionRow{Service: report.Service, Cells: make([][]string, len(matrix.Environments))}
+		for i, environment := range matrix.Environments {
+			for _, image := range report.Running {
+				if image.Environment == environment && !contains(row.Cells[i], image.Tag) {
+					row.Cells[i] = append(row.Cells[i], image.Tag)
+				}
+			}
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	if len(matrix.Rows) == 0 {
+		return nil
+	}
+	return matrix
+}
+
+// Whether a service runs images in an environment
+func runsIn(report ServiceReport, environment string) bool {
+	for _, image := range report.Running {
+		if image.Environment == environment {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package releasereport
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sections of pull requests mode when the config sets no "pull_sections"
+var defaultPullSections = []PullSection{
+	{Title: "✨ Features", Labels: []string{"feature", "enhancement"}},
+	{Title: "🐛 Bug Fixes", Labels: []string{"bug", "fix"}},
+	{Title: "🔒 Security", Labels: []string{"security"}},
+	{Title: "🏗️ Infrastructure", Labels: []string{"infra", "infrastructure", "ci", "dependencies"}},
+}
+
+// Section of the pull requests no section's labels match
+const otherPullSection = "📦 Other"
+
+// Pull requests of one section
+type PullGroup struct {
+	Title string
+	Pulls []PullRequest
+}
+
+// Check the pull request sections before any report is generated
+func checkPullSections(sections []PullSection) error {
+	for i, section := range sections {
+		if section.Title == "" {
+			return fmt.Errorf("pull_sections #%d: \"title\" is empty", i+1)
+		}
+		if len(section.Labels) == 0 {
+			return fmt.Errorf("pull_sections %s: no labels", section.Title)
+		}
+	}
+	return nil
+}
+
+// Group pull requests into the first section with one of their labels, in
+// section order, keeping their order and omitting empty sections. Pull
+// requests matching no section come last.
+func groupByLabel(pulls []PullRequest, sections []PullSection) []PullGroup {
+	if len(sections) == 0 {
+		sections = defaultPullSections
+	}
+	grouped := make([][]PullRequest, len(sections)+1)
+	for _, pull := range pulls {
+		i := pullSection(pull, sections)
+		grouped[i] = append(grouped[i], pull)
+	}
+	var groups []PullGroup
+	for i, pulls := range grouped {
+		if len(pulls) == 0 {
+			continue
+		}
+		title := otherPullSection
+		if i < len(sections) {
+			title = sections[i].Title
+		}
+		groups = append(groups, PullGroup{Title: title, Pulls: pulls})
+	}
+	return groups
+}
+
+// Index of the first section with one of the pull request's labels, or
+// len(sections) when none has
+func pullSection(pull PullRequest, sections []PullSection) int {
+	for i, section := range sections {
+		for _, want := range section.Labels {
+			for _, label := range pull.Labels {
+				if strings.EqualFold(label, want) {
+					return i
+				}
+			}
+		}
+	}
+	return len(sections)
+}
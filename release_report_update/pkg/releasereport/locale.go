@@ -0,0 +1,103 @@
+package releasereport
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Languages of the report strings and dates (see -lang), one bundle each in
+// locales/
+var ReportLanguages = []string{"en", "es", "fr"}
+
+// Language of reports without -lang
+const DefaultLanguage = "en"
+
+// Locale bundles, locales/<language>.json
+//
+//go:embed locales/*.json
+var localeBundles embed.FS
+
+// Strings and date formats of one report language. Messages map the English
+// text of the templates to its translation; text without one stays English,
+// so a bundle may lag behind the templates.
+type Locale struct {
+	Language    string            `json:"-"`
+	DateLayout  string            `json:"date_layout"`  // report date, e.g. "January 2, 2006"
+	DayLayout   string            `json:"day_layout"`   // chart days, e.g. "Jan 2"
+	TimeLayout  string            `json:"time_layout"`  // timestamps in -timezone
+	Months      []string          `json:"months"`       // January to December
+	ShortMonths []string          `json:"short_months"` // Jan to Dec
+	Messages    map[string]string `json:"messages"`
+
+	location *time.Location // of the timestamps shown, time.Local by default
+}
+
+// Load the bundle of a language, DefaultLanguage for ""
+func LoadLocale(language string) (*Locale, error) {
+	if language == "" {
+		language = DefaultLanguage
+	}
+	if !contains(ReportLanguages, language) {
+		return nil, fmt.Errorf("unknown language %q (want %s)", language, strings.Join(ReportLanguages, ", "))
+	}
+	data, err := localeBundles.ReadFile("locales/" + language + ".json")
+	if err != nil {
+		return nil, err
+	}
+	locale := &Locale{Language: language, location: time.Local}
+	if err := json.Unmarshal(data, locale); err != nil {
+		return nil, fmt.Errorf("locale %s: %v", language, err)
+	}
+	if len(locale.Months) != 12 || len(locale.ShortMonths) != 12 {
+		return nil, fmt.Errorf("locale %s: months and short_months need 12 names each", language)
+	}
+	return locale, nil
+}
+
+// Translate English report text; args fill in its fmt verbs, e.g.
+// Text("%d commits behind", 3)
+func (l *Locale) Text(text string, args ...interface{}) string {
+	if translated, ok := l.Messages[text]; ok && translated != "" {
+		text = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Format a time with a layout, in the month names of the locale: Go only
+// knows the English ones
+func (l *Locale) format(t time.Time, layout string) string {
+	formatted := t.Format(layout)
+	switch {
+	case strings.Contains(layout, "January"):
+		formatted = strings.Replace(formatted, t.Month().String(), l.Months[t.Month()-1], 1)
+	case strings.Contains(layout, "Jan"):
+		formatted = strings.Replace(formatted, t.Month().String()[:3], l.ShortMonths[t.Month()-1], 1)
+	}
+	return formatted
+}
+
+// Report date of a time
+func (l *Locale) date(t time.Time) string {
+	return l.format(t, l.DateLayout)
+}
+
+// Chart label of a day
+func (l *Locale) day(t time.Time) string {
+	return l.format(t, l.DayLayout)
+}
+
+// RFC 3339 timestamp as shown in the report, in the time zone of the run;
+// other text is returned as it is
+func (l *Locale) localTime(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return l.format(t.In(l.location), l.TimeLayout)
+}
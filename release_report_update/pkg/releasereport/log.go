@@ -0,0 +1,16 @@
+package releasereport
+
+// Logger receives leveled, structured messages: a short message followed by
+// alternating keys and values. *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+// Discards everything; the logger of Options without one
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
@@ -0,0 +1,51 @@
+package releasereport
+
+import (
+	"fmt"
+	"time"
+)
+
+// Release cadence of a service, with -metrics
+type ReleaseMetrics struct {
+	Releases          int    // published in the period
+	PerWeek           string // releases per week in the period
+	CommitsPerRelease string // commits in the period per release, "–" without releases
+	LastRelease       string // tag of the latest release up to the end date
+	DaysSinceRelease  string // from the latest release to the end date, "never" without one
+}
+
+// Compute the release metrics of a service from its releases up to endDate
+// (newest first) and the number of commits in the period, dated in loc
+func computeReleaseMetrics(releases []Release, commits int, startDate, endDate string, loc *time.Location) *ReleaseMetrics {
+	metrics := &ReleaseMetrics{CommitsPerRelease: "–", DaysSinceRelease: "never"}
+	for _, r := range releases {
+		if inPeriod(r.Date, startDate, endDate, loc) {
+			metrics.Releases++
+		}
+	}
+	metrics.PerWeek = fmt.Sprintf("%.1f", float64(metrics.Releases)/periodWeeks(startDate, endDate))
+	if metrics.Releases > 0 {
+		metrics.CommitsPerRelease = fmt.Sprintf("%.1f", float64(commits)/float64(metrics.Releases))
+	}
+	if len(releases) > 0 {
+		latest := releases[0]
+		for _, r := range releases[1:] {
+			if r.Date > latest.Date {
+				latest = r
+			}
+		}
+		metrics.LastRelease = latest.Tag
+		end, _ := time.Parse("2006-01-02", endDate) // checked in Fetch
+		if day, err := time.Parse("2006-01-02", dateOf(latest.Date, loc)); err == nil {
+			metrics.DaysSinceRelease = fmt.Sprint(int(end.Sub(day).Hours() / 24))
+		}
+	}
+	return metrics
+}
+
+// Length in weeks of the period from startDate to endDate, both included
+func periodWeeks(startDate, endDate string) float64 {
+	start, _ := time.Parse("2006-01-02", startDate) // checked in Fetch
+	end, _ := time.Parse("2006-01-02", endDate)
+	return (end.Sub(start).Hours()/24 + 1) / 7
+}
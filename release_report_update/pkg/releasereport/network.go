@@ -0,0 +1,89 @@
+package releasereport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// TLS versions accepted as "min_tls_version"
+var tlsVersions = map[string]uint16{"1.2": tls.VersionTLS12, "1.3": tls.VersionTLS13}
+
+// Check the network settings before any report is generated
+func (c NetworkConfig) validate() error {
+	if c.Proxy != "" {
+		if _, err := c.proxyURL(); err != nil {
+			return err
+		}
+	}
+	if c.CAFile != "" {
+		if _, err := c.rootCAs(); err != nil {
+			return err
+		}
+	}
+	if _, ok := tlsVersions[c.MinTLSVersion]; !ok && c.MinTLSVersion != "" {
+		return fmt.Errorf("network.min_tls_version %q is not 1.2 or 1.3", c.MinTLSVersion)
+	}
+	return nil
+}
+
+// Parsed network.proxy
+func (c NetworkConfig) proxyURL() (*url.URL, error) {
+	proxy, err := url.Parse(c.Proxy)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("network.proxy %q is not a URL such as http://proxy.example:3128", c.Proxy)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5":
+		return proxy, nil
+	}
+	return nil, fmt.Errorf("network.proxy %q: scheme must be http, https or socks5", c.Proxy)
+}
+
+// System certificate authorities plus those of network.ca_file
+func (c NetworkConfig) rootCAs() (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("network.ca_file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("network.ca_file %s holds no PEM certificates", c.CAFile)
+	}
+	return pool, nil
+}
+
+// Transport of the API client: the default one, with the proxy and TLS
+// settings of config when given
+func newTransport(config *NetworkConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config == nil {
+		return transport, nil
+	}
+	if config.Proxy != "" {
+		proxy, err := config.proxyURL()
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: config.InsecureSkipVerify}
+	if version, ok := tlsVersions[config.MinTLSVersion]; ok {
+		tlsConfig.MinVersion = version
+	}
+	if config.CAFile != "" {
+		pool, err := config.rootCAs()
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
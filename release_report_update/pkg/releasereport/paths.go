@@ -0,0 +1,131 @@
+package releasereport
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Check that the path patterns of a service are well-formed
+func checkPaths(service Service) error {
+	for _, pattern := range service.Paths {
+		for _, elem := range strings.Split(strings.Trim(pattern, "/"), "/") {
+			if _, err := path.Match(elem, ""); err != nil || elem == "" {
+				return fmt.Errorf("service %s: invalid path pattern %q", service.Service, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Directory a path pattern such as "services/payments/**" selects, when the
+// provider's path filter can apply it: no wildcards other than a trailing /**
+func pathFilterPrefix(pattern string) (string, bool) {
+	prefix := strings.TrimSuffix(strings.TrimSuffix(pattern, "/**"), "/")
+	if prefix == "" || strings.ContainsAny(prefix, "*?[") {
+		return "", false
+	}
+	return prefix, true
+}
+
+// Whether a slash-separated file path matches a pattern where * and ? match
+// within a path element and ** matches any number of elements
+func matchPath(pattern, file string) bool {
+	return matchPathElems(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(file, "/"))
+}
+
+func matchPathElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchPathElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	// A directory pattern matches the files below it.
+	return true
+}
+
+// Fetch the commits of a service in the period, limited to its paths. Plain
+// directory patterns use the provider's path filter; other patterns are
+// matched against each commit's changed files.
+func fetchServiceCommits(ctx context.Context, provider Provider, service Service, since, until string) ([]Commit, error) {
+	if len(service.Paths) == 0 {
+		return provider.Commits(ctx, service.Repo, service.branch(), since, until, "")
+	}
+
+	var prefixes []string
+	for _, pattern := range service.Paths {
+		prefix, ok := pathFilterPrefix(pattern)
+		if !ok {
+			commits, err := provider.Commits(ctx, service.Repo, service.branch(), since, until, "")
+			if err != nil {
+				return nil, err
+			}
+			return filterByPaths(ctx, provider, service, commits)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	// One query per directory, merged newest first without duplicates.
+	var commits []Commit
+	seen := make(map[string]bool)
+	for _, prefix := range prefixes {
+		found, err := provider.Commits(ctx, service.Repo, service.branch(), since, until, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range found {
+			if !seen[c.SHA] {
+				seen[c.SHA] = true
+				commits = append(commits, c)
+			}
+		}
+	}
+	if len(prefixes) > 1 {
+		sort.SliceStable(commits, func(i, j int) bool { return commits[i].Date > commits[j].Date })
+	}
+	return commits, nil
+}
+
+// Keep the commits that changed a file matching one of the service's paths
+func filterByPaths(ctx context.Context, provider Provider, service Service, commits []Commit) ([]Commit, error) {
+	if len(service.Paths) == 0 {
+		return commits, nil
+	}
+	var kept []Commit
+	for _, c := range commits {
+		files, err := provider.CommitFiles(ctx, service.Repo, c.SHA)
+		if err != nil {
+			return nil, err
+		}
+		if matchesAnyPath(service.Paths, files) {
+			kept = append(kept, c)
+		}
+	}
+	return kept, nil
+}
+
+// Whether any file matches any of the patterns
+func matchesAnyPath(patterns, files []string) bool {
+	for _, file := range files {
+		for _, pattern := range patterns {
+			if matchPath(pattern, file) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package releasereport
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// Provider serving canned commits; methods not set up panic
+type fakeProvider struct {
+	Provider
+	commits map[string][]Commit // by path filter
+	files   map[string][]string // by SHA
+}
+
+func (p fakeProvider) Commits(ctx context.Context, repo, branch, since, until, path string) ([]Commit, error) {
+	return p.commits[path], nil
+}
+
+func (p fakeProvider) CommitFiles(ctx context.Context, repo, sha string) ([]string, error) {
+	return p.files[sha], nil
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"services/payments", "services/payments/main.go", true},
+		{"services/payments/", "services/payments/api/handler.go", true},
+		{"services/payments", "services/payments-v2/main.go", false},
+		{"services/*/main.go", "services/payments/main.go", true},
+		{"services/*/main.go", "services/payments/cmd/main.go", false},
+		{"services/**/main.go", "services/payments/cmd/main.go", true},
+		{"services/**/main.go", "services/main.go", true},
+		{"**/*.proto", "api/v1/payments.proto", true},
+		{"**/*.proto", "api/v1/payments.go", false},
+		{"docs/??.md", "docs/en.md", true},
+		{"docs/??.md", "docs/eng.md", false},
+		{"services/payments/**", "services/payments", true},
+		{"services/payments/main.go", "services/payments", false},
+	}
+	for _, test := range tests {
+		if got := matchPath(test.pattern, test.file); got != test.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", test.pattern, test.file, got, test.want)
+		}
+	}
+}
+
+func TestPathFilterPrefix(t *testing.T) {
+	tests := []struct {
+		pattern, prefix string
+		ok              bool
+	}{
+		{"services/payments", "services/payments", true},
+		{"services/payments/", "services/payments", true},
+		{"services/payments/**", "services/payments", true},
+		{"services/*/main.go", "", false},
+		{"services/**/main.go", "", false},
+		{"docs/?", "", false},
+		{"**", "", false},
+		{"/", "", false},
+	}
+	for _, test := range tests {
+		prefix, ok := pathFilterPrefix(test.pattern)
+		if prefix != test.prefix || ok != test.ok {
+			t.Errorf("pathFilterPrefix(%q) = %q, %v, want %q, %v", test.pattern, prefix, ok, test.prefix, test.ok)
+		}
+	}
+}
+
+func TestCheckPaths(t *testing.T) {
+	tests := []struct {
+		paths []string
+		valid bool
+	}{
+		{nil, true},
+		{[]string{"services/payments", "libs/**/*.go"}, true},
+		{[]string{"services//payments"}, false},
+		{[]string{"services/[payments"}, false},
+		{[]string{""}, false},
+	}
+	for _, test := range tests {
+		if err := checkPaths(Service{Service: "web", Paths: test.paths}); (err == nil) != test.valid {
+			t.Errorf("checkPaths(%q) = %v, want valid %v", test.paths, err, test.valid)
+		}
+	}
+}
+
+func TestFetchServiceCommitsByPath(t *testing.T) {
+	provider := fakeProvider{
+		commits: map[string][]Commit{
+			"":        {{SHA: "c3", Date: "2026-03-03"}, {SHA: "c2", Date: "2026-03-02"}, {SHA: "c1", Date: "2026-03-01"}},
+			"api":     {{SHA: "c3", Date: "2026-03-03"}, {SHA: "c1", Date: "2026-03-01"}},
+			"web/src": {{SHA: "c3", Date: "2026-03-03"}, {SHA: "c2", Date: "2026-03-02"}},
+			"api/v1":  {{SHA: "c1", Date: "2026-03-01"}},
+		},
+		files: map[string][]string{
+			"c1": {"api/v1/payments.proto"},
+			"c2": {"web/src/app.ts"},
+			"c3": {"README.md"},
+		},
+	}
+	tests := []struct {
+		paths []string
+		want  string
+	}{
+		{nil, "[c3 c2 c1]"},
+		{[]string{"api"}, "[c3 c1]"},
+		// Merged newest first without duplicates
+		{[]string{"api/v1", "web/src/**"}, "[c3 c2 c1]"},
+		// Wildcards are matched against the changed files.
+		{[]string{"**/*.proto"}, "[c1]"},
+		{[]string{"web/*/app.ts", "api"}, "[c2 c1]"},
+	}
+	for _, test := range tests {
+		service := Service{Service: "web", Repo: "acme/web", Paths: test.paths}
+		commits, err := fetchServiceCommits(context.Background(), provider, service, "2026-03-01", "2026-03-31")
+		if err != nil {
+			t.Fatalf("fetchServiceCommits(%q): %v", test.paths, err)
+		}
+		var shas []string
+		for _, c := range commits {
+			shas = append(shas, c.SHA)
+		}
+		if fmt.Sprint(shas) != test.want {
+			t.Errorf("commits of %q = %v, want %s", test.paths, shas, test.want)
+		}
+	}
+}
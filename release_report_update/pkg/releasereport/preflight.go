@@ -0,0 +1,182 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Provider that can check its credentials and access to repositories without
+// fetching a report, for -validate-config
+type accessChecker interface {
+	// checkCredentials describes the credentials in use, such as the
+	// token's scopes, or fails when the API rejects them or they lack a
+	// needed scope.
+	checkCredentials(ctx context.Context) (string, error)
+	// checkRepo fails unless repo can be read with the credentials.
+	checkRepo(ctx context.Context, repo string) error
+}
+
+// Check the credentials of every provider in use and access to every
+// service's repository, logging each result to the logger of opts. Reports
+// whether all passed.
+func Preflight(ctx context.Context, providers map[string]Provider, services []Service, opts Options) bool {
+	ctx, logger := opts.Context(ctx), opts.logger()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	passed := true
+	for _, name := range names {
+		checker, ok := providers[name].(accessChecker)
+		if !ok {
+			continue
+		}
+		credentials, err := checker.checkCredentials(ctx)
+		if err != nil {
+			logger.Warn("credentials check failed", "provider", name, "error", err)
+			passed = false
+			continue
+		}
+		logger.Info("credentials valid", "provider", name, "credentials", credentials)
+		for _, service := range services {
+			if service.provider() != name {
+				continue
+			}
+			if err := checker.checkRepo(ctx, service.Repo); err != nil {
+				logger.Warn("repository not readable", "service", service.Service, "repo", service.Repo, "error", err)
+				passed = false
+			} else {
+				logger.Info("repository readable", "service", service.Service, "repo", service.Repo)
+			}
+		}
+	}
+	return passed
+}
+
+// Whether the scopes of a token include one of scopes
+func hasScope(list []string, scopes ...string) bool {
+	for _, have := range list {
+		for _, want := range scopes {
+			if strings.TrimSpace(have) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check the GitHub token and its scopes, or the GitHub App installation
+func (api githubAPI) checkCredentials(ctx context.Context) (string, error) {
+	if api.app != nil {
+		if _, err := api.app.installationToken(ctx); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("GitHub App %d, installation %d", api.app.appID, api.app.installationID), nil
+	}
+	resp, err := apiGet(ctx, api.client, api.baseURL+"/user", "Authorization", "token "+api.token)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("GitHub rejected the token (%w); check -token, GITHUB_TOKEN or `gh auth status`", errUnauthorized)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned %s for the token's user", resp.Status)
+	}
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return "", fmt.Errorf("decoding GitHub user: %v", err)
+	}
+	// Only classic tokens have scopes; fine-grained ones are granted
+	// repositories, which checkRepo covers.
+	header, classic := resp.Header["X-Oauth-Scopes"]
+	if !classic {
+		return fmt.Sprintf("GitHub fine-grained token of %s", user.Login), nil
+	}
+	scopes := strings.Split(strings.Join(header, ","), ",")
+	if !hasScope(scopes, "repo", "public_repo") {
+		return "", fmt.Errorf("GitHub token of %s lacks the repo scope (has: %s)", user.Login, strings.Join(header, ", "))
+	}
+	return fmt.Sprintf("GitHub token of %s, scopes: %s", user.Login, strings.Join(header, ", ")), nil
+}
+
+// Check that the GitHub repository can be read
+func (api githubAPI) checkRepo(ctx context.Context, repo string) error {
+	authorization, err := api.authorization(ctx)
+	if err != nil {
+		return err
+	}
+	resp, err := apiGetAs(ctx, api.client, fmt.Sprintf("%s/repos/%s", api.baseURL, repo), "Authorization", authorization, api.cacheIdentity(authorization))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errors.New("not found, or not readable with the GitHub credentials")
+	}
+	return fmt.Errorf("GitHub API returned %s", resp.Status)
+}
+
+// Check the GitLab token and its scopes
+func (api gitlabAPI) checkCredentials(ctx context.Context) (string, error) {
+	resp, err := apiGet(ctx, api.client, api.baseURL+"/personal_access_tokens/self", "PRIVATE-TOKEN", api.token)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("GitLab rejected the token (%w); check -gitlab-token or GITLAB_TOKEN", errUnauthorized)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		// GitLab before 15.5 cannot describe the token in use.
+		return "GitLab token", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitLab API returned %s for the token", resp.Status)
+	}
+	var token struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresAt string   `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding GitLab token: %v", err)
+	}
+	if !hasScope(token.Scopes, "api", "read_api") {
+		return "", fmt.Errorf("GitLab token %q lacks the read_api scope (has: %s)", token.Name, strings.Join(token.Scopes, ", "))
+	}
+	described := fmt.Sprintf("GitLab token %q, scopes: %s", token.Name, strings.Join(token.Scopes, ", "))
+	if token.ExpiresAt != "" {
+		described += ", expires " + token.ExpiresAt
+	}
+	return described, nil
+}
+
+// Check that the GitLab project can be read
+func (api gitlabAPI) checkRepo(ctx context.Context, repo string) error {
+	resp, err := apiGet(ctx, api.client, fmt.Sprintf("%s/projects/%s", api.baseURL, url.PathEscape(repo)), "PRIVATE-TOKEN", api.token)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return errors.New("not found, or not readable with the GitLab token")
+	}
+	return fmt.Errorf("GitLab API returned %s", resp.Status)
+}
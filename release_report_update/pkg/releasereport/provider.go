@@ -0,0 +1,231 @@
+package releasereport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Names of the supported source code hosts, used as "provider" in config.json
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// errUnauthorized is returned when a provider rejects its token.
+var errUnauthorized = errors.New("401 Unauthorized")
+
+// errLastPage stops paging early when a page callback has seen all it needs.
+var errLastPage = errors.New("last page")
+
+// Provider fetches commits from a source code host
+type Provider interface {
+	// Commits returns all commits on branch of repo between since and until
+	// (YYYY-MM-DD), newest first, limited to those touching path (a file or
+	// directory) unless it is empty.
+	Commits(ctx context.Context, repo, branch, since, until, path string) ([]Commit, error)
+	// CommitFiles returns the paths of the files a commit changed.
+	CommitFiles(ctx context.Context, repo, sha string) ([]string, error)
+	// Releases returns the published releases of repo created between since
+	// and until (YYYY-MM-DD, inclusive), newest first.
+	Releases(ctx context.Context, repo, since, until string) ([]Release, error)
+	// Compare returns the commits reachable from head but not from base
+	// (tags, branches or SHAs), newest first.
+	Compare(ctx context.Context, repo, base, head string) ([]Commit, error)
+	// PullRequests returns the pull (merge) requests of repo merged between
+	// since and until (YYYY-MM-DD, inclusive), most recently merged first.
+	PullRequests(ctx context.Context, repo, since, until string) ([]PullRequest, error)
+	// CommittedBefore reports whether the author with the given email has a
+	// commit in repo before the date (YYYY-MM-DD).
+	CommittedBefore(ctx context.Context, repo, email, date string) (bool, error)
+	// Deployments returns the successful deployments of repo to environment
+	// up to until (YYYY-MM-DD, inclusive), newest first, ending with the
+	// last one before since.
+	Deployments(ctx context.Context, repo, environment, since, until string) ([]Deployment, error)
+	// Branches returns the branch names of repo.
+	Branches(ctx context.Context, repo string) ([]string, error)
+	// Tags returns the tags of repo whose names match pattern (see
+	// path.Match), in no particular order.
+	Tags(ctx context.Context, repo, pattern string) ([]Tag, error)
+	// SquashedCommits returns the original commits of the pull (merge)
+	// request that sha squash- or rebase-merged, without sha itself, or
+	// none when sha merged no pull request that way.
+	SquashedCommits(ctx context.Context, repo, sha string) ([]string, error)
+	// Verification returns the signature verification of a commit (see
+	// Commit.Verification).
+	Verification(ctx context.Context, repo, sha string) (string, error)
+	// ClosedIssues returns the issues of repo that pull (merge) requests
+	// merged between since and until (YYYY-MM-DD, inclusive) closed, most
+	// recently closed first.
+	ClosedIssues(ctx context.Context, repo, since, until string) ([]ClosedIssue, error)
+	// CommitStats returns the lines a commit added and deleted and the
+	// number of files it changed.
+	CommitStats(ctx context.Context, repo, sha string) (CommitStats, error)
+	// CommitPull returns the merged pull (merge) request that brought a
+	// commit into repo, or nil when the commit was pushed directly.
+	CommitPull(ctx context.Context, repo, sha string) (*PullRequest, error)
+}
+
+// Provider-neutral commit data
+type Commit struct {
+	SHA         string
+	Message     string
+	Author      string
+	AuthorEmail string
+	URL         string
+	Date        string
+	Merge       bool // more than one parent
+	// Verification is "verified" for a verified signature, else why not,
+	// such as "unsigned"; "" until known.
+	Verification string
+	Stats        *CommitStats // size of the change, with -stats
+}
+
+// Size of the change a commit made
+type CommitStats struct {
+	Additions int // lines
+	Deletions int
+	Files     int // files changed
+}
+
+// Whether the commit's signature is known not to be verified
+func (c Commit) Unverified() bool {
+	return c.Verification != "" && c.Verification != verifiedSignature
+}
+
+// Commit.Verification of a verified signature
+const verifiedSignature = "verified"
+
+// Branch reported when a service sets none
+const defaultBranch = "main"
+
+// Branch of a service, defaulting to main
+func (s Service) branch() string {
+	if s.Branch == "" {
+		return defaultBranch
+	}
+	return s.Branch
+}
+
+// Provider name of a service, defaulting to GitHub
+func (s Service) provider() string {
+	if s.Provider == "" {
+		return ProviderGitHub
+	}
+	return strings.ToLower(s.Provider)
+}
+
+// Check that a service names a known provider
+func checkProvider(service Service) error {
+	switch service.provider() {
+	case ProviderGitHub, ProviderGitLab:
+		return nil
+	}
+	return fmt.Errorf("service %s: unknown provider %q (want %q or %q)", service.Service, service.Provider, ProviderGitHub, ProviderGitLab)
+}
+
+// Whether any service uses the named provider
+func UsesProvider(services []Service, name string) bool {
+	for _, service := range services {
+		if service.provider() == name {
+			return true
+		}
+	}
+	return false
+}
+
+var nextLinkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// URL of the next page from the Link header of a paginated response, or ""
+// on the last page. GitHub and GitLab both paginate this way.
+func nextPageURL(resp *http.Response) string {
+	if match := nextLinkPattern.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// Provider-neutral release (a published tag) data
+type Release struct {
+	Tag        string
+	Name       string
+	URL        string
+	Date       string
+	Notes      string // release notes as written, usually Markdown
+	Prerelease bool
+	Assets     []Asset
+}
+
+// Downloadable file attached to a release
+type Asset struct {
+	Name string
+	URL  string
+}
+
+// Whether an RFC 3339 timestamp falls on or between the dates startDate and
+// endDate (YYYY-MM-DD) in loc
+func inPeriod(timestamp, startDate, endDate string, loc *time.Location) bool {
+	if len(timestamp) < len("2006-01-02") {
+		return false
+	}
+	day := dateOf(timestamp, loc)
+	return day >= startDate && day <= endDate
+}
+
+// Date (YYYY-MM-DD) of an RFC 3339 timestamp in loc
+func dateOf(timestamp string, loc *time.Location) string {
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		return t.In(loc).Format("2006-01-02")
+	}
+	if len(timestamp) < len("2006-01-02") {
+		return timestamp
+	}
+	return timestamp[:len("2006-01-02")]
+}
+
+// RFC 3339 timestamp shown in loc; other strings are returned as they are
+func localTime(timestamp string, loc *time.Location) string {
+	if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		return t.In(loc).Format(time.RFC3339)
+	}
+	return timestamp
+}
+
+// First instant of a YYYY-MM-DD date in loc, as an RFC 3339 UTC
+// timestamp for API queries
+func periodStart(date string, loc *time.Location) string {
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return date
+	}
+	return day.UTC().Format(time.RFC3339)
+}
+
+// Last second of a YYYY-MM-DD date in loc, as an RFC 3339 UTC
+// timestamp for API queries
+func periodEnd(date string, loc *time.Location) string {
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return date
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Second).UTC().Format(time.RFC3339)
+}
+
+// Successful deployment of a commit to an environment
+type Deployment struct {
+	SHA         string
+	Ref         string
+	Environment string
+	Date        string // when the deployment succeeded
+}
+
+// Tag with the date of its commit
+type Tag struct {
+	Name string
+	SHA  string
+	Date string
+}
@@ -0,0 +1,135 @@
+package releasereport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     string
+		valid    bool
+	}{
+		{"", ProviderGitHub, true},
+		{"github", ProviderGitHub, true},
+		{"GitLab", ProviderGitLab, true},
+		{"bitbucket", "bitbucket", false},
+	}
+	for _, test := range tests {
+		service := Service{Service: "web", Provider: test.provider}
+		if got := service.provider(); got != test.want {
+			t.Errorf("provider() of %q = %q, want %q", test.provider, got, test.want)
+		}
+		if err := checkProvider(service); (err == nil) != test.valid {
+			t.Errorf("checkProvider(%q) = %v, want valid %v", test.provider, err, test.valid)
+		}
+	}
+}
+
+func TestUsesProvider(t *testing.T) {
+	services := []Service{{Service: "web"}, {Service: "api", Provider: "GitLab"}}
+	tests := []struct {
+		services []Service
+		name     string
+		want     bool
+	}{
+		{services, ProviderGitHub, true},
+		{services, ProviderGitLab, true},
+		{services[:1], ProviderGitLab, false},
+		{nil, ProviderGitHub, false},
+	}
+	for _, test := range tests {
+		if got := UsesProvider(test.services, test.name); got != test.want {
+			t.Errorf("UsesProvider(%d services, %q) = %v, want %v", len(test.services), test.name, got, test.want)
+		}
+	}
+}
+
+func TestPeriodDatesInLocation(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*60*60+30*60)
+	seattle := time.FixedZone("PST", -8*60*60)
+	tests := []struct {
+		loc                 *time.Location
+		start, end          string // of 2026-03-01 – 2026-03-31
+		lateMarch, earlyDay string // dates of 2026-03-31T23:30:00Z and 2026-03-01T03:00:00Z
+	}{
+		{time.UTC, "2026-03-01T00:00:00Z", "2026-03-31T23:59:59Z", "2026-03-31", "2026-03-01"},
+		{kolkata, "2026-02-28T18:30:00Z", "2026-03-31T18:29:59Z", "2026-04-01", "2026-03-01"},
+		{seattle, "2026-03-01T08:00:00Z", "2026-04-01T07:59:59Z", "2026-03-31", "2026-02-28"},
+	}
+	for _, test := range tests {
+		if got := periodStart("2026-03-01", test.loc); got != test.start {
+			t.Errorf("periodStart in %s = %s, want %s", test.loc, got, test.start)
+		}
+		if got := periodEnd("2026-03-31", test.loc); got != test.end {
+			t.Errorf("periodEnd in %s = %s, want %s", test.loc, got, test.end)
+		}
+		if got := dateOf("2026-03-31T23:30:00Z", test.loc); got != test.lateMarch {
+			t.Errorf("dateOf(2026-03-31T23:30:00Z) in %s = %s, want %s", test.loc, got, test.lateMarch)
+		}
+		if got := dateOf("2026-03-01T03:00:00Z", test.loc); got != test.earlyDay {
+			t.Errorf("dateOf(2026-03-01T03:00:00Z) in %s = %s, want %s", test.loc, got, test.earlyDay)
+		}
+		for timestamp, want := range map[string]bool{
+			"2026-03-31T23:30:00Z": test.lateMarch == "2026-03-31",
+			"2026-03-01T03:00:00Z": test.earlyDay == "2026-03-01",
+		} {
+			if got := inPeriod(timestamp, "2026-03-01", "2026-03-31", test.loc); got != want {
+				t.Errorf("inPeriod(%s) in %s = %v, want %v", timestamp, test.loc, got, want)
+			}
+		}
+	}
+}
+
+func TestDateOfNonTimestamps(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*60*60+30*60)
+	tests := []struct {
+		value, date string
+		inMarch     bool
+	}{
+		{"2026-03-05", "2026-03-05", true},
+		{"2026-03-05 22:00:00 +0000", "2026-03-05", true},
+		{"2026-04-01", "2026-04-01", false},
+		{"bad", "bad", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		if got := dateOf(test.value, kolkata); got != test.date {
+			t.Errorf("dateOf(%q) = %q, want %q", test.value, got, test.date)
+		}
+		if got := inPeriod(test.value, "2026-03-01", "2026-03-31", kolkata); got != test.inMarch {
+			t.Errorf("inPeriod(%q) = %v, want %v", test.value, got, test.inMarch)
+		}
+	}
+}
+
+func TestLocalTime(t *testing.T) {
+	tests := []struct {
+		value string
+		loc   *time.Location
+		want  string
+	}{
+		{"2026-03-31T23:30:00Z", time.UTC, "2026-03-31T23:30:00Z"},
+		{"2026-03-31T23:30:00Z", time.FixedZone("IST", 5*60*60+30*60), "2026-04-01T05:00:00+05:30"},
+		{"2026-03-31T23:30:00+02:00", time.FixedZone("PST", -8*60*60), "2026-03-31T13:30:00-08:00"},
+		{"2026-03-31", time.UTC, "2026-03-31"},
+		{"", time.UTC, ""},
+	}
+	for _, test := range tests {
+		if got := localTime(test.value, test.loc); got != test.want {
+			t.Errorf("localTime(%q) in %s = %q, want %q", test.value, test.loc, got, test.want)
+		}
+	}
+}
+
+func TestInvalidPeriodDates(t *testing.T) {
+	for _, date := range []string{"", "2026-3-1", "yesterday"} {
+		if got := periodStart(date, time.UTC); got != date {
+			t.Errorf("periodStart(%q) = %q, want it unchanged", date, got)
+		}
+		if got := periodEnd(date, time.UTC); got != date {
+			t.Errorf("periodEnd(%q) = %q, want it unchanged", date, got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package releasereport
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Merged pull request (GitHub) or merge request (GitLab)
+type PullRequest struct {
+	Number    int
+	Title     string
+	Author    string
+	URL       string
+	MergedAt  string
+	Labels    []string
+	Reviewers []string
+	Issues    []Issue // issues the description closes
+	// Description as written, where -trace looks for ticket references
+	Description string
+}
+
+// Issue linked from a pull request
+type Issue struct {
+	Ref string // "#123" or "owner/repo#123"
+	URL string
+}
+
+// Issue closed by merging a pull (merge) request
+type ClosedIssue struct {
+	Number   int
+	Title    string
+	URL      string
+	ClosedAt string
+	Pull     int // number of the pull request that closed it
+	PullURL  string
+}
+
+// Closing keywords understood by GitHub and GitLab, e.g. "Fixes #12" or
+// "closes org/repo#7"
+var closingIssuePattern = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?|implement(?:s|ed)?)\s*:?\s+([\w.-]+/[\w./-]+)?#(\d+)\b`)
+
+// Issues closed by a pull request description. issueURL returns the URL of
+// an issue number in repo, where "" is the pull request's own repository.
+func linkedIssues(description string, issueURL func(repo, number string) string) []Issue {
+	var issues []Issue
+	seen := make(map[string]bool)
+	for _, match := range closingIssuePattern.FindAllStringSubmatch(description, -1) {
+		ref := match[1] + "#" + match[2]
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		issues = append(issues, Issue{Ref: ref, URL: issueURL(match[1], match[2])})
+	}
+	return issues
+}
+
+// Add value to list unless it is empty or already in it
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,104 @@
+package releasereport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sprints last two weeks unless "sprint" says otherwise
+const defaultSprintDays = 14
+
+// Named periods of -range; iso-week:YYYY-Www takes a week as well
+var RangePresets = []string{"this-week", "last-week", "this-month", "last-month", "this-sprint", "last-sprint"}
+
+// Check the sprint settings before any report is generated
+func (c SprintConfig) validate() error {
+	if !ValidDate(c.Start) {
+		return fmt.Errorf("sprint.start %q is not a date (want YYYY-MM-DD)", c.Start)
+	}
+	if c.Days < 0 {
+		return fmt.Errorf("sprint.length_days %d is negative", c.Days)
+	}
+	return nil
+}
+
+// Sprint length in days
+func (c SprintConfig) days() int {
+	if c.Days == 0 {
+		return defaultSprintDays
+	}
+	return c.Days
+}
+
+// Period (YYYY-MM-DD dates) of a -range preset as of today. Sprint presets
+// need the sprint cadence.
+func ResolveRange(preset string, today time.Time, sprint *SprintConfig) (startDate, endDate string, err error) {
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	// Days since Monday, for ISO weeks
+	weekday := (int(day.Weekday()) + 6) % 7
+	var first, last time.Time
+	switch {
+	case preset == "this-week":
+		first, last = day.AddDate(0, 0, -weekday), day
+	case preset == "last-week":
+		first = day.AddDate(0, 0, -weekday-7)
+		last = first.AddDate(0, 0, 6)
+	case preset == "this-month":
+		first, last = day.AddDate(0, 0, 1-day.Day()), day
+	case preset == "last-month":
+		last = day.AddDate(0, 0, -day.Day())
+		first = last.AddDate(0, 0, 1-last.Day())
+	case preset == "this-sprint" || preset == "last-sprint":
+		if sprint == nil {
+			return "", "", fmt.Errorf("-range %s needs a \"sprint\" section in the config", preset)
+		}
+		anchor, _ := time.Parse("2006-01-02", sprint.Start) // checked in Validate
+		length := sprint.days()
+		// Sprints before the anchor follow the same cadence.
+		offset := int(day.Sub(anchor).Hours()/24) % length
+		if offset < 0 {
+			offset += length
+		}
+		first = day.AddDate(0, 0, -offset)
+		last = day
+		if preset == "last-sprint" {
+			last = first.AddDate(0, 0, -1)
+			first = first.AddDate(0, 0, -length)
+		}
+	case strings.HasPrefix(preset, "iso-week:"):
+		if first, err = isoWeekStart(strings.TrimPrefix(preset, "iso-week:")); err != nil {
+			return "", "", err
+		}
+		last = first.AddDate(0, 0, 6)
+	default:
+		return "", "", fmt.Errorf("unknown -range %q (want %s or iso-week:YYYY-Www)", preset, strings.Join(RangePresets, ", "))
+	}
+	return first.Format("2006-01-02"), last.Format("2006-01-02"), nil
+}
+
+// Monday of an ISO week such as 2025-W14
+func isoWeekStart(week string) (time.Time, error) {
+	invalid := errors.New("iso-week " + strconv.Quote(week) + " is not a week such as 2025-W14")
+	parts := strings.SplitN(week, "-W", 2)
+	if len(parts) != 2 {
+		return time.Time{}, invalid
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts[0]) != 4 {
+		return time.Time{}, invalid
+	}
+	number, err := strconv.Atoi(parts[1])
+	if err != nil || number < 1 || number > 53 {
+		return time.Time{}, invalid
+	}
+	// January 4th is always in week 1.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(number-1)*7)
+	if y, w := monday.ISOWeek(); y != year || w != number {
+		return time.Time{}, fmt.Errorf("%d has no ISO week %d", year, number)
+	}
+	return monday, nil
+}
@@ -0,0 +1,90 @@
+package releasereport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveRange(t *testing.T) {
+	wednesday := time.Date(2026, time.October, 14, 10, 0, 0, 0, time.UTC)
+	sprint := &SprintConfig{Start: "2026-01-05"}
+	tenDays := &SprintConfig{Start: "2026-11-02", Days: 10} // anchored after today
+	tests := []struct {
+		preset     string
+		today      time.Time
+		sprint     *SprintConfig
+		start, end string
+	}{
+		{"this-week", wednesday, nil, "2026-10-12", "2026-10-14"},
+		{"this-week", time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC), nil, "2026-10-12", "2026-10-12"},
+		{"this-week", time.Date(2026, time.October, 18, 23, 0, 0, 0, time.UTC), nil, "2026-10-12", "2026-10-18"},
+		{"last-week", wednesday, nil, "2026-10-05", "2026-10-11"},
+		{"this-month", wednesday, nil, "2026-10-01", "2026-10-14"},
+		{"last-month", wednesday, nil, "2026-09-01", "2026-09-30"},
+		{"last-month", time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC), nil, "2026-02-01", "2026-02-28"},
+		{"last-month", time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC), nil, "2025-12-01", "2025-12-31"},
+		{"this-sprint", wednesday, sprint, "2026-10-12", "2026-10-14"},
+		{"last-sprint", wednesday, sprint, "2026-09-28", "2026-10-11"},
+		{"this-sprint", wednesday, tenDays, "2026-10-13", "2026-10-14"},
+		{"last-sprint", wednesday, tenDays, "2026-10-03", "2026-10-12"},
+		{"iso-week:2026-W42", wednesday, nil, "2026-10-12", "2026-10-18"},
+		{"iso-week:2026-W01", wednesday, nil, "2025-12-29", "2026-01-04"},
+		{"iso-week:2020-W53", wednesday, nil, "2020-12-28", "2021-01-03"},
+		// Today is the date in the time zone of today, not in UTC.
+		{"this-week", time.Date(2026, time.October, 18, 23, 30, 0, 0, time.FixedZone("PST", -8*60*60)), nil, "2026-10-12", "2026-10-18"},
+		{"this-month", time.Date(2026, time.November, 1, 3, 0, 0, 0, time.FixedZone("IST", 5*60*60+30*60)), nil, "2026-11-01", "2026-11-01"},
+	}
+	for _, test := range tests {
+		start, end, err := ResolveRange(test.preset, test.today, test.sprint)
+		if err != nil {
+			t.Errorf("ResolveRange(%s, %s): %v", test.preset, test.today, err)
+			continue
+		}
+		if start != test.start || end != test.end {
+			t.Errorf("ResolveRange(%s, %s) = %s – %s, want %s – %s", test.preset, test.today, start, end, test.start, test.end)
+		}
+	}
+}
+
+func TestResolveRangeErrors(t *testing.T) {
+	today := time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC)
+	for _, preset := range []string{"", "yesterday", "this-sprint", "last-sprint", "iso-week:", "iso-week:2025-W53"} {
+		if start, end, err := ResolveRange(preset, today, nil); err == nil {
+			t.Errorf("ResolveRange(%q) = %s – %s, want an error", preset, start, end)
+		}
+	}
+}
+
+func TestISOWeekStart(t *testing.T) {
+	tests := []struct {
+		week   string
+		monday string // "" for invalid weeks
+	}{
+		{"2025-W14", "2025-03-31"},
+		{"2026-W01", "2025-12-29"},
+		{"2027-W01", "2027-01-04"},
+		{"2026-W53", "2026-12-28"},
+		{"2025-W53", ""},
+		{"2020-W53", "2020-12-28"},
+		{"2026-W00", ""},
+		{"2026-W54", ""},
+		{"2026-14", ""},
+		{"26-W14", ""},
+		{"2026-Wxx", ""},
+		{"2026-W14-3", ""},
+	}
+	for _, test := range tests {
+		monday, err := isoWeekStart(test.week)
+		if test.monday == "" {
+			if err == nil {
+				t.Errorf("isoWeekStart(%q) = %s, want an error", test.week, monday.Format("2006-01-02"))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("isoWeekStart(%q): %v", test.week, err)
+		} else if got := monday.Format("2006-01-02"); got != test.monday {
+			t.Errorf("isoWeekStart(%q) = %s, want %s", test.week, got, test.monday)
+		}
+	}
+}
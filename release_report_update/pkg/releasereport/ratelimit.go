@@ -0,0 +1,72 @@
+package releasereport
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Rate limit handling for API calls
+const (
+	maxRateLimitRetries = 5                // retries of one request while rate limited
+	maxRateLimitWait    = 15 * time.Minute // longest single wait before giving up
+	lowQuotaThreshold   = 100              // remaining requests that trigger a warning
+)
+
+// errRateLimited is returned when a provider keeps rate limiting a request.
+var errRateLimited = errors.New("API rate limit exceeded")
+
+// Value of a rate limit header, e.g. "Remaining": GitHub sends
+// X-RateLimit-Remaining, GitLab RateLimit-Remaining
+func rateLimitHeader(resp *http.Response, name string) string {
+	if value := resp.Header.Get("X-RateLimit-" + name); value != "" {
+		return value
+	}
+	return resp.Header.Get("RateLimit-" + name)
+}
+
+// Work out how long to wait before retrying a 403 or 429 response, and
+// whether it was a rate limit at all
+func rateLimitWait(resp *http.Response, body []byte, backoff time.Duration) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if rateLimitHeader(resp, "Remaining") == "0" {
+		if reset, err := strconv.ParseInt(rateLimitHeader(resp, "Reset"), 10, 64); err == nil {
+			wait := time.Until(time.Unix(reset, 0)) + time.Second
+			if wait < time.Second {
+				wait = time.Second
+			}
+			return wait, true
+		}
+		return backoff, true
+	}
+	// Secondary rate limits come without quota headers.
+	if resp.StatusCode == http.StatusTooManyRequests || bytes.Contains(bytes.ToLower(body), []byte("rate limit")) {
+		return backoff, true
+	}
+	return 0, false
+}
+
+// Log an API call at debug level with its status, duration and the
+// remaining quota, warning when the quota runs low
+func logRequest(resp *http.Response, elapsed time.Duration) {
+	logger := settingsOf(resp.Request.Context()).logger
+	args := []interface{}{"method", resp.Request.Method, "url", resp.Request.URL.String(), "status", resp.StatusCode, "duration", elapsed.Round(time.Millisecond)}
+	remaining, err := strconv.Atoi(rateLimitHeader(resp, "Remaining"))
+	if err != nil {
+		logger.Debug("API request", args...)
+		return
+	}
+	limit := rateLimitHeader(resp, "Limit")
+	logger.Debug("API request", append(args, "quota_remaining", remaining, "quota_limit", limit)...)
+	if remaining < lowQuotaThreshold {
+		reset := "unknown"
+		if unix, err := strconv.ParseInt(rateLimitHeader(resp, "Reset"), 10, 64); err == nil {
+			reset = time.Unix(unix, 0).Format("15:04:05")
+		}
+		logger.Warn("API quota low", "host", resp.Request.URL.Host, "remaining", remaining, "limit", limit, "reset", reset)
+	}
+}
@@ -0,0 +1,99 @@
+package releasereport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Create or update a draft GitHub Release per service whose body is the
+// service's notable changes (see -publish-release-notes). Releases are
+// named by version, or by the head ref for services reporting a ref range;
+// published releases are never changed.
+func PublishReleaseNotes(ctx context.Context, providers map[string]Provider, services []Service, reports []ServiceReport, version string, opts Options) {
+	ctx, logger := opts.Context(ctx), opts.logger()
+	for i, report := range reports {
+		service := services[i]
+		api, ok := githubREST(providers[service.provider()])
+		if !ok {
+			logger.Info("not on GitHub; no release notes published", "service", report.Service)
+			continue
+		}
+		changes := changelogChanges(report, opts.subjectLimit())
+		if changes == "" {
+			logger.Info("no notable changes; no release notes published", "service", report.Service)
+			continue
+		}
+		tag := version
+		if report.Range != "" {
+			_, tag, _ = ParseRefRange(report.Range) // checked in Fetch
+		}
+		url, err := api.publishDraftRelease(ctx, report.Repo, tag, service.branch(), strings.TrimPrefix(changes, "\n"))
+		if err != nil {
+			logger.Warn("publishing release notes failed", "repo", report.Repo, "error", err)
+			continue
+		}
+		if url == "" {
+			logger.Info("release already published; release notes not updated", "repo", report.Repo, "tag", tag)
+			continue
+		}
+		logger.Info("draft release notes published", "service", report.Service, "url", url)
+	}
+}
+
+// Create a draft release of repo for tag with the given notes, or update
+// the draft that exists. Returns the release URL, or "" when the release is
+// already published.
+func (api githubAPI) publishDraftRelease(ctx context.Context, repo, tag, target, notes string) (string, error) {
+	var existing *githubRelease
+	next := fmt.Sprintf("%s/repos/%s/releases?per_page=100", api.baseURL, repo)
+	err := api.getPages(ctx, next, repo, func(body io.Reader) error {
+		var page []githubRelease
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return fmt.Errorf("decoding GitHub releases of %s: %v", repo, err)
+		}
+		for i := range page {
+			if page[i].TagName == tag {
+				existing = &page[i]
+				return errLastPage
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if existing != nil && !existing.Draft {
+		return "", nil
+	}
+
+	method, url := "POST", fmt.Sprintf("%s/repos/%s/releases", api.baseURL, repo)
+	payload := map[string]interface{}{"tag_name": tag, "name": tag, "body": notes, "draft": true, "target_commitish": target}
+	if existing != nil {
+		method, url = "PATCH", fmt.Sprintf("%s/repos/%s/releases/%d", api.baseURL, repo, existing.ID)
+		payload = map[string]interface{}{"body": notes}
+	}
+	authorization, err := api.authorization(ctx)
+	if err != nil {
+		return "", err
+	}
+	resp, err := apiSend(ctx, api.client, method, url, "Authorization", authorization, payload)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("GitHub API returned %s; the token needs write access to the repository's contents", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("decoding GitHub release of %s: %v", repo, err)
+	}
+	return release.URL, nil
+}
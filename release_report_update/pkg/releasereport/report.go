@@ -0,0 +1,732 @@
+// Package releasereport fetches the commits, releases or pull requests of
+// services from GitHub and GitLab and renders them as a release report (HTML
+// or CSV), optionally with deployments, metrics and traceability.
+//
+// Providers send their requests through the *http.Client they are made with
+// (see NewHTTPClient). Fetch returns the data of every service, with the
+// failures of single services in their ServiceReport.Errors; RenderHTML and
+// RenderCSV write it out.
+//
+//	github := releasereport.NewGitHub(client, releasereport.DefaultAPIBaseURL, token)
+//	providers := map[string]releasereport.Provider{releasereport.ProviderGitHub: github}
+//	opts := releasereport.Options{Mode: releasereport.ModeCommits, StartDate: "2025-05-01", EndDate: "2025-05-14"}
+//	reports, err := releasereport.Fetch(ctx, providers, config.Services, opts)
+//	err = releasereport.RenderHTML(w, reports, opts)
+package releasereport
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Commits of one service in the report
+type ServiceReport struct {
+	Service  string
+	Repo     string
+	Range    string // compared ref range, or "" for the date period
+	Branch   string // reported branch, or "" for ref ranges and releases
+	Commits  []Commit
+	Groups   []ChangeGroup // Commits by Conventional Commits type
+	Tickets  []Ticket      // "Tickets shipped", when Jira is configured
+	Releases []Release     // in releases mode
+	Pulls    []PullRequest // in pulls mode
+	// Pulls by label section, in pulls mode
+	PullGroups []PullGroup
+	// Commits without a verified signature, with -signatures
+	Unverified int
+	// Release cadence, with -metrics
+	Metrics *ReleaseMetrics
+	// Deployment frequency and lead time, with -dora
+	DORA *DORAMetrics
+	// Author keys (see authorKey) without earlier commits, with -contributors
+	NewContributors []string
+	// Deployed changes per environment, when "environments" are configured
+	Environments []EnvironmentReport
+	// Change volume against the previous archived period, with -history
+	History *PeriodChange
+	// Issues closed by pull requests merged in the period, with -issues
+	ClosedIssues []ClosedIssue
+	// Lines and files changed by the commits, with -stats
+	Stats *ChangeStats
+	// Pull request and tickets of each commit, with -trace
+	Trace []TraceLink
+	// Harness pipeline executions of the service in the period, newest
+	// first, when "harness" is configured
+	HarnessExecutions []HarnessExecution
+	// Revision running in each environment, when "argocd" is configured
+	Live []LiveVersion
+	// Images running in each environment, with -kube
+	Running []RunningImage
+	// Commits and releases not yet deployed per environment, with -drift
+	Drift []Drift
+	// Failures that left (part of) the report of the service empty, e.g. an
+	// unreadable repository or unavailable deployments
+	Errors []error `json:"-"`
+}
+
+// Log (to the logger of ctx) and record a failure that leaves part of the
+// report empty
+func (r *ServiceReport) skip(ctx context.Context, part string, err error) {
+	settingsOf(ctx).logger.Warn("skipping part of the report", "part", part, "service", r.Service, "repo", r.Repo, "error", err)
+	r.Errors = append(r.Errors, fmt.Errorf("%s: %w", part, err))
+}
+
+// Report output formats (see -format)
+var ReportFormats = []string{"html", "csv"}
+
+// What the report lists (see -mode)
+const (
+	ModeCommits  = "commits"
+	ModeReleases = "releases"
+	ModePulls    = "pulls"
+)
+
+var ReportModes = []string{ModeCommits, ModeReleases, ModePulls}
+
+// Services fetched in parallel by default; enough to hide API latency
+// without tripping GitHub's secondary rate limits
+const DefaultConcurrency = 4
+
+// Settings of one report run
+type Options struct {
+	Mode      string // one of ReportModes
+	Format    string // one of ReportFormats
+	StartDate string
+	EndDate   string
+	Compare   string // ref range of -compare, for services without their own
+	Jira      *JiraConfig
+	// Contributors adds the contributor statistics section.
+	Contributors bool
+	// Metrics adds the release metrics table.
+	Metrics bool
+	// Signatures flags commits without a verified signature.
+	Signatures bool
+	// Issues adds the issues closed by pull requests merged in the period.
+	Issues bool
+	// Stats adds the lines and files each commit changed.
+	Stats bool
+	// Trace adds the pull request and tickets of each commit.
+	Trace bool
+	// Drift adds the commits and releases not yet deployed per environment.
+	Drift bool
+	// JiraClient looks up the status of traced tickets; nil without Jira
+	// credentials.
+	JiraClient *JiraClient
+	// Harness lists the pipeline executions of the services; nil for no
+	// Harness section.
+	Harness *HarnessClient
+	// ArgoCD looks up the revision each service runs per environment; nil
+	// for no live versions.
+	ArgoCD *ArgoCDClient
+	// Kubernetes scans the clusters for the images the services run, with
+	// -kube; nil for no deployed version matrix.
+	Kubernetes *KubernetesScanner
+	// DORAEnvironment is the environment the DORA metrics measure, or ""
+	// for no DORA section.
+	DORAEnvironment string
+	// Environments to report deployments of, in commits mode
+	Environments []string
+	// Filter leaves commits out before anything is reported.
+	Filter CommitFilter
+	// PullSections group pull requests by label in pulls mode.
+	PullSections []PullSection
+	// FailFast stops the report at the first service whose data cannot be
+	// fetched instead of marking it unavailable.
+	FailFast bool
+	// Concurrency is the number of services fetched in parallel.
+	Concurrency int
+	// Output is the report file (default: release_report.<format>).
+	Output string
+	// History is the directory of the report archive, or "" for none.
+	History string
+	// Template is the HTML template file, or "" for the built-in views.
+	Template string
+	// Views are the built-in HTML templates rendered from the same data
+	// (see ReportViews): the first to Output, the others next to it (see
+	// viewOutput). Default: ViewDetailed.
+	Views []string
+	// Language of the HTML report strings and dates, one of
+	// ReportLanguages (default: DefaultLanguage).
+	Language string
+	Theme    *ThemeConfig
+	// SubjectLimit is the number of characters of a commit subject shown
+	// before it is cut short with "…": 0 for DefaultSubjectLimit, negative
+	// for no limit.
+	SubjectLimit int
+	// Location is the time zone of the period and of the dates shown
+	// (default: time.Local).
+	Location *time.Location
+	// CacheDir stores API responses, revalidated with ETags, or "" for no
+	// cache (see DefaultCacheDir). Entries unused for 30 days are removed.
+	CacheDir string
+	// Logger receives the progress, warnings and (at debug level) every API
+	// call; nil discards them.
+	Logger Logger
+}
+
+// Reported period for titles: the -compare range, else the dates
+func (o Options) Period() string {
+	if o.Compare != "" {
+		return o.Compare
+	}
+	return o.StartDate + " – " + o.EndDate
+}
+
+// Check the settings of a run before anything is fetched, so callers other
+// than the command line cannot pass a broken mode, period or ref range
+func (o Options) check(services []Service) error {
+	if !contains(ReportModes, o.Mode) {
+		return fmt.Errorf("unknown mode %q (want %s)", o.Mode, strings.Join(ReportModes, ", "))
+	}
+	if o.Format != "" && !contains(ReportFormats, o.Format) {
+		return fmt.Errorf("unknown format %q (want %s)", o.Format, strings.Join(ReportFormats, " or "))
+	}
+	needDates := false
+	for _, service := range services {
+		refRange := service.RefRange(o.Compare)
+		if refRange == "" {
+			needDates = true
+			continue
+		}
+		if o.Mode != ModeCommits {
+			return fmt.Errorf("ref ranges need mode %s", ModeCommits)
+		}
+		if _, _, err := ParseRefRange(refRange); err != nil {
+			return fmt.Errorf("%s: %w", service.Service, err)
+		}
+	}
+	if needDates {
+		if err := CheckPeriod(o.StartDate, o.EndDate); err != nil {
+			return err
+		}
+	}
+	if o.Jira != nil {
+		if _, err := o.Jira.compile(); err != nil {
+			return err
+		}
+	}
+	for _, view := range o.Views {
+		if _, err := viewTemplate(view); err != nil {
+			return err
+		}
+	}
+	if _, err := LoadLocale(o.Language); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Fetch the commits (or releases or pull requests) of every service and save
+// the report in the given format. Returns the fetched data and the report
+// file.
+func Generate(ctx context.Context, providers map[string]Provider, services []Service, opts Options) ([]ServiceReport, string, error) {
+	reports, err := Fetch(ctx, providers, services, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	logger := opts.logger()
+	if opts.History != "" {
+		recordHistory(reports, opts)
+	}
+
+	output := opts.Output
+	if output == "" {
+		output = "release_report." + opts.Format
+	}
+	switch opts.Format {
+	case "csv":
+		if err := writeCSVReport(output, reports, opts); err != nil {
+			return nil, "", fmt.Errorf("writing CSV file: %w", err)
+		}
+	default:
+		for i, view := range opts.views() {
+			viewOpts, filename := opts, output
+			viewOpts.Views = []string{view}
+			if i > 0 {
+				filename = viewOutput(output, view)
+			}
+			if err := writeHTMLReport(filename, reports, viewOpts); err != nil {
+				return nil, "", fmt.Errorf("writing HTML file: %w", err)
+			}
+			if i > 0 {
+				logger.Info("report generated", "file", filename, "format", opts.Format, "view", view)
+			}
+		}
+	}
+	logger.Info("report generated", "file", output, "format", opts.Format)
+	return reports, output, nil
+}
+
+// Fetch the data of every service, up to opts.Concurrency services at a
+// time. Only errors that doom the whole report are returned; failures of
+// single services are kept in their Errors, unless opts.FailFast.
+func Fetch(ctx context.Context, providers map[string]Provider, services []Service, opts Options) ([]ServiceReport, error) {
+	if err := opts.check(services); err != nil {
+		return nil, err
+	}
+	ctx, logger := opts.Context(ctx), opts.logger()
+	for _, service := range services {
+		if providers[service.provider()] == nil {
+			return nil, fmt.Errorf("no %s provider for %s", service.provider(), service.Service)
+		}
+	}
+	var ticketPattern *regexp.Regexp
+	if opts.Jira != nil {
+		ticketPattern, _ = opts.Jira.compile() // checked by opts.check
+	}
+
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(services) {
+		workers = len(services)
+	}
+	for _, provider := range providers {
+		if batch, ok := provider.(batchPrefetcher); ok {
+			err := batch.prefetch(ctx, services, opts)
+			if fatal(ctx, err) {
+				return nil, err
+			}
+			if err != nil {
+				logger.Warn("batched fetching failed; fetching service by service", "error", err)
+			}
+		}
+	}
+	logger.Info("fetching services", "services", len(services), "concurrency", workers)
+
+	// Reports keep the configured service order whatever order the
+	// fetches finish in.
+	reports := make([]ServiceReport, len(services))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu       sync.Mutex
+		fatalErr error
+		done     int
+		wg       sync.WaitGroup
+	)
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				report, err := fetchServiceReport(ctx, providers[services[i].provider()], services[i], opts, ticketPattern)
+				if err == nil && opts.FailFast && len(report.Errors) > 0 {
+					err = fmt.Errorf("%s: %w", report.Service, report.Errors[0])
+				}
+				mu.Lock()
+				if err != nil {
+					// Stop the other workers; the first error is reported.
+					if fatalErr == nil {
+						fatalErr = err
+					}
+					cancel()
+				} else {
+					reports[i] = report
+					done++
+					changes := changeCount(report, opts.Mode)
+					logger.Info("fetched service", "service", report.Service, "done", fmt.Sprintf("%d/%d", done, len(services)), "changes", changes)
+					if changes == 0 && len(report.Errors) == 0 {
+						logger.Warn("service has no "+modeNoun(opts.Mode)+"s", "service", report.Service, "repo", report.Repo, "mode", opts.Mode)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range services {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	if fatalErr != nil {
+		return nil, fatalErr
+	}
+	if opts.Trace && opts.JiraClient != nil {
+		if err := fillTicketStatuses(ctx, *opts.JiraClient, reports); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			logger.Warn("skipping Jira ticket statuses", "error", err)
+		}
+	}
+	if opts.Harness != nil {
+		if err := fillHarnessExecutions(ctx, *opts.Harness, services, reports, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Kubernetes != nil {
+		if err := fillRunningImages(ctx, *opts.Kubernetes, services, reports, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Drift {
+		if err := fillDrift(ctx, providers, services, reports, opts); err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
+
+// Fetch the data of one service. Failures of its repository are recorded in
+// its Errors and leave (part of) the report empty; only errors that doom every service (bad
+// credentials, exhausted rate limit, cancellation) are returned.
+func fetchServiceReport(ctx context.Context, provider Provider, service Service, opts Options, ticketPattern *regexp.Regexp) (ServiceReport, error) {
+	report := ServiceReport{Service: service.Service, Repo: service.Repo}
+	var err error
+	report.Range = service.RefRange(opts.Compare)
+	if opts.Mode == ModeReleases {
+		report.Releases, err = provider.Releases(ctx, service.Repo, opts.StartDate, opts.EndDate)
+	} else if opts.Mode == ModePulls {
+		report.Pulls, err = provider.PullRequests(ctx, service.Repo, opts.StartDate, opts.EndDate)
+	} else if report.Range != "" {
+		base, head, _ := ParseRefRange(report.Range) // checked in Fetch
+		report.Commits, err = provider.Compare(ctx, service.Repo, base, head)
+		if err == nil {
+			report.Commits, err = filterByPaths(ctx, provider, service, report.Commits)
+		}
+	} else {
+		report.Branch = service.branch()
+		report.Commits, err = fetchServiceCommits(ctx, provider, service, opts.StartDate, opts.EndDate)
+		if err != nil && !errors.Is(err, errUnauthorized) && !errors.Is(err, errRateLimited) {
+			err = fmt.Errorf("branch %s: %w", report.Branch, err)
+		}
+	}
+	if fatal(ctx, err) {
+		return report, err
+	}
+	if err == nil {
+		if report.Commits, err = opts.Filter.filter(ctx, provider, service.Repo, report.Commits); err != nil {
+			return report, err
+		}
+	}
+	if err != nil {
+		// One failing repository must not fail the whole report.
+		report.skip(ctx, opts.Mode, err)
+	}
+	if err == nil && (len(opts.Environments) > 0 || len(service.Environments) > 0) && opts.Mode == ModeCommits && report.Range == "" {
+		report.Environments, err = environmentReports(ctx, provider, service, opts.Environments, opts.StartDate, opts.EndDate)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "deployments", err)
+			err = nil
+		}
+		for i := range report.Environments {
+			environment := &report.Environments[i]
+			if environment.Commits, err = opts.Filter.filter(ctx, provider, service.Repo, environment.Commits); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err == nil && opts.ArgoCD != nil {
+		report.Live, err = opts.ArgoCD.liveVersions(ctx, service, opts.Environments)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "live versions", err)
+			err = nil
+		}
+	}
+	if err == nil && opts.Signatures {
+		lists := [][]Commit{report.Commits}
+		for _, environment := range report.Environments {
+			lists = append(lists, environment.Commits)
+		}
+		err = verifySignatures(ctx, provider, service.Repo, lists...)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "signature verification", err)
+			err = nil
+		}
+		report.Unverified = unverifiedCommits(report.Commits)
+	}
+	if err == nil && opts.Stats {
+		lists := [][]Commit{report.Commits}
+		for _, environment := range report.Environments {
+			lists = append(lists, environment.Commits)
+		}
+		err = fetchCommitStats(ctx, provider, service.Repo, lists...)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "change statistics", err)
+			err = nil
+		} else {
+			report.Stats = computeChangeStats(report.Commits)
+		}
+	}
+	if err == nil && opts.Metrics && report.Range == "" {
+		// Releases before the period tell how long ago the last one was.
+		releases, err := provider.Releases(ctx, service.Repo, "", opts.EndDate)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "release metrics", err)
+		} else {
+			report.Metrics = computeReleaseMetrics(releases, len(report.Commits), opts.StartDate, opts.EndDate, opts.location())
+		}
+	}
+	if err == nil && opts.DORAEnvironment != "" && report.Range == "" {
+		report.DORA, err = computeDORAMetrics(ctx, provider, service, opts.DORAEnvironment, opts.StartDate, opts.EndDate)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "DORA metrics", err)
+			err = nil
+		}
+	}
+	if err == nil && opts.Issues && report.Range == "" {
+		report.ClosedIssues, err = provider.ClosedIssues(ctx, service.Repo, opts.StartDate, opts.EndDate)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "closed issues", err)
+			err = nil
+		}
+	}
+	if err == nil && opts.Trace {
+		pattern, baseURL := ticketPattern, ""
+		if pattern == nil {
+			pattern = defaultTicketRegexp
+		}
+		if opts.Jira != nil {
+			baseURL = opts.Jira.BaseURL
+		}
+		report.Trace, err = traceCommits(ctx, provider, service.Repo, report.Commits, pattern, baseURL)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "traceability", err)
+			err = nil
+		}
+	}
+	if err == nil && opts.Contributors && report.Range == "" {
+		report.NewContributors, err = newContributors(ctx, provider, service.Repo, report.Commits, opts.StartDate)
+		if fatal(ctx, err) {
+			return report, err
+		}
+		if err != nil {
+			report.skip(ctx, "new contributors", err)
+		}
+	}
+	sanitizeReport(&report)
+	report.Groups = groupByChangeType(report.Commits)
+	for i := range report.Environments {
+		report.Environments[i].Groups = groupByChangeType(report.Environments[i].Commits)
+	}
+	report.PullGroups = groupByLabel(report.Pulls, opts.PullSections)
+	if ticketPattern != nil {
+		report.Tickets = extractTickets(report.Commits, ticketPattern, opts.Jira.BaseURL)
+	}
+	return report, nil
+}
+
+// Whether an error aborts the whole report rather than one service
+func fatal(ctx context.Context, err error) bool {
+	return errors.Is(err, errUnauthorized) || errors.Is(err, errRateLimited) || (err != nil && ctx.Err() != nil)
+}
+
+// Number of reported changes of a service in the given mode
+func changeCount(report ServiceReport, mode string) int {
+	switch mode {
+	case ModeReleases:
+		return len(report.Releases)
+	case ModePulls:
+		return len(report.Pulls)
+	}
+	return len(report.Commits)
+}
+
+// What a change is called in the given mode, for counts
+func modeNoun(mode string) string {
+	switch mode {
+	case ModeReleases:
+		return "release"
+	case ModePulls:
+		return "pull request"
+	}
+	return "commit"
+}
+
+// Default report template, the detailed view. Styles are inline so the
+// report also displays correctly as an email body.
+//
+//go:embed report.html.tmpl
+var defaultReportTemplate string
+
+// Parse the report template from a file, or the embedded default when
+// filename is empty
+func ParseTemplate(filename string) (*template.Template, error) {
+	text := defaultReportTemplate
+	if filename != "" {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	locale, err := LoadLocale(DefaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+	return parseReportTemplate(text, locale, DefaultSubjectLimit)
+}
+
+// Parse report template text with the report functions, translating with
+// locale and shortening commit subjects to subjectLimit characters
+func parseReportTemplate(text string, locale *Locale, subjectLimit int) (*template.Template, error) {
+	summary := func(message string) string { return reportSummary(message, subjectLimit) }
+	return template.New("report").Funcs(template.FuncMap{"summary": summary, "join": strings.Join, "short": shortSHA, "local": locale.localTime, "t": locale.Text}).Parse(text)
+}
+
+// Render the HTML report with the -template file, else the first view
+func RenderHTML(w io.Writer, reports []ServiceReport, opts Options) error {
+	locale, err := LoadLocale(opts.Language)
+	if err != nil {
+		return err
+	}
+	locale.location = opts.location()
+	text := defaultReportTemplate
+	if opts.Template != "" {
+		var data []byte
+		if data, err = ioutil.ReadFile(opts.Template); err != nil {
+			return err
+		}
+		text = string(data)
+	} else if text, err = viewTemplate(opts.views()[0]); err != nil {
+		return err
+	}
+	tmpl, err := parseReportTemplate(text, locale, opts.subjectLimit())
+	if err != nil {
+		return err
+	}
+	reportData := struct {
+		Date          string
+		Language      string
+		Mode          string
+		Services      []ServiceReport
+		Contributors  *contributorStats
+		ByEnvironment bool
+		Theme         reportTheme
+		Chart         *commitChart
+		Versions      *versionMatrix
+		Drift         *driftTable
+		Overview      *overview
+		Metrics       bool
+		DORA          bool
+		Signatures    bool
+		History       bool
+		Stats         bool
+	}{
+		Date:     locale.date(time.Now()),
+		Language: locale.Language,
+		Mode:     opts.Mode,
+		Services: reports,
+	}
+	if reportData.Theme, err = opts.Theme.load(); err != nil {
+		return err
+	}
+	reportData.Signatures = opts.Signatures
+	for _, report := range reports {
+		if report.Metrics != nil {
+			reportData.Metrics = true
+		}
+		if report.DORA != nil {
+			reportData.DORA = true
+		}
+		if report.History != nil {
+			reportData.History = true
+		}
+		if report.Stats != nil {
+			reportData.Stats = true
+		}
+	}
+	if opts.Mode == ModeCommits {
+		colors := append([]string{reportData.Theme.Primary, reportData.Theme.Accent}, chartColors...)
+		reportData.Chart = buildCommitChart(reports, opts.StartDate, opts.EndDate, colors, locale)
+	}
+	reportData.Versions = buildVersionMatrix(reports, opts.Environments)
+	reportData.Drift = buildDriftTable(reports, opts)
+	reportData.Overview = buildOverview(reports, opts)
+	if opts.Contributors {
+		reportData.Contributors = computeContributorStats(reports)
+	}
+	for _, report := range reports {
+		if len(report.Environments) > 0 {
+			reportData.ByEnvironment = true
+		}
+	}
+
+	return tmpl.Execute(w, reportData)
+}
+
+// Save the HTML report
+func writeHTMLReport(filename string, reports []ServiceReport, opts Options) error {
+	reportFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer reportFile.Close()
+
+	if err := RenderHTML(reportFile, reports, opts); err != nil {
+		return err
+	}
+	return reportFile.Close()
+}
+
+// Whether value is one of values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Default report period: the last 14 days up to now, in the time zone of now
+func DefaultPeriod(now time.Time) (startDate, endDate string) {
+	return now.AddDate(0, 0, -14).Format("2006-01-02"), now.Format("2006-01-02")
+}
+
+// Check a report period of YYYY-MM-DD dates
+func CheckPeriod(startDate, endDate string) error {
+	if !ValidDate(startDate) || !ValidDate(endDate) {
+		return fmt.Errorf("invalid period %s – %s (want YYYY-MM-DD dates)", startDate, endDate)
+	}
+	if startDate > endDate {
+		return fmt.Errorf("start date %s is after end date %s", startDate, endDate)
+	}
+	return nil
+}
+
+// Whether a string is a YYYY-MM-DD date
+func ValidDate(date string) bool {
+	_, err := time.Parse("2006-01-02", date)
+	return err == nil
+}
@@ -0,0 +1,107 @@
+package releasereport
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Characters of a commit subject shown in the report by default
+const DefaultSubjectLimit = 200
+
+// Invisible characters that reorder or hide text ("Trojan Source"), dropped
+// from commit content. The zero-width joiner stays: emoji sequences need it.
+var hiddenCharacters = strings.NewReplacer(
+	"\u200b", "", "\u200c", "", "\u200e", "", "\u200f", "", "\ufeff", "",
+	"\u202a", "", "\u202b", "", "\u202c", "", "\u202d", "", "\u202e", "",
+	"\u2066", "", "\u2067", "", "\u2068", "", "\u2069", "",
+)
+
+// Escape the markup of commit content written into Markdown, so a message
+// like "fix <script> tag" stays text in changelogs and release notes
+var markdownEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Commit content as plain text: valid UTF-8 without control characters
+// (newlines and tabs aside) or hidden ones. Emoji are kept.
+func cleanText(text string) string {
+	text = hiddenCharacters.Replace(strings.ToValidUTF8(text, "�"))
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r == '\r' || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// Text shortened to limit characters with "…", never cutting an emoji
+// sequence in half; a limit of 0 or less keeps it whole
+func truncateText(text string, limit int) string {
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	cut := limit - 1
+	// Back off to the start of an emoji sequence rather than split it.
+	for cut > 0 && (emojiModifier(runes[cut]) || runes[cut-1] == '\u200d') {
+		cut--
+	}
+	runes = runes[:cut]
+	return strings.TrimRightFunc(string(runes), unicode.IsSpace) + "…"
+}
+
+// Whether a character modifies the one before it: a zero-width joiner,
+// variation selector or skin tone
+func emojiModifier(r rune) bool {
+	return r == '\u200d' || (r >= '\ufe00' && r <= '\ufe0f') || (r >= 0x1f3fb && r <= 0x1f3ff)
+}
+
+// Release-note line of a commit for the report, shortened to limit
+// characters (0: no limit)
+func reportSummary(message string, limit int) string {
+	return truncateText(changeSummary(message), limit)
+}
+
+// Release-note line of a commit for Markdown output
+func markdownSummary(message string, limit int) string {
+	return markdownEscaper.Replace(reportSummary(message, limit))
+}
+
+// Clean the commits in place
+func cleanCommits(commits []Commit) {
+	for i := range commits {
+		commits[i].Message = cleanText(commits[i].Message)
+		commits[i].Author = cleanText(commits[i].Author)
+	}
+}
+
+// Clean the text the providers returned before any output renders it
+func sanitizeReport(report *ServiceReport) {
+	cleanCommits(report.Commits)
+	for i := range report.Environments {
+		cleanCommits(report.Environments[i].Commits)
+	}
+	if report.Stats != nil {
+		cleanCommits(report.Stats.Largest)
+	}
+	for i := range report.Releases {
+		report.Releases[i].Name = cleanText(report.Releases[i].Name)
+		report.Releases[i].Notes = cleanText(report.Releases[i].Notes)
+	}
+	for i := range report.Pulls {
+		report.Pulls[i].Title = cleanText(report.Pulls[i].Title)
+		report.Pulls[i].Author = cleanText(report.Pulls[i].Author)
+	}
+	for i := range report.Trace {
+		link := &report.Trace[i]
+		link.Commit.Message, link.Commit.Author = cleanText(link.Commit.Message), cleanText(link.Commit.Author)
+		if link.Pull != nil {
+			link.Pull.Title = cleanText(link.Pull.Title)
+		}
+	}
+	for i := range report.ClosedIssues {
+		report.ClosedIssues[i].Title = cleanText(report.ClosedIssues[i].Title)
+	}
+}
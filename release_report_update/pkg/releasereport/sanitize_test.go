@@ -0,0 +1,71 @@
+package releasereport
+
+import (
+	"testing"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello world", 5, "hell…"},
+		{"hello world", 7, "hello…"}, // no space before the ellipsis
+		{"hello world", 0, "hello world"},
+		{"hello world", -1, "hello world"},
+		{"hello world", 1, "…"},
+		{"héllo wörld", 4, "hél…"},
+		{"ab👍🏽cd", 4, "ab…"},       // skin tone kept with its emoji
+		{"ab👍🏽cd", 5, "ab👍🏽…"},     // whole emoji fits
+		{"a👨‍👩‍👧 family", 5, "a…"}, // joined emoji kept whole
+		{"ok ✔️ done", 5, "ok…"},   // variation selector kept with its symbol
+	}
+	for _, test := range tests {
+		if got := truncateText(test.text, test.limit); got != test.want {
+			t.Errorf("truncateText(%q, %d) = %q, want %q", test.text, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"fix: typo", "fix: typo"},
+		{"subject\r\n\nbody\twith tab", "subject\n\nbody\twith tab"},
+		{"bell\a and escape\x1b[31m", "bell and escape[31m"},
+		{"access\u202e\u2066level\u2069", "accesslevel"},
+		{"zero\u200bwidth\ufeff", "zerowidth"},
+		{"family 👨‍👩‍👧", "family 👨‍👩‍👧"},
+		{"bad \xff byte", "bad � byte"},
+	}
+	for _, test := range tests {
+		if got := cleanText(test.text); got != test.want {
+			t.Errorf("cleanText(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestSummaries(t *testing.T) {
+	tests := []struct {
+		message  string
+		limit    int
+		report   string
+		markdown string
+	}{
+		{"feat(ui): escape <script> & co\n\nbody", 0, "ui: escape <script> & co", "ui: escape &lt;script&gt; &amp; co"},
+		{"fix: a rather long subject line", 12, "a rather lo…", "a rather lo…"},
+		{"plain subject", 200, "plain subject", "plain subject"},
+	}
+	for _, test := range tests {
+		if got := reportSummary(test.message, test.limit); got != test.report {
+			t.Errorf("reportSummary(%q, %d) = %q, want %q", test.message, test.limit, got, test.report)
+		}
+		if got := markdownSummary(test.message, test.limit); got != test.markdown {
+			t.Errorf("markdownSummary(%q, %d) = %q, want %q", test.message, test.limit, got, test.markdown)
+		}
+	}
+}
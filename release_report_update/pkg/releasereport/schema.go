@@ -0,0 +1,192 @@
+package releasereport
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Source of the configuration types, whose field comments describe the keys
+// of the schema
+//
+//go:embed config.go
+var configSource string
+
+// Go field names of several words, which their doc comments start with
+var camelCase = regexp.MustCompile(`[a-z][A-Z]|[A-Z][A-Z][a-z]`)
+
+// Keys every configuration needs, by schema path ("[]" stands for a list
+// item); Validate checks the rest
+var schemaRequired = map[string][]string{
+	"":           {"services"},
+	"services[]": {"service", "repo"},
+	"smtp":       {"host", "from"},
+	"jira":       {"base_url"},
+	"confluence": {"base_url", "space"},
+	"github_app": {"app_id", "installation_id"},
+	"sprint":     {"start"},
+	"harness":    {"account_id", "org", "project"},
+	"argocd":     {"base_url"},
+}
+
+// Keys taking one of a fixed set of values
+var schemaEnums = map[string][]string{
+	"services[].provider":     {ProviderGitHub, ProviderGitLab},
+	"network.min_tls_version": {"1.2", "1.3"},
+}
+
+// Defaults applied to unset keys
+var schemaDefaults = map[string]interface{}{
+	"services[].provider":     ProviderGitHub,
+	"services[].branch":       "main",
+	"smtp.port":               defaultSMTPPort,
+	"theme.primary_color":     "#0073e6",
+	"theme.accent_color":      "#ff9800",
+	"sprint.length_days":      defaultSprintDays,
+	"network.min_tls_version": "1.2",
+	"harness.base_url":        DefaultHarnessBaseURL,
+	"argocd.application":      defaultArgoCDApplication,
+}
+
+// JSON Schema (draft-07) of the configuration file, derived from the Config
+// struct: json tags give the keys, Go types the JSON types and comments the
+// descriptions. YAML configuration files follow it too.
+func ConfigSchema() ([]byte, error) {
+	docs, err := fieldDocs()
+	if err != nil {
+		return nil, err
+	}
+	root := typeSchema(reflect.TypeOf(Config{}), "", docs)
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["title"] = "release_report configuration"
+	// Lets editors find the schema of a config.json.
+	root["properties"].(map[string]interface{})["$schema"] = map[string]interface{}{"type": "string"}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(root)
+	return out.Bytes(), err
+}
+
+// Comments of the struct fields and types in configSource, keyed by
+// "Type.Field" and by type name
+func fieldDocs() (map[string]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "config.go", configSource, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]string)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			st, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			docs[typeSpec.Name.Name] = strings.TrimPrefix(commentText(gen.Doc), "Struct for ")
+			for _, field := range st.Fields.List {
+				text := strings.TrimSpace(commentText(field.Doc) + " " + commentText(field.Comment))
+				for _, name := range field.Names {
+					docs[typeSpec.Name.Name+"."+name.Name] = text
+				}
+				if ident, ok := field.Type.(*ast.Ident); ok && len(field.Names) == 0 {
+					docs[typeSpec.Name.Name+"."+ident.Name] = text
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+// Schema of a configuration value of type t at path
+func typeSchema(t reflect.Type, path string, docs map[string]string) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), path, docs)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), path+"[]", docs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), path+".*", docs)}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		addProperties(properties, t, path, docs)
+		schema := map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+		if required, ok := schemaRequired[path]; ok {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// Add the keys of struct t to properties; embedded structs without a json
+// tag add theirs, as encoding/json inlines them
+func addProperties(properties map[string]interface{}, t reflect.Type, path string, docs map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && key == "" {
+			addProperties(properties, field.Type, path, docs)
+			continue
+		}
+		if key == "" || key == "-" {
+			continue
+		}
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		schema := typeSchema(field.Type, fieldPath, docs)
+		description := docs[t.Name()+"."+field.Name]
+		if camelCase.MatchString(field.Name) {
+			// "ExcludeBots leaves out ..." describes exclude_bots as
+			// "Leaves out ...".
+			description = strings.TrimPrefix(strings.TrimPrefix(description, field.Name+" "), "is ")
+		}
+		if description == "" {
+			description = docs[elemType(field.Type).Name()]
+		}
+		if description != "" {
+			schema["description"] = strings.ToUpper(description[:1]) + description[1:]
+		}
+		if values, ok := schemaEnums[fieldPath]; ok {
+			schema["enum"] = values
+		}
+		if value, ok := schemaDefaults[fieldPath]; ok {
+			schema["default"] = value
+		}
+		properties[key] = schema
+	}
+}
+
+// Type behind pointers, slices and maps
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Comment group as a single line
+func commentText(group *ast.CommentGroup) string {
+	return strings.Join(strings.Fields(group.Text()), " ")
+}
@@ -0,0 +1,53 @@
+package releasereport
+
+import "testing"
+
+// Effective value of every schemaDefaults key for an unset setting, as the
+// code applying it computes it
+func appliedDefaults(t *testing.T) map[string]interface{} {
+	t.Helper()
+	transport, err := newTransport(&NetworkConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	minTLSVersion := ""
+	for name, version := range tlsVersions {
+		if version == transport.TLSClientConfig.MinVersion {
+			minTLSVersion = name
+		}
+	}
+	theme, err := (&ThemeConfig{}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]interface{}{
+		"services[].provider":     Service{}.provider(),
+		"services[].branch":       Service{}.branch(),
+		"smtp.port":               SMTPConfig{}.port(),
+		"theme.primary_color":     theme.Primary,
+		"theme.accent_color":      theme.Accent,
+		"sprint.length_days":      SprintConfig{}.days(),
+		"network.min_tls_version": minTLSVersion,
+		"harness.base_url":        HarnessConfig{}.baseURL(),
+		"argocd.application":      ArgoCDConfig{}.application(Service{Service: "{service}", Repo: "{repo}"}, "{environment}"),
+	}
+}
+
+func TestSchemaDefaultsMatchCode(t *testing.T) {
+	applied := appliedDefaults(t)
+	for key, want := range schemaDefaults {
+		got, ok := applied[key]
+		if !ok {
+			t.Errorf("%s: schema default %v is not checked against the code", key, want)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: schema default %v, code applies %v", key, want, got)
+		}
+	}
+	for key := range applied {
+		if _, ok := schemaDefaults[key]; !ok {
+			t.Errorf("%s: default applied by the code is missing from the schema", key)
+		}
+	}
+}
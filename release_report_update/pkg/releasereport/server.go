@@ -0,0 +1,225 @@
+package releasereport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Content types of the report formats
+var reportContentTypes = map[string]string{
+	"html": "text/html; charset=utf-8",
+	"csv":  "text/csv; charset=utf-8",
+}
+
+// Serves reports generated on demand for -serve
+type Server struct {
+	Providers map[string]Provider
+	Services  []Service
+	Options   Options       // defaults of every request
+	LatestTTL time.Duration // how long /latest is answered from the cache
+	Sprint    *SprintConfig // cadence of the sprint ranges
+	// WebhookSecret enables /webhook for GitHub deliveries signed with it.
+	WebhookSecret string
+
+	// Reports are generated one at a time so concurrent requests do not
+	// multiply the API calls and trip the rate limits.
+	mu            sync.Mutex
+	latest        []byte
+	latestOpts    Options
+	latestReports []ServiceReport // kept to regenerate single services
+	latestSince   time.Time
+}
+
+// Serve reports on addr until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/report", s.handleReport)
+	mux.HandleFunc("/latest", s.handleLatest)
+	if s.WebhookSecret != "" {
+		mux.HandleFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {
+			s.handleWebhook(ctx, w, r)
+		})
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "/latest", http.StatusFound)
+	})
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	endpoints := "/report, /latest"
+	if s.WebhookSecret != "" {
+		endpoints += ", /webhook"
+	}
+	s.Options.logger().Info("serving reports", "addr", addr, "endpoints", endpoints)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// GET /report?start=YYYY-MM-DD&end=YYYY-MM-DD&format=html|csv&mode=commits|releases|pulls
+// generates a report, for a -range preset instead of the dates with range=;
+// omitted parameters take the defaults of the command line
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	opts, err := s.requestOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	body, err := s.render(r.Context(), opts)
+	s.mu.Unlock()
+	if err != nil {
+		s.Options.logger().Warn("serving report failed", "url", r.URL.String(), "error", err)
+		http.Error(w, "generating the report failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	s.write(w, body, opts.Format, time.Now())
+}
+
+// GET /latest returns the report of the default period, regenerated at most
+// once per LatestTTL
+func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	if s.latest == nil || time.Since(s.latestSince) > s.LatestTTL {
+		opts := s.Options
+		opts.StartDate, opts.EndDate = DefaultPeriod(time.Now().In(opts.location()))
+		reports, err := Fetch(r.Context(), s.Providers, s.Services, opts)
+		var body []byte
+		if err == nil {
+			body, err = renderReport(reports, opts)
+		}
+		if err != nil {
+			s.mu.Unlock()
+			s.Options.logger().Warn("serving latest report failed", "error", err)
+			http.Error(w, "generating the report failed: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+		s.latest, s.latestOpts, s.latestReports, s.latestSince = body, opts, reports, time.Now()
+	}
+	body, format, generated := s.latest, s.latestOpts.Format, s.latestSince
+	s.mu.Unlock()
+	s.write(w, body, format, generated)
+}
+
+// Report options of a request: the server defaults overridden by the query
+// parameters
+func (s *Server) requestOptions(r *http.Request) (Options, error) {
+	opts := s.Options
+	query := r.URL.Query()
+	today := time.Now().In(opts.location())
+	opts.StartDate, opts.EndDate = DefaultPeriod(today)
+	if start := query.Get("start"); start != "" {
+		opts.StartDate = start
+	}
+	if end := query.Get("end"); end != "" {
+		opts.EndDate = end
+	}
+	if preset := query.Get("range"); preset != "" {
+		if query.Get("start") != "" || query.Get("end") != "" {
+			return opts, errors.New("range replaces start and end")
+		}
+		var err error
+		if opts.StartDate, opts.EndDate, err = ResolveRange(preset, today, s.Sprint); err != nil {
+			return opts, err
+		}
+	}
+	if err := CheckPeriod(opts.StartDate, opts.EndDate); err != nil {
+		return opts, err
+	}
+	if format := query.Get("format"); format != "" {
+		if !contains(ReportFormats, format) {
+			return opts, fmt.Errorf("unknown format %q (want %s)", format, strings.Join(ReportFormats, " or "))
+		}
+		opts.Format = format
+	}
+	if mode := query.Get("mode"); mode != "" {
+		if !contains(ReportModes, mode) {
+			return opts, fmt.Errorf("unknown mode %q (want %s)", mode, strings.Join(ReportModes, ", "))
+		}
+		opts.Mode = mode
+	}
+	if view := query.Get("view"); view != "" {
+		if opts.Template != "" {
+			return opts, errors.New("view does not apply to the server's -template")
+		}
+		if _, err := viewTemplate(view); err != nil {
+			return opts, err
+		}
+		opts.Views = []string{view}
+	}
+	if language := query.Get("lang"); language != "" {
+		if _, err := LoadLocale(language); err != nil {
+			return opts, err
+		}
+		opts.Language = language
+	}
+	if opts.Mode != ModeCommits {
+		for _, service := range s.Services {
+			if service.RefRange(opts.Compare) != "" {
+				return opts, fmt.Errorf("compare ranges need mode %s", ModeCommits)
+			}
+		}
+		// These sections only exist for commits.
+		opts.Contributors, opts.Metrics, opts.Signatures, opts.Stats, opts.Trace = false, false, false, false, false
+	}
+	return opts, nil
+}
+
+// Generate a report and render it in memory
+func (s *Server) render(ctx context.Context, opts Options) ([]byte, error) {
+	reports, err := Fetch(ctx, s.Providers, s.Services, opts)
+	if err != nil {
+		return nil, err
+	}
+	return renderReport(reports, opts)
+}
+
+// Render a report in memory in the format of opts
+func renderReport(reports []ServiceReport, opts Options) ([]byte, error) {
+	var (
+		out bytes.Buffer
+		err error
+	)
+	if opts.Format == "csv" {
+		err = RenderCSV(&out, reports, opts)
+	} else {
+		err = RenderHTML(&out, reports, opts)
+	}
+	return out.Bytes(), err
+}
+
+// Write a rendered report as the response
+func (s *Server) write(w http.ResponseWriter, body []byte, format string, generated time.Time) {
+	w.Header().Set("Content-Type", reportContentTypes[format])
+	w.Header().Set("Last-Modified", generated.UTC().Format(http.TimeFormat))
+	if format == "csv" {
+		w.Header().Set("Content-Disposition", `attachment; filename="release_report.csv"`)
+	}
+	w.Write(body)
+}
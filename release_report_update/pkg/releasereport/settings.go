@@ -0,0 +1,69 @@
+package releasereport
+
+import (
+	"context"
+	"time"
+)
+
+// Settings of a report run that the providers and API calls need. The
+// providers of a process are shared by all its runs, e.g. the requests of
+// -serve, so the entry points taking Options put these in the context
+// rather than in the providers or package variables.
+type runSettings struct {
+	logger   Logger
+	location *time.Location
+	cache    *diskCache // nil disables the response cache
+}
+
+// Context key of the run settings
+type runSettingsKey struct{}
+
+// Logger of the run, discarding messages when none is set
+func (o Options) logger() Logger {
+	if o.Logger == nil {
+		return nopLogger{}
+	}
+	return o.Logger
+}
+
+// Time zone of the run, time.Local when none is set
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+	return o.Location
+}
+
+// Characters of a commit subject shown, 0 for no limit
+func (o Options) subjectLimit() int {
+	switch {
+	case o.SubjectLimit == 0:
+		return DefaultSubjectLimit
+	case o.SubjectLimit < 0:
+		return 0
+	}
+	return o.SubjectLimit
+}
+
+// Context carrying the logger, time zone and response cache of o to the API
+// calls. The functions taking Options add it themselves; pass it to those
+// that do not, such as PublishToConfluence, to log and cache their calls.
+func (o Options) Context(ctx context.Context) context.Context {
+	settings := runSettings{logger: o.logger(), location: o.location()}
+	if o.CacheDir != "" {
+		settings.cache = &diskCache{dir: o.CacheDir}
+		if err := settings.cache.prune(time.Now()); err != nil {
+			settings.logger.Debug("pruning the API response cache failed", "dir", o.CacheDir, "error", err)
+		}
+	}
+	return context.WithValue(ctx, runSettingsKey{}, settings)
+}
+
+// Run settings carried by ctx; without any, messages are discarded, dates
+// are local and no response is cached
+func settingsOf(ctx context.Context) runSettings {
+	if settings, ok := ctx.Value(runSettingsKey{}).(runSettings); ok {
+		return settings
+	}
+	return runSettings{logger: nopLogger{}, location: time.Local}
+}
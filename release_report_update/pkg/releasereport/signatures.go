@@ -0,0 +1,37 @@
+package releasereport
+
+import "context"
+
+// Fill in the signature verification of the commits the API listed without
+// it (all of them on GitLab), looking each commit up once across the lists
+func verifySignatures(ctx context.Context, provider Provider, repo string, lists ...[]Commit) error {
+	known := make(map[string]string)
+	for _, commits := range lists {
+		for i := range commits {
+			if commits[i].Verification != "" {
+				continue
+			}
+			verification, ok := known[commits[i].SHA]
+			if !ok {
+				var err error
+				if verification, err = provider.Verification(ctx, repo, commits[i].SHA); err != nil {
+					return err
+				}
+				known[commits[i].SHA] = verification
+			}
+			commits[i].Verification = verification
+		}
+	}
+	return nil
+}
+
+// Number of commits whose signature is not verified
+func unverifiedCommits(commits []Commit) int {
+	n := 0
+	for _, c := range commits {
+		if c.Unverified() {
+			n++
+		}
+	}
+	return n
+}
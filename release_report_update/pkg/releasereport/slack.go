@@ -0,0 +1,144 @@
+package releasereport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Limits of the Slack summary
+const (
+	slackTopChanges   = 3   // changes listed per service
+	slackMaxServices  = 45  // service sections, within Slack's 50 blocks per message
+	slackSubjectLimit = 100 // characters of a commit subject
+)
+
+// Slack Block Kit block
+type slackBlock map[string]interface{}
+
+// Slack mrkdwn or plain_text element
+func slackText(kind, text string) map[string]string {
+	return map[string]string{"type": kind, "text": text}
+}
+
+// Escape the characters Slack treats as control sequences in mrkdwn
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// First line of a commit message, shortened to limit characters
+func commitSubject(message string, limit int) string {
+	return truncateText(strings.TrimSpace(strings.SplitN(message, "\n", 2)[0]), limit)
+}
+
+// Count with a singular or plural noun, e.g. "1 commit" or "2 commits"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Changes of a service to summarize: its releases or pull requests in those
+// modes, else its commits, as subjects with links
+func slackChanges(report ServiceReport, mode string) (changes [][2]string) {
+	if mode == ModeReleases {
+		for _, r := range report.Releases {
+			title := r.Tag
+			if r.Name != "" && r.Name != r.Tag {
+				title += " " + r.Name
+			}
+			changes = append(changes, [2]string{commitSubject(title, slackSubjectLimit), r.URL})
+		}
+		return changes
+	}
+	if mode == ModePulls {
+		for _, p := range report.Pulls {
+			changes = append(changes, [2]string{commitSubject(fmt.Sprintf("#%d %s", p.Number, p.Title), slackSubjectLimit), p.URL})
+		}
+		return changes
+	}
+	for _, c := range report.Commits {
+		changes = append(changes, [2]string{commitSubject(c.Message, slackSubjectLimit), c.URL})
+	}
+	return changes
+}
+
+// Build the Block Kit summary: per-service commit (or release) counts, the
+// latest changes and a link to the full report (or its file name when
+// reportURL is empty)
+func SlackSummary(reports []ServiceReport, opts Options, reportURL, reportFile string) map[string]interface{} {
+	noun := modeNoun(opts.Mode)
+	total := 0
+	for _, report := range reports {
+		total += len(slackChanges(report, opts.Mode))
+	}
+	blocks := []slackBlock{
+		{"type": "header", "text": slackText("plain_text", "🚀 Release Report "+opts.Period())},
+		{"type": "section", "text": slackText("mrkdwn", fmt.Sprintf("*%s* across *%s*", plural(total, noun), plural(len(reports), "service")))},
+		{"type": "divider"},
+	}
+	for i, report := range reports {
+		if i == slackMaxServices {
+			blocks = append(blocks, slackBlock{"type": "context", "elements": []map[string]string{
+				slackText("mrkdwn", fmt.Sprintf("…and %d more services", len(reports)-slackMaxServices)),
+			}})
+			break
+		}
+		changes := slackChanges(report, opts.Mode)
+		var text strings.Builder
+		fmt.Fprintf(&text, "*%s* — %s", slackEscape(report.Service), plural(len(changes), noun))
+		if len(report.Errors) > 0 {
+			text.WriteString(" ⚠️ data unavailable")
+		}
+		for j, change := range changes {
+			if j == slackTopChanges {
+				break
+			}
+			if subject, url := slackEscape(change[0]), change[1]; url != "" {
+				fmt.Fprintf(&text, "\n• <%s|%s>", url, subject)
+			} else {
+				fmt.Fprintf(&text, "\n• %s", subject)
+			}
+		}
+		blocks = append(blocks, slackBlock{"type": "section", "text": slackText("mrkdwn", text.String())})
+	}
+	link := "Full report: " + reportFile
+	if reportURL != "" {
+		link = fmt.Sprintf("<%s|Full report>", reportURL)
+	}
+	blocks = append(blocks, slackBlock{"type": "context", "elements": []map[string]string{slackText("mrkdwn", link)}})
+
+	return map[string]interface{}{
+		// Fallback for notifications and clients without Block Kit support.
+		"text":   fmt.Sprintf("Release Report %s: %s across %s", opts.Period(), plural(total, noun), plural(len(reports), "service")),
+		"blocks": blocks,
+	}
+}
+
+// Post the report summary to a Slack incoming webhook
+func PostSlackSummary(ctx context.Context, client *http.Client, webhookURL string, summary map[string]interface{}) error {
+	payload, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Slack returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
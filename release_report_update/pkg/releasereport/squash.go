@@ -0,0 +1,42 @@
+package releasereport
+
+import "context"
+
+// Report each change once: when a squash- or rebase-merge commit of a pull
+// request is listed, its original commits from the pull request branch are
+// dropped, e.g. where a report merges several branches. True merge commits
+// keep their commits, which are the branch's history.
+func dedupeSquashMerges(ctx context.Context, provider Provider, repo string, commits []Commit) ([]Commit, error) {
+	if len(commits) < 2 {
+		return commits, nil
+	}
+	listed := make(map[string]bool)
+	for _, c := range commits {
+		listed[c.SHA] = true
+	}
+	squashed := make(map[string]bool)
+	for _, c := range commits {
+		if c.Merge || squashed[c.SHA] {
+			continue
+		}
+		originals, err := provider.SquashedCommits(ctx, repo, c.SHA)
+		if err != nil {
+			return commits, err
+		}
+		for _, sha := range originals {
+			if listed[sha] {
+				squashed[sha] = true
+			}
+		}
+	}
+	if len(squashed) == 0 {
+		return commits, nil
+	}
+	var deduped []Commit
+	for _, c := range commits {
+		if !squashed[c.SHA] {
+			deduped = append(deduped, c)
+		}
+	}
+	return deduped, nil
+}
@@ -0,0 +1,71 @@
+package releasereport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Provider knowing which commits each squash merge brought in
+type squashProvider struct {
+	Provider
+	originals map[string][]string // by squash-merge SHA
+	err       error
+}
+
+func (p squashProvider) SquashedCommits(ctx context.Context, repo, sha string) ([]string, error) {
+	return p.originals[sha], p.err
+}
+
+func TestDedupeSquashMerges(t *testing.T) {
+	squash := squashProvider{originals: map[string][]string{
+		"s1": {"a1", "a2"},
+		"s2": {"b1", "x9"}, // x9 is not in the report
+	}}
+	tests := []struct {
+		name    string
+		commits []Commit
+		want    string
+	}{
+		{"none", nil, "[]"},
+		{"single", []Commit{{SHA: "s1"}}, "[s1]"},
+		{"no squash merges", []Commit{{SHA: "c2"}, {SHA: "c1"}}, "[c2 c1]"},
+		{"originals dropped", []Commit{{SHA: "s1"}, {SHA: "a2"}, {SHA: "c1"}, {SHA: "a1"}}, "[s1 c1]"},
+		{"originals not listed", []Commit{{SHA: "s2"}, {SHA: "c1"}}, "[s2 c1]"},
+		{"several squash merges", []Commit{{SHA: "s2"}, {SHA: "b1"}, {SHA: "s1"}, {SHA: "a1"}}, "[s2 s1]"},
+		{"true merges keep their commits", []Commit{{SHA: "s1", Merge: true}, {SHA: "a1"}, {SHA: "a2"}}, "[s1 a1 a2]"},
+	}
+	for _, test := range tests {
+		commits, err := dedupeSquashMerges(context.Background(), squash, "acme/web", test.commits)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		shas := []string{}
+		for _, c := range commits {
+			shas = append(shas, c.SHA)
+		}
+		if fmt.Sprint(shas) != test.want {
+			t.Errorf("%s: commits = %v, want %s", test.name, shas, test.want)
+		}
+	}
+}
+
+func TestCommitFilterDedupeFailure(t *testing.T) {
+	commits := []Commit{{SHA: "s1"}, {SHA: "a1"}}
+	filter := CommitFilter{DedupeSquashMerges: true}
+	tests := []struct {
+		err     error
+		fatal   bool
+		commits int
+	}{
+		{errors.New("404 Not Found"), false, 2},
+		{fmt.Errorf("GitHub API: %w", errRateLimited), true, 0},
+	}
+	for _, test := range tests {
+		kept, err := filter.filter(context.Background(), squashProvider{err: test.err}, "acme/web", commits)
+		if (err != nil) != test.fatal || len(kept) != test.commits {
+			t.Errorf("filter with %v = %d commits, %v; want %d commits, fatal %v", test.err, len(kept), err, test.commits, test.fatal)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package releasereport
+
+import (
+	"context"
+	"sort"
+)
+
+// Commits highlighted as the largest changes of a service
+const largestChanges = 3
+
+// Change size of a service's commits, with -stats
+type ChangeStats struct {
+	Additions int
+	Deletions int
+	Files     int      // file changes, summed over the commits
+	Largest   []Commit // by changed lines, largest first
+}
+
+// Fill in the change size of the commits, looking each commit up once
+// across the lists
+func fetchCommitStats(ctx context.Context, provider Provider, repo string, lists ...[]Commit) error {
+	known := make(map[string]*CommitStats)
+	for _, commits := range lists {
+		for i := range commits {
+			stats, ok := known[commits[i].SHA]
+			if !ok {
+				fetched, err := provider.CommitStats(ctx, repo, commits[i].SHA)
+				if err != nil {
+					return err
+				}
+				stats = &fetched
+				known[commits[i].SHA] = stats
+			}
+			commits[i].Stats = stats
+		}
+	}
+	return nil
+}
+
+// Total change size of commits with their stats, and the largest of them
+func computeChangeStats(commits []Commit) *ChangeStats {
+	stats := &ChangeStats{}
+	var sized []Commit
+	for _, c := range commits {
+		if c.Stats == nil {
+			continue
+		}
+		stats.Additions += c.Stats.Additions
+		stats.Deletions += c.Stats.Deletions
+		stats.Files += c.Stats.Files
+		sized = append(sized, c)
+	}
+	sort.SliceStable(sized, func(i, j int) bool {
+		return sized[i].Stats.Additions+sized[i].Stats.Deletions > sized[j].Stats.Additions+sized[j].Stats.Deletions
+	})
+	if len(sized) > largestChanges {
+		sized = sized[:largestChanges]
+	}
+	stats.Largest = sized
+	return stats
+}
@@ -0,0 +1,75 @@
+package releasereport
+
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Colors of the unbranded report
+const (
+	defaultPrimaryColor = "#0073e6" // service names and links
+	defaultAccentColor  = "#ff9800" // commit bullets
+)
+
+// CSS hex colors such as #0073e6 or #fff
+var hexColorPattern = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// Theme settings as used by the report template
+type reportTheme struct {
+	LogoURL  string
+	Primary  string
+	Accent   string
+	CSS      template.CSS
+	DarkMode bool
+}
+
+// Check the theme before any report is generated
+func (c ThemeConfig) validate() error {
+	for _, color := range []struct{ key, value string }{{"primary_color", c.PrimaryColor}, {"accent_color", c.AccentColor}} {
+		if color.value != "" && !hexColorPattern.MatchString(color.value) {
+			return fmt.Errorf("theme.%s %q is not a hex color such as #0073e6", color.key, color.value)
+		}
+	}
+	if c.LogoURL != "" {
+		if u, err := url.Parse(c.LogoURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("theme.logo_url %q is not an http(s) URL", c.LogoURL)
+		}
+	}
+	if c.CSSFile != "" {
+		if _, err := ioutil.ReadFile(c.CSSFile); err != nil {
+			return fmt.Errorf("theme.css_file: %v", err)
+		}
+	}
+	return nil
+}
+
+// Resolve the theme of the report, with the default colors for unset (or
+// nil) settings
+func (c *ThemeConfig) load() (reportTheme, error) {
+	theme := reportTheme{Primary: defaultPrimaryColor, Accent: defaultAccentColor}
+	if c == nil {
+		return theme, nil
+	}
+	theme.LogoURL = c.LogoURL
+	theme.DarkMode = c.DarkMode
+	if c.PrimaryColor != "" {
+		theme.Primary = c.PrimaryColor
+	}
+	if c.AccentColor != "" {
+		theme.Accent = c.AccentColor
+	}
+	if c.CSSFile != "" {
+		css, err := ioutil.ReadFile(c.CSSFile)
+		if err != nil {
+			return theme, err
+		}
+		// The stylesheet is the operator's own configuration, not report
+		// data; it only must not close its <style> element.
+		theme.CSS = template.CSS(strings.ReplaceAll(string(css), "</", `<\/`))
+	}
+	return theme, nil
+}
@@ -0,0 +1,59 @@
+package releasereport
+
+import (
+	"context"
+	"regexp"
+)
+
+// Ticket keys -trace looks for without a "jira" section
+var defaultTicketRegexp = regexp.MustCompile(defaultTicketPattern)
+
+// Row of the traceability table of -trace: a commit with the pull request
+// that merged it and the tickets either of them references
+type TraceLink struct {
+	Commit  Commit
+	Pull    *PullRequest // nil for a commit pushed directly
+	Tickets []Ticket
+}
+
+// Trace each commit to its pull request and tickets. pattern finds the
+// ticket keys in the commit message and the pull request title and
+// description, linked to the Jira instance at baseURL unless it is empty.
+func traceCommits(ctx context.Context, provider Provider, repo string, commits []Commit, pattern *regexp.Regexp, baseURL string) ([]TraceLink, error) {
+	links := make([]TraceLink, 0, len(commits))
+	for _, c := range commits {
+		pull, err := provider.CommitPull(ctx, repo, c.SHA)
+		if err != nil {
+			return nil, err
+		}
+		texts := []string{c.Message}
+		if pull != nil {
+			texts = append(texts, pull.Title, pull.Description)
+		}
+		links = append(links, TraceLink{Commit: c, Pull: pull, Tickets: findTickets(pattern, baseURL, texts...)})
+	}
+	return links, nil
+}
+
+// Fill in the Jira status of the traced tickets, looking each ticket up
+// once across the services
+func fillTicketStatuses(ctx context.Context, api JiraClient, reports []ServiceReport) error {
+	statuses := make(map[string]string)
+	for i := range reports {
+		for j := range reports[i].Trace {
+			tickets := reports[i].Trace[j].Tickets
+			for k := range tickets {
+				status, ok := statuses[tickets[k].Key]
+				if !ok {
+					var err error
+					if status, err = api.status(ctx, tickets[k].Key); err != nil {
+						return err
+					}
+					statuses[tickets[k].Key] = status
+				}
+				tickets[k].Status = status
+			}
+		}
+	}
+	return nil
+}
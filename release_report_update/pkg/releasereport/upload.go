@@ -0,0 +1,81 @@
+package releasereport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Object storage destination of -upload, such as s3://bucket/prefix
+type UploadTarget struct {
+	Scheme string // "s3" or "gs"
+	Bucket string
+	Prefix string // without leading or trailing slashes
+}
+
+// Command line tools that copy files to each storage, using their own
+// credentials (profiles, SSO, instance or workload identity)
+var uploadCommands = map[string]string{
+	"s3": "aws",
+	"gs": "gcloud",
+}
+
+// Parse the -upload destination
+func ParseUploadTarget(raw string) (UploadTarget, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || uploadCommands[u.Scheme] == "" {
+		return UploadTarget{}, fmt.Errorf("invalid destination %q (want s3://bucket/prefix or gs://bucket/prefix)", raw)
+	}
+	return UploadTarget{Scheme: u.Scheme, Bucket: u.Host, Prefix: strings.Trim(u.Path, "/")}, nil
+}
+
+// Check that the storage's command line tool is installed
+func (t UploadTarget) Check() error {
+	if _, err := exec.LookPath(uploadCommands[t.Scheme]); err != nil {
+		return fmt.Errorf("uploading to %s:// needs the %s CLI: %v", t.Scheme, uploadCommands[t.Scheme], err)
+	}
+	return nil
+}
+
+// URL of an object below the prefix
+func (t UploadTarget) objectURL(key string) string {
+	return fmt.Sprintf("%s://%s/%s", t.Scheme, t.Bucket, path.Join(t.Prefix, key))
+}
+
+// Upload a report file below a timestamped key, and to latest/ as well when
+// latest is set. Returns the URLs of the uploaded objects.
+func UploadReport(ctx context.Context, target UploadTarget, file string, now time.Time, latest bool) ([]string, error) {
+	keys := []string{path.Join(now.UTC().Format("20060102T150405Z"), filepath.Base(file))}
+	if latest {
+		keys = append(keys, path.Join("latest", filepath.Base(file)))
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	var uploaded []string
+	for _, key := range keys {
+		destination := target.objectURL(key)
+		var cmd *exec.Cmd
+		switch target.Scheme {
+		case "s3":
+			cmd = exec.CommandContext(ctx, "aws", "s3", "cp", "--only-show-errors", "--content-type", contentType, file, destination)
+		case "gs":
+			cmd = exec.CommandContext(ctx, "gcloud", "storage", "cp", "--quiet", "--content-type", contentType, file, destination)
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return uploaded, fmt.Errorf("%s: %v: %s", destination, err, strings.TrimSpace(stderr.String()))
+		}
+		uploaded = append(uploaded, destination)
+	}
+	return uploaded, nil
+}
@@ -0,0 +1,130 @@
+package releasereport
+
+import (
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Built-in HTML report templates (see -view)
+const (
+	ViewDetailed  = "detailed"  // every change, for engineers
+	ViewExecutive = "executive" // counts and highlights, for leadership
+)
+
+var ReportViews = []string{ViewDetailed, ViewExecutive}
+
+// Changes listed per service in the executive view
+const executiveHighlights = 5
+
+// Executive report template: counts and highlights per service
+//
+//go:embed executive.html.tmpl
+var executiveReportTemplate string
+
+// Template text of a built-in view
+func viewTemplate(view string) (string, error) {
+	switch view {
+	case "", ViewDetailed:
+		return defaultReportTemplate, nil
+	case ViewExecutive:
+		return executiveReportTemplate, nil
+	}
+	return "", fmt.Errorf("unknown view %q (want %s)", view, strings.Join(ReportViews, " or "))
+}
+
+// Views of a run, the detailed one by default
+func (o Options) views() []string {
+	if len(o.Views) == 0 {
+		return []string{ViewDetailed}
+	}
+	return o.Views
+}
+
+// File of a view after the first: the report file with the view appended to
+// its name, e.g. release_report-executive.html
+func viewOutput(output, view string) string {
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "-" + view + ext
+}
+
+// Counts and highlights of the whole report, for the executive view
+type overview struct {
+	Services []serviceOverview
+	Changes  int // commits, releases or pull requests, by mode
+	Features int
+	Fixes    int
+	Breaking int
+	Lagging  []string // services behind in production, with -drift
+}
+
+// Counts and highlights of one service
+type serviceOverview struct {
+	Service     string
+	Changes     int
+	Features    int
+	Fixes       int
+	Breaking    int
+	Highlights  []highlight // breaking changes and features first
+	Unavailable bool        // part of the data could not be fetched
+}
+
+// Change worth naming to leadership
+type highlight struct {
+	Title string
+	URL   string
+}
+
+// Sum up the reports for the executive view
+func buildOverview(reports []ServiceReport, opts Options) *overview {
+	o := &overview{}
+	production := productionEnvironment(opts)
+	for _, report := range reports {
+		s := serviceOverview{Service: report.Service, Changes: changeCount(report, opts.Mode), Unavailable: len(report.Errors) > 0}
+		var breaking, features, others []highlight
+		for _, c := range report.Commits {
+			item := highlight{Title: changeSummary(c.Message), URL: c.URL}
+			switch {
+			case breakingChange(c.Message):
+				s.Breaking++
+				breaking = append(breaking, item)
+			case changeType(c.Message) == "feat":
+				features = append(features, item)
+			}
+			switch changeType(c.Message) {
+			case "feat":
+				s.Features++
+			case "fix":
+				s.Fixes++
+			}
+		}
+		for _, r := range report.Releases {
+			title := r.Name
+			if title == "" {
+				title = r.Tag
+			}
+			others = append(others, highlight{Title: title, URL: r.URL})
+		}
+		for _, group := range report.PullGroups {
+			for _, p := range group.Pulls {
+				others = append(others, highlight{Title: fmt.Sprintf("#%d %s", p.Number, p.Title), URL: p.URL})
+			}
+		}
+		s.Highlights = append(append(breaking, features...), others...)
+		if len(s.Highlights) > executiveHighlights {
+			s.Highlights = s.Highlights[:executiveHighlights]
+		}
+		for _, drift := range report.Drift {
+			if drift.Environment == production && drift.Lagging() {
+				o.Lagging = append(o.Lagging, report.Service)
+			}
+		}
+		o.Services = append(o.Services, s)
+		o.Changes += s.Changes
+		o.Features += s.Features
+		o.Fixes += s.Fixes
+		o.Breaking += s.Breaking
+	}
+	return o
+}
@@ -0,0 +1,124 @@
+package releasereport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// GitHub caps webhook payloads at 25 MB
+const maxWebhookPayload = 25 << 20
+
+// GitHub webhook events that change a report
+var webhookEvents = []string{"push", "release", "deployment"}
+
+// The parts of a GitHub webhook payload used here
+type webhookPayload struct {
+	Ref        string `json:"ref"` // push events only
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// Whether the X-Hub-Signature-256 header signs the payload with secret
+func validWebhookSignature(payload []byte, signature, secret string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// Indexes of the GitHub services an event changes: every service of the
+// repository, or for pushes the ones on the pushed branch
+func webhookServices(services []Service, event string, payload webhookPayload) []int {
+	var indexes []int
+	for i, service := range services {
+		if service.provider() != ProviderGitHub || !strings.EqualFold(service.Repo, payload.Repository.FullName) {
+			continue
+		}
+		if event == "push" && payload.Ref != "refs/heads/"+service.branch() {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// POST /webhook receives GitHub deliveries and regenerates the sections of
+// the latest report they change. The work happens after the response, as
+// GitHub gives up on deliveries after 10 seconds.
+func (s *Server) handleWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayload))
+	if err != nil {
+		http.Error(w, "reading the payload failed", http.StatusBadRequest)
+		return
+	}
+	if !validWebhookSignature(body, r.Header.Get("X-Hub-Signature-256"), s.WebhookSecret) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	event := r.Header.Get("X-GitHub-Event")
+	if !contains(webhookEvents, event) {
+		// Includes the ping sent when the webhook is created.
+		fmt.Fprintf(w, "ignored %s event\n", event)
+		return
+	}
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid payload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	indexes := webhookServices(s.Services, event, payload)
+	if len(indexes) == 0 {
+		fmt.Fprintf(w, "no service of %s reported\n", payload.Repository.FullName)
+		return
+	}
+	s.Options.logger().Info("webhook event; regenerating services", "event", event, "repo", payload.Repository.FullName, "services", len(indexes))
+	go s.regenerate(ctx, indexes)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "regenerating %s\n", plural(len(indexes), "service"))
+}
+
+// Refetch the given services of the latest report and render it again. The
+// latest report changes only when every service was fetched and the report
+// rendered, so it never mixes in a partial update. Does nothing before the
+// first /latest request, which fetches every service anyway.
+func (s *Server) regenerate(ctx context.Context, indexes []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.latestReports == nil {
+		return
+	}
+	updated := append([]ServiceReport(nil), s.latestReports...)
+	for _, i := range indexes {
+		reports, err := Fetch(ctx, s.Providers, s.Services[i:i+1], s.latestOpts)
+		if err != nil {
+			s.Options.logger().Warn("regenerating service failed", "service", s.Services[i].Service, "error", err)
+			return
+		}
+		updated[i] = reports[0]
+	}
+	body, err := renderReport(updated, s.latestOpts)
+	if err != nil {
+		s.Options.logger().Warn("rendering the latest report failed", "error", err)
+		return
+	}
+	s.latest, s.latestReports = body, updated
+}
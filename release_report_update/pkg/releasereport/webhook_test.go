@@ -0,0 +1,161 @@
+package releasereport
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testWebhookSecret = "It's a Secret to Everybody"
+
+// GitHub's X-Hub-Signature-256 of payload
+func sign(payload, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidWebhookSignature(t *testing.T) {
+	payload := `{"ref":"refs/heads/main","repository":{"full_name":"acme/api"}}`
+	signature := sign(payload, testWebhookSecret)
+	sha1 := "sha1=" + strings.TrimPrefix(signature, "sha256=")
+	tests := []struct {
+		name      string
+		payload   string
+		signature string
+		want      bool
+	}{
+		{"valid", payload, signature, true},
+		{"GitHub's example", "Hello, World!", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", true},
+		{"missing", payload, "", false},
+		{"no prefix", payload, strings.TrimPrefix(signature, "sha256="), false},
+		{"wrong algorithm", payload, sha1, false},
+		{"upper case prefix", payload, "SHA256=" + strings.TrimPrefix(signature, "sha256="), false},
+		{"wrong secret", payload, sign(payload, "another secret"), false},
+		{"tampered body", strings.Replace(payload, "main", "mainx", 1), signature, false},
+		{"truncated", payload, signature[:len(signature)-2], false},
+		{"not hex", payload, "sha256=" + strings.Repeat("zz", sha256.Size), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validWebhookSignature([]byte(tt.payload), tt.signature, testWebhookSecret); got != tt.want {
+				t.Errorf("validWebhookSignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// The digest must be compared in constant time so response times do not
+// leak how much of a forged signature is right: with hmac.Equal, never
+// with == or bytes.Equal.
+func TestValidWebhookSignatureIsConstantTime(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "webhook.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fn *ast.FuncDecl
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Name.Name == "validWebhookSignature" {
+			fn = d
+		}
+	}
+	if fn == nil {
+		t.Fatal("validWebhookSignature not found in webhook.go")
+	}
+	usesHMACEqual := false
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			if pkg, ok := n.X.(*ast.Ident); ok && n.Sel.Name == "Equal" {
+				if pkg.Name != "hmac" {
+					t.Errorf("validWebhookSignature compares with %s.Equal", pkg.Name)
+				}
+				usesHMACEqual = pkg.Name == "hmac"
+			}
+		case *ast.BinaryExpr:
+			if y, ok := n.Y.(*ast.Ident); (n.Op == token.EQL || n.Op == token.NEQ) && !(ok && y.Name == "nil") {
+				t.Errorf("validWebhookSignature compares with %s", n.Op)
+			}
+		}
+		return true
+	})
+	if !usesHMACEqual {
+		t.Error("validWebhookSignature does not compare with hmac.Equal")
+	}
+}
+
+func TestHandleWebhookSignature(t *testing.T) {
+	s := &Server{WebhookSecret: testWebhookSecret}
+	payload := `{"zen":"Design for failure."}`
+	tests := []struct {
+		name      string
+		signature string
+		wantCode  int
+	}{
+		{"valid", sign(payload, testWebhookSecret), http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong secret", sign(payload, "another secret"), http.StatusUnauthorized},
+		{"other payload", sign(payload+" ", testWebhookSecret), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+			r.Header.Set("X-GitHub-Event", "ping")
+			if tt.signature != "" {
+				r.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			w := httptest.NewRecorder()
+			s.handleWebhook(context.Background(), w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
+
+// Provider whose commits come from a map keyed by repository; a repository
+// without an entry fails with errUnauthorized
+type commitsProvider struct {
+	Provider
+	commits map[string][]Commit
+}
+
+func (p commitsProvider) Commits(ctx context.Context, repo, branch, since, until, path string) ([]Commit, error) {
+	commits, ok := p.commits[repo]
+	if !ok {
+		return nil, errUnauthorized
+	}
+	return commits, nil
+}
+
+func TestRegenerateKeepsLatestOnFailure(t *testing.T) {
+	services := []Service{{Service: "api", Repo: "acme/api"}, {Service: "web", Repo: "acme/web"}}
+	old := []ServiceReport{{Service: "api", Repo: "acme/api"}, {Service: "web", Repo: "acme/web"}}
+	s := &Server{
+		Providers: map[string]Provider{ProviderGitHub: commitsProvider{commits: map[string][]Commit{
+			"acme/api": {{SHA: "0123456789abcdef", Message: "fix: retry uploads", Date: "2026-03-02T10:00:00Z"}},
+		}}},
+		Services:      services,
+		latest:        []byte("old report"),
+		latestOpts:    Options{Mode: ModeCommits, StartDate: "2026-03-01", EndDate: "2026-03-31"},
+		latestReports: append([]ServiceReport(nil), old...),
+	}
+
+	s.regenerate(context.Background(), []int{0, 1})
+	if string(s.latest) != "old report" || len(s.latestReports[0].Commits) != 0 {
+		t.Errorf("a failed regeneration changed the latest report: %d commits of api", len(s.latestReports[0].Commits))
+	}
+
+	s.regenerate(context.Background(), []int{0})
+	if string(s.latest) == "old report" || len(s.latestReports[0].Commits) != 1 {
+		t.Errorf("regenerating api did not update the latest report: %d commits of api", len(s.latestReports[0].Commits))
+	}
+}
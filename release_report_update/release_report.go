@@ -1,152 +1,649 @@
-## to run this:
-# go run release_report.go
+// To run this:
+//   go run . [-verbose] [-mode commits|releases|pulls] [-compare BASE..HEAD] [-format html|csv] [-slack-webhook URL] [-changelog DIR]
+//
+// The fetching, aggregation and rendering live in the importable
+// pkg/releasereport package; this command only handles flags, prompting,
+// credentials and which outputs to produce.
+//
+// The period is prompted for unless passed as -start and -end; scheduled jobs
+// pass -non-interactive to never prompt, e.g.
+//   go run . -non-interactive -config /etc/release_report.json -start 2024-05-01 -end 2024-05-14 -output report.html
+// or name it with -range: this-week, last-week, this-month, last-month,
+// iso-week:2025-W14, or this-sprint and last-sprint with a "sprint" section
+// ({"start": "2025-01-06", "length_days": 14}) in config.json.
+//
+// The configuration is config.json, else config.yaml or config.yml, unless
+// -config names another file. YAML files take the same keys as the JSON
+// (see config.txt), e.g.
+//   services:
+//     - service: Payments
+//       repo: ViaXLabs/payments
+//
+// config.schema.json is the JSON Schema of both, for editors and CI; after
+// changing a config struct regenerate it with
+//   go run . -print-config-schema > config.schema.json
+// As a preflight step, -validate-config checks the config file, the
+// credentials (and the scopes of classic tokens) and that every repository
+// is readable, exiting non-zero on any problem without fetching a report.
+//
+// The GitHub token is taken from the -token flag, then the GITHUB_TOKEN
+// environment variable, then `gh auth token` (GitHub CLI). To authenticate as
+// a GitHub App instead, set "github_app" in config.json (app_id,
+// installation_id and private_key_file, or the key itself in the
+// GITHUB_APP_PRIVATE_KEY environment variable); its short-lived installation
+// tokens are renewed automatically.
+// For GitHub Enterprise Server set "api_base_url" in config.json or pass
+// -api-base-url, e.g. https://github.corp.example/api/v3.
+//
+// Services on GitLab set "provider": "gitlab" and use the project path as
+// "repo". The GitLab token is taken from -gitlab-token or GITLAB_TOKEN; for
+// self-managed GitLab set "gitlab_base_url" or pass -gitlab-base-url.
+//
+// With -trace each commit is traced to its pull (merge) request and the
+// tickets either references, for auditors. With Jira credentials ("username"
+// in the "jira" section and the API token in the JIRA_TOKEN environment
+// variable) the table shows each ticket's status too.
+//
+// With a "harness" section (account_id, org, project, and the API key in
+// the HARNESS_API_KEY environment variable) the report lists the Harness
+// pipeline executions of each service in the period: those of the pipelines
+// stored in its repository's .harness/piplines directory, or of the
+// "harness_pipelines" it names, narrowed to its "harness_service" when
+// pipelines deploy several services.
+//
+// With an "argocd" section (base_url, and the API token in the
+// ARGOCD_AUTH_TOKEN environment variable) the report also shows what is
+// live: the revision or chart version, sync and health status of each
+// service's Argo CD application in every environment. Applications are
+// named "{service}-{environment}" unless "application" in the section or a
+// service's "argocd_applications" says otherwise.
+//
+// With -kube the report adds a deployed version matrix without any CD tool:
+// the image tags of the running pods per environment, read from the
+// clusters of the kubeconfig ($KUBECONFIG, else ~/.kube/config). Without a
+// "kubernetes" section every namespace of the current context is an
+// environment; the section maps environments to a context and namespaces.
+// Images belong to the service whose repository they are named after, or
+// whose "images" list them.
+//
+// With -drift a drift table shows, per service and environment, how many
+// commits on the branch and releases are not deployed yet, measured from
+// what runs there: the Argo CD revision, the mapped branch or newest mapped
+// tag, the latest deployment, or the image tag -kube found. Services behind
+// in production (the -dora environment, else the last of "environments")
+// are highlighted as lagging.
+//
+// The HTML report comes in two built-in views of the same data: -view
+// detailed (the default) lists every change, -view executive gives counts,
+// breaking changes and a few highlights per service for leadership. Several
+// views, e.g. -view executive,detailed, are written in one run: the first
+// to -output, the others next to it as release_report-detailed.html.
+//
+// -lang es or -lang fr renders the HTML report, its email subject and
+// Confluence title in Spanish or French, dates included, for partner teams.
+// The strings live in pkg/releasereport/locales, one JSON bundle per
+// language mapping the English text to its translation.
+//
+// With an "smtp" section in config.json the HTML report is also emailed; the
+// SMTP password is best passed in the SMTP_PASSWORD environment variable.
+// With a "confluence" section it is also published as a Confluence page; the
+// API token is best passed in the CONFLUENCE_TOKEN environment variable.
+//
+// Requests go through the proxy of HTTPS_PROXY (honoring NO_PROXY). Behind
+// a proxy that re-signs TLS traffic, add its certificate authority as
+// "ca_file" in the "network" section of config.json, which also takes an
+// explicit "proxy" and "min_tls_version".
+//
+// Dates are days in -timezone (default: the local time zone): a period runs
+// from midnight of its start date to the end of its end date there, and
+// timestamps in the report are shown in it.
+//
+// With many services, -graphql fetches the GitHub commits, pull requests and
+// deployments of ten repositories per GraphQL query, leaving the rest (and
+// repositories with more than 100 of them) to the REST API.
+//
+// Commit messages, titles and author names are reported as plain text:
+// control characters and invisible direction overrides are dropped, markup
+// is escaped, and subjects are cut to -max-subject-length characters.
+//
+// Progress, warnings and errors are logged to stderr, as text or, with
+// -log-format json, one JSON object per line for CI log search. With
+// -log-level debug (or -verbose) every API request is logged too, with its
+// status, duration and remaining rate limit quota. Services without a single
+// change in the period are logged as warnings.
+//
+// The exit status is 0 for a complete report, 3 when the report was written
+// but the data of some services is unavailable (they are marked "data
+// unavailable" in it), 2 for invalid flags and 1 when no report could be
+// made. -fail-fast stops at the first unavailable service instead, with 1.
+//
+// API responses are cached in -cache-dir (by default below the user cache
+// directory) and revalidated with ETags, so reruns mostly get 304 Not
+// Modified answers, which GitHub does not count against the rate limit.
+// Entries unused for 30 days are removed. Pass -no-cache to bypass the
+// cache.
+//
+// With -history DIR every run is archived in DIR as JSON, and the report
+// shows each service's change volume against the latest archived period
+// before it, e.g. the previous sprint.
+//
+// With -serve ADDR the tool runs as an HTTP server instead of writing a
+// report: GET /report?start=&end=&range=&format=&mode=&view=&lang= generates one on
+// demand and GET /latest returns the last 14 days, regenerated at most once
+// per -serve-ttl. Emailing, Slack and the other outputs are skipped. With
+// -webhook-secret (or GITHUB_WEBHOOK_SECRET) a GitHub webhook sending push,
+// release and deployment events to POST /webhook refreshes the services they
+// change in the latest report.
 
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"html/template"
-	"io/ioutil"
-	"net/http"
+	"log/slog"
 	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
 	"time"
+
+	"github.com/ViaXLabs/tools/release_report_update/pkg/releasereport"
 )
 
-// Struct for service configuration
-type Service struct {
-	Service string `json:"service"`
-	Repo    string `json:"repo"`
+// Leveled, structured process logger (see -log-format and -log-level)
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// Resolve the GitHub token from the -token flag, the GITHUB_TOKEN
+// environment variable or the GitHub CLI, in that order
+func resolveToken(flagToken string) (string, error) {
+	if flagToken != "" {
+		return flagToken, nil
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token, nil
+	}
+	if ghPath, err := exec.LookPath("gh"); err == nil {
+		var stdout bytes.Buffer
+		cmd := exec.Command(ghPath, "auth", "token")
+		cmd.Stdout = &stdout
+		if cmd.Run() == nil {
+			if token := strings.TrimSpace(stdout.String()); token != "" {
+				return token, nil
+			}
+		}
+	}
+	return "", errors.New("no GitHub token found: pass -token, set GITHUB_TOKEN or run `gh auth login`")
 }
 
-// Struct for GitHub commit data
-type Commit struct {
-	SHA     string `json:"sha"`
-	Message string `json:"commit"`
-	URL     string `json:"html_url"`
-	Date    string `json:"date"`
+// Resolve the GitLab token from the -gitlab-token flag or the GITLAB_TOKEN
+// environment variable
+func resolveGitLabToken(flagToken string) (string, error) {
+	if flagToken != "" {
+		return flagToken, nil
+	}
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+		return token, nil
+	}
+	return "", errors.New("no GitLab token found: pass -gitlab-token or set GITLAB_TOKEN")
 }
 
-// Load service configuration from config.json
-func loadConfig(filename string) ([]Service, error) {
-	file, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, err
+// API root from the flag, else the config, else the default, without a
+// trailing slash
+func baseURL(flagValue, configValue, defaultValue string) string {
+	for _, value := range []string{flagValue, configValue} {
+		if value != "" {
+			return strings.TrimSuffix(value, "/")
+		}
 	}
+	return defaultValue
+}
 
-	var config struct {
-		Services []Service `json:"services"`
+// Build the process logger for the requested format and level
+func newLogger(format, level string, verbose bool) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid -log-level %q: use debug, info, warn or error", level)
+	}
+	if verbose {
+		lvl = slog.LevelDebug
+	}
+	handlerOpts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, handlerOpts)), nil
 	}
-	err = json.Unmarshal(file, &config)
-	return config.Services, err
+	return nil, fmt.Errorf("invalid -log-format %q: use text or json", format)
 }
 
-// Fetch commits from GitHub API
-func fetchGithubCommits(repo string, startDate, endDate string) ([]Commit, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/commits?since=%s&until=%s", repo, startDate, endDate)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Authorization", "token YOUR_GITHUB_TOKEN")
+// Exit statuses
+const (
+	exitFailed  = 1 // no report: bad configuration or credentials, or a fatal API error
+	exitUsage   = 2 // invalid flags or dates
+	exitPartial = 3 // report written, but the data of some services is unavailable
+)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// Log an error and exit without a report
+func fail(msg string, args ...interface{}) {
+	logger.Error(msg, args...)
+	os.Exit(exitFailed)
+}
 
-	var commits []Commit
-	err = json.NewDecoder(resp.Body).Decode(&commits)
-	return commits, err
+// Log an invalid flag and exit
+func failUsage(msg string, args ...interface{}) {
+	logger.Error(msg, args...)
+	os.Exit(exitUsage)
 }
 
-// Generate and save the HTML report
-func generateHTMLReport(services []Service, startDate, endDate string) {
-	const templateHTML = `
-<!DOCTYPE html>
-<html>
-<head>
-	<title>Release Report</title>
-	<style>
-		body { font-family: Arial, sans-serif; margin: 20px; }
-		.container { max-width: 900px; margin: auto; background: white; padding: 20px; }
-		.section { margin-bottom: 30px; }
-		.service { font-weight: bold; color: #0073e6; }
-		.commit { color: #ff9800; }
-		.commit-link { text-decoration: none; color: #0073e6; }
-	</style>
-</head>
-<body>
-	<div class="container">
-		<h1>🚀 Release Report - {{.Date}}</h1>
-
-		<!-- Summary Report by Environment -->
-		<div class="section">
-			<h2>📌 Summary Report by Environment</h2>
-			{{range .Services}}
-				<h3 class="service">{{.Service}}</h3>
-				<ul>
-				{{range .Commits}}
-					<li class="commit"><a href="{{.URL}}" class="commit-link">{{.Message}}</a> - {{.Date}}</li>
-				{{end}}
-				</ul>
-			{{end}}
-		</div>
-	</div>
-</body>
-</html>`
-
-	reportFile, err := os.Create("release_report.html")
-	if err != nil {
-		fmt.Println("Error creating HTML file:", err)
-		return
+// Whether value is one of values
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Main function to execute the report generation
+func main() {
+	tokenFlag := flag.String("token", "", "GitHub token (default: $GITHUB_TOKEN, then `gh auth token`)")
+	gitlabTokenFlag := flag.String("gitlab-token", "", "GitLab token (default: $GITLAB_TOKEN)")
+	verbose := flag.Bool("verbose", false, "Log every API request with its status and remaining quota (same as -log-level debug)")
+	logFormat := flag.String("log-format", "text", "Log output format: text or json")
+	logLevel := flag.String("log-level", "info", "Minimum log level: debug, info, warn or error")
+	subjectLimit := flag.Int("max-subject-length", releasereport.DefaultSubjectLimit, "Characters of a commit subject shown in the report and changelogs before it is cut short with \"…\" (0: no limit)")
+	timeout := flag.Duration("timeout", releasereport.DefaultRequestTimeout, "Timeout of each API request")
+	apiBaseURL := flag.String("api-base-url", "", "GitHub API root, e.g. https://github.corp.example/api/v3 (default: api_base_url from the config, else "+releasereport.DefaultAPIBaseURL+")")
+	mode := flag.String("mode", releasereport.ModeCommits, "Report on "+releasereport.ModeCommits+", on the "+releasereport.ModeReleases+" (GitHub/GitLab Releases) published or on the "+releasereport.ModePulls+" (pull/merge requests) merged in the period")
+	graphQL := flag.Bool("graphql", false, "Fetch GitHub commits, pull requests and deployments of many repositories per GraphQL query instead of one REST call (or more) each")
+	trace := flag.Bool("trace", false, "Add a traceability table: each commit with its pull (merge) request, the tickets they reference and the tickets' Jira status (one extra request per commit)")
+	stats := flag.Bool("stats", false, "Add the lines and files each commit changed, with totals and the largest changes per service")
+	issues := flag.Bool("issues", false, "List the issues closed by the pull (merge) requests merged in the period, per service")
+	metrics := flag.Bool("metrics", false, "Add release metrics: releases per week, commits per release and days since the last release")
+	signatures := flag.Bool("signatures", false, "Flag commits without a verified signature (one extra request per commit on GitLab)")
+	dora := flag.Bool("dora", false, "Add DORA metrics: deployment frequency and lead time for changes, from the Deployments API")
+	drift := flag.Bool("drift", false, "Add a drift table: per environment, the commits and releases on each service's branch not yet deployed (from Argo CD, the environment mapping, the Deployments API or -kube), flagging services lagging in production")
+	kube := flag.Bool("kube", false, "Scan the Kubernetes clusters of the kubeconfig for the image tags each service runs per environment (see \"kubernetes\" in the config) and add a deployed version matrix")
+	contributors := flag.Bool("contributors", false, "Add contributor statistics: commits per author, new contributors and busiest services")
+	validateConfig := flag.Bool("validate-config", false, "Check the config file, the credentials (and token scopes) and access to every repository, then exit without generating a report")
+	printSchema := flag.Bool("print-config-schema", false, "Print a JSON Schema of the config file to stdout and exit")
+	configFile := flag.String("config", "", "Report configuration file, JSON or YAML (default: config.json, else config.yaml or config.yml)")
+	output := flag.String("output", "", "Report file to write (default: release_report.<format>)")
+	start := flag.String("start", "", "First day of the period, YYYY-MM-DD (default: prompted for, else 14 days ago)")
+	end := flag.String("end", "", "Last day of the period, YYYY-MM-DD (default: prompted for, else today)")
+	rangePreset := flag.String("range", "", "Named period instead of -start and -end: "+strings.Join(releasereport.RangePresets, ", ")+" or iso-week:YYYY-Www; sprints follow \"sprint\" in the config")
+	templateFile := flag.String("template", "", "HTML report template file (default: the built-in -view)")
+	view := flag.String("view", "", "Built-in HTML report: "+releasereport.ViewDetailed+" (every change, the default) or "+releasereport.ViewExecutive+" (counts and highlights); several, comma-separated, are written next to -output, e.g. release_report-executive.html")
+	lang := flag.String("lang", releasereport.DefaultLanguage, "Language of the HTML report strings and dates: "+strings.Join(releasereport.ReportLanguages, ", "))
+	nonInteractive := flag.Bool("non-interactive", false, "Never prompt: take the period from -start and -end or their defaults, for scheduled jobs")
+	failFast := flag.Bool("fail-fast", false, "Stop without a report at the first service whose data cannot be fetched, instead of marking it \"data unavailable\" and exiting with status 3")
+	concurrency := flag.Int("concurrency", releasereport.DefaultConcurrency, "Number of services fetched in parallel")
+	cacheDir := flag.String("cache-dir", releasereport.DefaultCacheDir(), "Directory of the API response cache, revalidated with ETags")
+	noCache := flag.Bool("no-cache", false, "Do not read or write the API response cache")
+	compare := flag.String("compare", "", "Report the commits between two refs, e.g. v1.2.0..v1.3.0, instead of a date period (services can set their own \"compare\")")
+	format := flag.String("format", "html", "Report format: "+strings.Join(releasereport.ReportFormats, " or ")+" (one row per commit)")
+	slackWebhook := flag.String("slack-webhook", "", "Slack incoming webhook URL to post a summary to after generation (default: $SLACK_WEBHOOK_URL)")
+	reportURL := flag.String("report-url", "", "URL of the published report, linked from the Slack summary")
+	changelogDir := flag.String("changelog", "", "Also add a Keep a Changelog section per service to DIR/<service>/CHANGELOG.md")
+	changelogVersion := flag.String("changelog-version", "", "Release heading of the changelog sections and tag of the draft releases (default: the head ref of -compare, else the end date)")
+	publishNotes := flag.Bool("publish-release-notes", false, "Create or update a draft GitHub Release per service with its notable changes (the token needs write access)")
+	upload := flag.String("upload", "", "Also upload the report to s3://bucket/prefix or gs://bucket/prefix, below a timestamped key (needs the aws or gcloud CLI)")
+	uploadLatest := flag.Bool("upload-latest", false, "With -upload, also store the report as latest/<file>")
+	historyDir := flag.String("history", "", "Directory archiving every run as JSON; the report then compares each service's change volume with the previous archived period")
+	timezone := flag.String("timezone", "", "IANA time zone of the report dates and shown timestamps, e.g. Europe/Berlin or UTC (default: the local time zone)")
+	serve := flag.String("serve", "", "Serve reports over HTTP on this address, e.g. :8080, instead of writing one: /report?start=&end=&range=&format=&mode=&view=&lang= and /latest")
+	webhookSecret := flag.String("webhook-secret", "", "Secret of the GitHub webhook whose push, release and deployment deliveries to /webhook refresh -serve's latest report (default: $GITHUB_WEBHOOK_SECRET)")
+	serveTTL := flag.Duration("serve-ttl", time.Hour, "How long -serve answers /latest from the last generated report")
+	gitlabBaseURL := flag.String("gitlab-base-url", "", "GitLab API root, e.g. https://gitlab.corp.example/api/v4 (default: gitlab_base_url from the config, else "+releasereport.DefaultGitLabBaseURL+")")
+	flag.Parse()
+
+	var err error
+	if logger, err = newLogger(*logFormat, *logLevel, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
 	}
-	defer reportFile.Close()
 
-	tmpl, _ := template.New("report").Parse(templateHTML)
-	reportData := struct {
-		Date     string
-		Services []struct {
-			Service string
-			Commits []Commit
+	if *printSchema {
+		schema, err := releasereport.ConfigSchema()
+		if err != nil {
+			fail("building the config schema failed", "error", err)
+		}
+		os.Stdout.Write(schema)
+		return
+	}
+	location := time.Local
+	if *timezone != "" {
+		if location, err = time.LoadLocation(*timezone); err != nil {
+			failUsage("unknown -timezone (want an IANA name such as Europe/Berlin)", "timezone", *timezone)
 		}
-	}{
-		Date:     time.Now().Format("January 2, 2006"),
-		Services: []struct {
-			Service string
-			Commits []Commit
-		}{},
 	}
 
-	for _, service := range services {
-		commits, _ := fetchGithubCommits(service.Repo, startDate, endDate)
-		reportData.Services = append(reportData.Services, struct {
-			Service string
-			Commits []Commit
-		}{service.Service, commits})
+	if !contains(releasereport.ReportFormats, *format) {
+		failUsage("unknown -format", "format", *format, "want", strings.Join(releasereport.ReportFormats, " or "))
 	}
 
-	tmpl.Execute(reportFile, reportData)
-	fmt.Println("✅ HTML Release Report generated successfully!")
-}
+	if *concurrency < 1 {
+		failUsage("-concurrency must be at least 1")
+	}
 
-// Main function to execute the report generation
-func main() {
-	services, err := loadConfig("config.json")
+	if !contains(releasereport.ReportModes, *mode) {
+		failUsage("unknown -mode", "mode", *mode, "want", strings.Join(releasereport.ReportModes, ", "))
+	}
+	if *mode != releasereport.ModeCommits && *contributors {
+		failUsage("-contributors needs -mode " + releasereport.ModeCommits)
+	}
+	if *mode != releasereport.ModeCommits && *signatures {
+		failUsage("-signatures needs -mode " + releasereport.ModeCommits)
+	}
+	if *mode != releasereport.ModeCommits && *trace {
+		failUsage("-trace needs -mode " + releasereport.ModeCommits)
+	}
+	if *mode != releasereport.ModeCommits && *stats {
+		failUsage("-stats needs -mode " + releasereport.ModeCommits)
+	}
+	if *mode != releasereport.ModeCommits && *metrics {
+		failUsage("-metrics needs -mode " + releasereport.ModeCommits)
+	}
+	if *mode != releasereport.ModeCommits && *changelogDir != "" {
+		failUsage("-changelog needs -mode " + releasereport.ModeCommits)
+	}
+	if *mode != releasereport.ModeCommits && *publishNotes {
+		failUsage("-publish-release-notes needs -mode " + releasereport.ModeCommits)
+	}
+
+	var uploadTo releasereport.UploadTarget
+	if *upload != "" {
+		var err error
+		if uploadTo, err = releasereport.ParseUploadTarget(*upload); err == nil {
+			err = uploadTo.Check()
+		}
+		if err != nil {
+			failUsage("invalid -upload", "error", err)
+		}
+	} else if *uploadLatest {
+		failUsage("-upload-latest needs -upload")
+	}
+	var views []string
+	for _, name := range strings.Split(*view, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if !contains(releasereport.ReportViews, name) {
+			failUsage("unknown -view", "view", name, "want", strings.Join(releasereport.ReportViews, " or "))
+		}
+		if !contains(views, name) {
+			views = append(views, name)
+		}
+	}
+	locale, err := releasereport.LoadLocale(*lang)
 	if err != nil {
-		fmt.Println("Error loading config:", err)
+		failUsage("unknown -lang", "lang", *lang, "want", strings.Join(releasereport.ReportLanguages, ", "))
+	}
+	if len(views) > 0 && *format != "html" {
+		failUsage("-view needs -format html")
+	}
+	if *templateFile != "" {
+		if *view != "" {
+			failUsage("-template replaces -view")
+		}
+		if _, err := releasereport.ParseTemplate(*templateFile); err != nil {
+			failUsage("invalid -template", "error", err)
+		}
+	}
+	if *compare != "" {
+		if _, _, err := releasereport.ParseRefRange(*compare); err != nil {
+			failUsage("invalid -compare", "error", err)
+		}
+	}
+	if *rangePreset != "" && (*start != "" || *end != "") {
+		failUsage("-range replaces -start and -end")
+	}
+	for _, date := range []struct{ flag, value string }{{"-start", *start}, {"-end", *end}} {
+		if date.value != "" && !releasereport.ValidDate(date.value) {
+			failUsage("not a date (want YYYY-MM-DD)", "flag", date.flag, "value", date.value)
+		}
+	}
+
+	if *configFile == "" {
+		*configFile = releasereport.FindConfig()
+	}
+	config, err := releasereport.LoadConfig(*configFile)
+	if err != nil {
+		fail("loading config failed", "file", *configFile, "error", err)
+	}
+	if problems := config.Validate(); len(problems) > 0 {
+		for _, problem := range problems {
+			logger.Error("invalid config", "file", *configFile, "problem", problem)
+		}
+		os.Exit(exitFailed)
+	}
+	// Dates are only asked for when a service has no ref range to compare.
+	needDates := false
+	for _, service := range config.Services {
+		if service.RefRange(*compare) == "" {
+			needDates = true
+		} else if *mode != releasereport.ModeCommits {
+			failUsage("-compare and \"compare\" need -mode " + releasereport.ModeCommits)
+		}
+	}
+
+	// Fail fast before prompting when no credentials are available.
+	client, err := releasereport.NewHTTPClient(*timeout, config.Network)
+	if err != nil {
+		fail("creating the HTTP client failed", "error", err)
+	}
+	providers := make(map[string]releasereport.Provider)
+	if releasereport.UsesProvider(config.Services, releasereport.ProviderGitHub) && config.GitHubApp != nil {
+		github, err := releasereport.NewGitHubApp(client, baseURL(*apiBaseURL, config.APIBaseURL, releasereport.DefaultAPIBaseURL), *config.GitHubApp)
+		if err != nil {
+			fail("setting up the GitHub App failed", "error", err)
+		}
+		providers[releasereport.ProviderGitHub] = github
+	} else if releasereport.UsesProvider(config.Services, releasereport.ProviderGitHub) {
+		token, err := resolveToken(*tokenFlag)
+		if err != nil {
+			fail("no GitHub credentials", "error", err)
+		}
+		providers[releasereport.ProviderGitHub] = releasereport.NewGitHub(client, baseURL(*apiBaseURL, config.APIBaseURL, releasereport.DefaultAPIBaseURL), token)
+	}
+	if github, ok := providers[releasereport.ProviderGitHub]; ok && *graphQL {
+		providers[releasereport.ProviderGitHub] = releasereport.WithGraphQL(github)
+	}
+	if releasereport.UsesProvider(config.Services, releasereport.ProviderGitLab) {
+		token, err := resolveGitLabToken(*gitlabTokenFlag)
+		if err != nil {
+			fail("no GitLab credentials", "error", err)
+		}
+		providers[releasereport.ProviderGitLab] = releasereport.NewGitLab(client, baseURL(*gitlabBaseURL, config.GitLabBaseURL, releasereport.DefaultGitLabBaseURL), token)
+	}
+
+	opts := releasereport.Options{Mode: *mode, Format: *format, Compare: *compare, Jira: config.Jira, Contributors: *contributors, Metrics: *metrics, Signatures: *signatures, Issues: *issues, Stats: *stats, Trace: *trace, Drift: *drift, Environments: config.Environments, Filter: config.CommitFilter, PullSections: config.PullSections, FailFast: *failFast, Concurrency: *concurrency, Output: *output, Template: *templateFile, Views: views, Language: *lang, Theme: config.Theme, History: *historyDir, SubjectLimit: *subjectLimit, Location: location, Logger: logger}
+	if opts.SubjectLimit == 0 {
+		opts.SubjectLimit = -1 // no limit
+	}
+	if !*noCache {
+		opts.CacheDir = *cacheDir
+	}
+
+	// Ctrl-C cancels in-flight requests and retry waits. The context also
+	// carries the logger and cache to the calls taking no options.
+	ctx, stop := signal.NotifyContext(opts.Context(context.Background()), os.Interrupt)
+	defer stop()
+	if *validateConfig {
+		logger.Info("config is valid", "file", *configFile, "services", len(config.Services))
+		if !releasereport.Preflight(ctx, providers, config.Services, opts) {
+			fail("preflight checks failed", "file", *configFile)
+		}
+		return
+	}
+	if *trace && config.Jira != nil {
+		if config.Jira.HasToken() {
+			opts.JiraClient = releasereport.NewJiraClient(client, *config.Jira)
+		} else {
+			logger.Info("no Jira token (JIRA_TOKEN or jira.token); tickets are traced without their status")
+		}
+	}
+	if config.Harness != nil {
+		opts.Harness = releasereport.NewHarnessClient(client, *config.Harness)
+	}
+	if config.ArgoCD != nil {
+		opts.ArgoCD = releasereport.NewArgoCDClient(client, *config.ArgoCD)
+	}
+	if *kube {
+		kubernetes := releasereport.KubernetesConfig{}
+		if config.Kubernetes != nil {
+			kubernetes = *config.Kubernetes
+		}
+		if opts.Kubernetes, err = releasereport.NewKubernetesScanner(kubernetes, *timeout); err != nil {
+			fail("loading the kubeconfig failed", "error", err)
+		}
+	}
+	if *dora {
+		opts.DORAEnvironment = config.DORAEnvironment
+		if opts.DORAEnvironment == "" && len(config.Environments) > 0 {
+			opts.DORAEnvironment = config.Environments[len(config.Environments)-1]
+		}
+		if opts.DORAEnvironment == "" {
+			opts.DORAEnvironment = releasereport.DefaultDORAEnvironment
+		}
+	}
+
+	if *serve != "" {
+		if *webhookSecret == "" {
+			*webhookSecret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+		}
+		server := &releasereport.Server{Providers: providers, Services: config.Services, Options: opts, LatestTTL: *serveTTL, Sprint: config.Sprint, WebhookSecret: *webhookSecret}
+		if err := server.ListenAndServe(ctx, *serve); err != nil {
+			fail("serving reports failed", "error", err)
+		}
 		return
 	}
 
 	// Default date range (last 14 days)
-	startDate := time.Now().AddDate(0, 0, -14).Format("2006-01-02")
-	endDate := time.Now().Format("2006-01-02")
+	startDate, endDate := releasereport.DefaultPeriod(time.Now().In(location))
+	if *start != "" {
+		startDate = *start
+	}
+	if *end != "" {
+		endDate = *end
+	}
+	if *rangePreset != "" {
+		if startDate, endDate, err = releasereport.ResolveRange(*rangePreset, time.Now().In(location), config.Sprint); err != nil {
+			failUsage("invalid -range", "error", err)
+		}
+	}
 
-	// Allow user input for date range
-	fmt.Printf("Enter start date (YYYY-MM-DD) [Default: %s]: ", startDate)
-	fmt.Scanln(&startDate)
-	fmt.Printf("Enter end date (YYYY-MM-DD) [Default: %s]: ", endDate)
-	fmt.Scanln(&endDate)
+	// Allow user input for the dates not passed as flags
+	if needDates && !*nonInteractive && *rangePreset == "" {
+		if *start == "" {
+			fmt.Printf("Enter start date (YYYY-MM-DD) [Default: %s]: ", startDate)
+			fmt.Scanln(&startDate)
+		}
+		if *end == "" {
+			fmt.Printf("Enter end date (YYYY-MM-DD) [Default: %s]: ", endDate)
+			fmt.Scanln(&endDate)
+		}
+	}
+	if needDates {
+		if err := releasereport.CheckPeriod(startDate, endDate); err != nil {
+			failUsage("invalid period", "error", err)
+		}
+	}
+	opts.StartDate, opts.EndDate = startDate, endDate
 
 	// Generate the report
-	generateHTMLReport(services, startDate, endDate)
+	reports, reportFile, err := releasereport.Generate(ctx, providers, config.Services, opts)
+	if err != nil {
+		fail("generating the report failed", "error", err)
+	}
+
+	version := *changelogVersion
+	if version == "" {
+		version = endDate
+		if *compare != "" {
+			_, version, _ = releasereport.ParseRefRange(*compare)
+		}
+	}
+	if *changelogDir != "" {
+		if err := releasereport.UpdateChangelogs(*changelogDir, reports, version, endDate, opts); err != nil {
+			logger.Warn("updating changelogs failed", "dir", *changelogDir, "error", err)
+		}
+	}
+	if *publishNotes {
+		releasereport.PublishReleaseNotes(ctx, providers, config.Services, reports, version, opts)
+	}
+
+	if *upload != "" {
+		uploaded, err := releasereport.UploadReport(ctx, uploadTo, reportFile, time.Now(), *uploadLatest)
+		for _, object := range uploaded {
+			logger.Info("report uploaded", "object", object)
+		}
+		if err != nil {
+			logger.Warn("uploading the report failed", "error", err)
+		}
+	}
+
+	if config.SMTP != nil {
+		var html bytes.Buffer
+		err := releasereport.RenderHTML(&html, reports, opts)
+		if err == nil {
+			subject := config.SMTP.Subject
+			if subject == "" {
+				subject = locale.Text("Release Report %s", opts.Period())
+			}
+			err = releasereport.SendEmail(ctx, *config.SMTP, *timeout, subject, html.Bytes())
+		}
+		if err != nil {
+			logger.Warn("emailing the report failed", "error", err)
+		} else {
+			logger.Info("report emailed", "to", strings.Join(append(append([]string{}, config.SMTP.To...), config.SMTP.Cc...), ", "))
+		}
+	}
+
+	if config.Confluence != nil {
+		var html bytes.Buffer
+		pageURL := ""
+		err := releasereport.RenderHTML(&html, reports, opts)
+		if err == nil {
+			title := config.Confluence.Title
+			if title == "" {
+				title = locale.Text("Release Report %s", opts.Period())
+			}
+			pageURL, err = releasereport.PublishToConfluence(ctx, client, *config.Confluence, title, html.Bytes())
+		}
+		if err != nil {
+			logger.Warn("publishing to Confluence failed", "error", err)
+		} else {
+			logger.Info("report published to Confluence", "url", pageURL)
+		}
+	}
+
+	if *slackWebhook == "" {
+		*slackWebhook = os.Getenv("SLACK_WEBHOOK_URL")
+	}
+	if *slackWebhook != "" {
+		summary := releasereport.SlackSummary(reports, opts, *reportURL, reportFile)
+		if err := releasereport.PostSlackSummary(ctx, client, *slackWebhook, summary); err != nil {
+			logger.Warn("posting the Slack summary failed", "error", err)
+		} else {
+			logger.Info("summary posted to Slack")
+		}
+	}
+
+	// A partial report is still delivered, but fails the run.
+	var unavailable []string
+	for _, report := range reports {
+		if len(report.Errors) > 0 {
+			unavailable = append(unavailable, report.Service)
+		}
+	}
+	if len(unavailable) > 0 {
+		logger.Error("data of some services is unavailable", "services", strings.Join(unavailable, ", "))
+		os.Exit(exitPartial)
+	}
 }
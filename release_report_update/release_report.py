@@ -1,5 +1,8 @@
 import requests
 import json
+import os
+import subprocess
+import sys
 import boto3
 import datetime
 
@@ -7,6 +10,22 @@ import datetime
 with open("config.json") as config_file:
     config = json.load(config_file)
 
+# GitHub token: GITHUB_TOKEN environment variable, else the GitHub CLI
+def resolve_github_token():
+    """Return the GitHub token from GITHUB_TOKEN or `gh auth token`."""
+    token = os.environ.get("GITHUB_TOKEN")
+    if token:
+        return token
+    try:
+        token = subprocess.run(["gh", "auth", "token"], capture_output=True, text=True).stdout.strip()
+    except OSError:
+        token = ""
+    if not token:
+        sys.exit("Error: no GitHub token found: set GITHUB_TOKEN or run `gh auth login`")
+    return token
+
+GITHUB_TOKEN = resolve_github_token()
+
 # Default date range for commit history (Last 14 days)
 DEFAULT_START_DATE = (datetime.datetime.utcnow() - datetime.timedelta(days=14)).strftime("%Y-%m-%d")
 DEFAULT_END_DATE = datetime.datetime.utcnow().strftime("%Y-%m-%d")
@@ -19,10 +38,12 @@ end_date = input(f"Enter end date (YYYY-MM-DD) [Default: {DEFAULT_END_DATE}]: ")
 def fetch_github_commits(repo):
     """Retrieve commit history for a specific GitHub repository."""
     url = f"https://api.github.com/repos/{repo}/commits?since={start_date}&until={end_date}"
-    headers = {"Authorization": f"token YOUR_GITHUB_TOKEN"}
+    headers = {"Authorization": f"token {GITHUB_TOKEN}"}
 
     response = requests.get(url, headers=headers)
 
+    if response.status_code == 401:
+        sys.exit("Error: GitHub rejected the token (401 Unauthorized); check GITHUB_TOKEN or `gh auth status`")
     if response.status_code == 200:
         return response.json()  # Return commit data as JSON
     else: